@@ -10,30 +10,65 @@ const docTemplate = `{
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {
-            "name": "API Support",
-            "url": "http://www.swagger.io/support",
-            "email": "support@swagger.io"
-        },
+        "contact": {},
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/create": {
-            "post": {
-                "description": "Created new user",
-                "consumes": [
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Public keys for offline token verification. Empty when tokens are signed with a shared secret.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "well-known"
+                ],
+                "summary": "Token signing keys",
+                "operationId": "jwks",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.JWKSet"
+                        }
+                    }
+                }
+            }
+        },
+        "/.well-known/openid-configuration": {
+            "get": {
+                "description": "Provider metadata for OpenID Connect relying parties. Endpoints are relative to the configured issuer.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "well-known"
                 ],
-                "summary": "Created new user",
-                "operationId": "create",
+                "summary": "OpenID Connect discovery",
+                "operationId": "openidConfiguration",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.OpenIDConfiguration"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/lockouts": {
+            "get": {
+                "description": "List the accounts and client addresses locked out after too many failed logins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List lockouts",
+                "operationId": "adminLockouts",
                 "parameters": [
                     {
                         "type": "string",
@@ -48,36 +83,80 @@ const docTemplate = `{
                         "name": "refresh_token",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "request body",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/requests.CreateUser"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "ok",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.Lockout"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "bad request",
+                    "403": {
+                        "description": "forbidden",
                         "schema": {
                             "$ref": "#/definitions/response.Error"
                         }
                     },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/lockouts/{id}": {
+            "delete": {
+                "description": "Forget the failed logins of an account or client address, ending its lockout and login delays.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unlock account or address",
+                "operationId": "adminUnlock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "lockout id, user:\u003cuser id\u003e or ip:\u003caddress\u003e",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
                     "403": {
                         "description": "forbidden",
                         "schema": {
                             "$ref": "#/definitions/response.Error"
                         }
                     },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
                     "500": {
                         "description": "internal error",
                         "schema": {
@@ -87,20 +166,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/i": {
-            "post": {
-                "description": "returning user info",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/policies": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin"
                 ],
-                "summary": "Get user info",
-                "operationId": "GetUserInfo",
+                "summary": "List policy rules",
+                "operationId": "adminPolicies",
                 "parameters": [
                     {
                         "type": "string",
@@ -121,13 +196,10 @@ const docTemplate = `{
                     "200": {
                         "description": "ok",
                         "schema": {
-                            "$ref": "#/definitions/response.User"
-                        }
-                    },
-                    "400": {
-                        "description": "bad request",
-                        "schema": {
-                            "$ref": "#/definitions/response.Error"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.PolicyRule"
+                            }
                         }
                     },
                     "403": {
@@ -145,9 +217,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Authenticate and authorized user. Return access and refresh tokens in cookies.",
+        "/admin/policies/{id}": {
+            "put": {
+                "description": "Define a rule of the policy Check RPC, it applies to the next checks.",
                 "consumes": [
                     "application/json"
                 ],
@@ -155,24 +227,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Authorized user",
-                "operationId": "login",
+                "summary": "Create or replace policy rule",
+                "operationId": "adminSavePolicy",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "redirect uri",
-                        "name": "redirect_uri",
-                        "in": "query"
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "rule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "description": "request body",
-                        "name": "login",
+                        "name": "rule",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/requests.Login"
+                            "$ref": "#/definitions/requests.PolicyRule"
                         }
                     }
                 ],
@@ -180,17 +267,7 @@ const docTemplate = `{
                     "200": {
                         "description": "ok",
                         "schema": {
-                            "$ref": "#/definitions/response.TokenPair"
-                        },
-                        "headers": {
-                            "access_token": {
-                                "type": "string",
-                                "description": "token for access services"
-                            },
-                            "refresh_token": {
-                                "type": "string",
-                                "description": "token for refresh access_token"
-                            }
+                            "$ref": "#/definitions/response.PolicyRule"
                         }
                     },
                     "400": {
@@ -205,10 +282,57 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.Error"
                         }
                     },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete policy rule",
+                "operationId": "adminDeletePolicy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "rule id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
                     "404": {
-                        "description": "404 page not found",
+                        "description": "not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
@@ -220,29 +344,17 @@ const docTemplate = `{
                 }
             }
         },
-        "/logout": {
-            "post": {
-                "security": [
-                    {
-                        "Auth": []
-                    }
-                ],
-                "description": "Clears access and refresh tokens",
+        "/admin/roles": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Clears tokens",
-                "operationId": "logout",
+                "summary": "List roles",
+                "operationId": "adminRoles",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "redirect uri",
-                        "name": "redirect_uri",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "access token",
@@ -260,33 +372,43 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "ok"
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.Role"
+                            }
+                        }
                     },
-                    "302": {
-                        "description": "redirect"
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
                     },
                     "500": {
-                        "description": "internal error"
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
                     }
                 }
             }
         },
-        "/validate": {
-            "post": {
-                "security": [
-                    {
-                        "Auth": []
-                    }
+        "/admin/roles/{name}": {
+            "put": {
+                "description": "Define the permissions of a role. Users holding it get them with their next access token.",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Validate tokens and refresh tokens if refresh token is valid",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "Validate tokens",
-                "operationId": "Validate",
+                "summary": "Create or replace role",
+                "operationId": "adminSaveRole",
                 "parameters": [
                     {
                         "type": "string",
@@ -301,88 +423,4711 @@ const docTemplate = `{
                         "name": "refresh_token",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.Role"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "ok",
                         "schema": {
-                            "$ref": "#/definitions/response.TokenPair"
+                            "$ref": "#/definitions/response.Role"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "403": {
                         "description": "forbidden",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     },
                     "500": {
                         "description": "internal error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/response.Error"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "requests.CreateUser": {
-            "type": "object",
-            "required": [
-                "email",
-                "first_name",
-                "last_name",
-                "password",
-                "username"
-            ],
+            },
+            "delete": {
+                "description": "Delete a role definition. Users keep the role name but it no longer grants permissions.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete role",
+                "operationId": "adminDeleteRole",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts": {
+            "get": {
+                "description": "Service accounts by name, all of them or those of one owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List service accounts",
+                "operationId": "adminServiceAccounts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id or organization id of the owner",
+                        "name": "owner",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.ServiceAccount"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a principal without a password for automation, owned by a user or an organization. It signs in with an API key or an RFC 7523 assertion at the token endpoint once one is added.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create service account",
+                "operationId": "adminCreateServiceAccount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "account",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.CreateServiceAccount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServiceAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, unknown owner or role",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get service account",
+                "operationId": "adminServiceAccount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServiceAccount"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/api-keys": {
+            "post": {
+                "description": "Creates a key the account sends as Authorization: Bearer header. The key is shown only this once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Create service account API key",
+                "operationId": "adminCreateServiceAccountAPIKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ServiceAccountAPIKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServiceAccountAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/api-keys/{keyID}": {
+            "delete": {
+                "description": "The key is rejected from the next request on.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete service account API key",
+                "operationId": "adminDeleteServiceAccountAPIKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key id",
+                        "name": "keyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/disable": {
+            "post": {
+                "description": "The API keys and access tokens of the account are rejected from the next request on, no new tokens are issued.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Disable service account",
+                "operationId": "adminDisableServiceAccount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServiceAccount"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/enable": {
+            "post": {
+                "description": "Enable a disabled account, its keys and unexpired tokens work again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Enable service account",
+                "operationId": "adminEnableServiceAccount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServiceAccount"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/keys": {
+            "post": {
+                "description": "Register a public key the account signs RFC 7523 assertions with. The assertion names the key by the returned id in its kid header, iss and sub are the account id and aud is the issuer or its token endpoint.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Add service account key",
+                "operationId": "adminAddServiceAccountKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ServiceAccountKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServiceAccountKey"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request or invalid public key",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/keys/{keyID}": {
+            "delete": {
+                "description": "Assertions signed with the key are rejected, access tokens already issued stay valid until they expire.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete service account key",
+                "operationId": "adminDeleteServiceAccountKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "key id",
+                        "name": "keyID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/roles": {
+            "put": {
+                "description": "Replace the roles of a service account. API keys get the new permissions at once, access tokens with the next assertion.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Assign service account roles",
+                "operationId": "adminAssignServiceAccountRoles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "service account id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "roles",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.AssignRoles"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServiceAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "description": "Page through users. Pass nextCursor of the previous page as cursor with the same filters and sorting.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List users",
+                "operationId": "adminUsers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "username prefix",
+                        "name": "username",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "email prefix",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created at or after, RFC 3339",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created at or before, RFC 3339",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "creation_date",
+                        "description": "username, email or creation_date, prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "cursor of the next page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.UserPage"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get user",
+                "operationId": "adminUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.User"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a user and log out all of their sessions",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete user",
+                "operationId": "adminDeleteUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update profile fields of a user, omitted fields are left unchanged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Update user",
+                "operationId": "adminUpdateUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.UpdateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.User"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/lockout": {
+            "delete": {
+                "description": "Forget the failed logins of the user, ending its lockout and login delays. Addresses stay locked.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unlock user",
+                "operationId": "adminUnlockUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/roles": {
+            "put": {
+                "description": "Replace the roles of a user. Permissions change when the user gets the next access token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Assign roles",
+                "operationId": "adminAssignRoles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "roles",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.AssignRoles"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.User"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/create": {
+            "post": {
+                "description": "Created new user, requires the users:create permission. With a token issued for an organization the account belongs to it and becomes a member without roles.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Created new user",
+                "operationId": "create",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.CreateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/i": {
+            "post": {
+                "description": "returning user info",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user info",
+                "operationId": "GetUserInfo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.User"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update profile fields of the user, omitted fields are left unchanged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user info",
+                "operationId": "UpdateUserInfo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.UpdateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.User"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/i/password": {
+            "post": {
+                "description": "Change the password of the user. Every other session of the user is logged out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Change password",
+                "operationId": "ChangePassword",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ChangePassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "wrong current password",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Authenticate and authorized user. Return access and refresh tokens in cookies. Users with a second factor get an MFA challenge instead and finish the login at /login/mfa.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Authorized user",
+                "operationId": "login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "relative path, allowlisted uri or uri registered for client_id",
+                        "name": "redirect_uri",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "OAuth client the redirect uri is registered for",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "request body",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.Login"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok, or response.MFAChallenge when a second factor is required",
+                        "schema": {
+                            "$ref": "#/definitions/response.TokenPair"
+                        },
+                        "headers": {
+                            "access_token": {
+                                "type": "string",
+                                "description": "token for access services"
+                            },
+                            "refresh_token": {
+                                "type": "string",
+                                "description": "token for refresh access_token"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request or redirect uri is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "404 page not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed logins, retry after the Retry-After header seconds",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "seconds to wait before the next login"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/mfa": {
+            "post": {
+                "description": "Finishes a login that returned an MFA challenge with a code of the authenticator app or a recovery code, security keys finish it at /webauthn/login/finish. Return access and refresh tokens in cookies. A challenge is dropped after five wrong codes, wrong codes count as failed logins of the account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish login with the second factor",
+                "operationId": "loginMFA",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "relative path, allowlisted uri or uri registered for client_id",
+                        "name": "redirect_uri",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "OAuth client the redirect uri is registered for",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "request body",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.LoginMFA"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.TokenPair"
+                        },
+                        "headers": {
+                            "access_token": {
+                                "type": "string",
+                                "description": "token for access services"
+                            },
+                            "refresh_token": {
+                                "type": "string",
+                                "description": "token for refresh access_token"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request or redirect uri is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "invalid code or challenge",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed logins, retry after the Retry-After header seconds",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Revokes access and refresh tokens server side and clears them",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Clears tokens",
+                "operationId": "logout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "relative path, allowlisted uri or uri registered for client_id",
+                        "name": "redirect_uri",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "OAuth client the redirect uri is registered for",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok"
+                    },
+                    "302": {
+                        "description": "redirect"
+                    },
+                    "400": {
+                        "description": "redirect uri is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error"
+                    }
+                }
+            }
+        },
+        "/mfa/totp": {
+            "post": {
+                "description": "Generates a TOTP secret and returns it as otpauth URI and QR code. It becomes the second factor of the user once confirmed with a first code, until then enrolling again replaces it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Set up an authenticator app",
+                "operationId": "enrollTOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.TOTPEnrollment"
+                        }
+                    },
+                    "400": {
+                        "description": "already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes the authenticator app and the recovery codes. A code of the app or a recovery code is required once it is enabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Disable the authenticator app",
+                "operationId": "disableTOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not enrolled",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/mfa/totp/confirm": {
+            "post": {
+                "description": "Enables the enrolled authenticator app with its first code and returns the recovery codes. They are shown only this once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Enable the authenticator app",
+                "operationId": "confirmTOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.MFACode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, not enrolled or already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "invalid code",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/authorize": {
+            "get": {
+                "description": "RFC 6749 authorization code request with mandatory S256 PKCE. Signed in users are redirected to redirect_uri with a code, others to the login page first. Unknown clients and redirect URIs are answered with 400, other errors are sent to redirect_uri.",
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Authorization endpoint",
+                "operationId": "authorize",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "code",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "registered redirect uri, optional when the client has only one",
+                        "name": "redirect_uri",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "space separated scopes, all scopes of the client when empty",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "opaque value returned to the client",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "base64url SHA-256 of the code verifier",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "OpenID Connect nonce copied to the ID token",
+                        "name": "nonce",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "redirect"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/device": {
+            "get": {
+                "description": "Form where the signed in user enters the user code of a device and approves or denies it. Users without a session are sent to the login page first.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Device verification page",
+                "operationId": "devicePage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user code, prefilled from verification_uri_complete",
+                        "name": "user_code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "form"
+                    },
+                    "302": {
+                        "description": "redirect to the login page"
+                    }
+                }
+            },
+            "post": {
+                "description": "Approves or denies the device with the user code on behalf of the signed in user.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Device verification",
+                "operationId": "deviceDecision",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user code shown on the device",
+                        "name": "user_code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "approve or deny",
+                        "name": "action",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "token of the verification form",
+                        "name": "csrf",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "result"
+                    },
+                    "400": {
+                        "description": "invalid user code"
+                    },
+                    "401": {
+                        "description": "not signed in"
+                    },
+                    "403": {
+                        "description": "invalid form token"
+                    }
+                }
+            }
+        },
+        "/oauth/device_authorization": {
+            "post": {
+                "security": [
+                    {
+                        "ClientAuth": []
+                    }
+                ],
+                "description": "RFC 8628 device authorization request. The device shows the user code and polls the token endpoint with the device code while the user approves it at the verification uri.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Device authorization endpoint",
+                "operationId": "deviceAuthorization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "space separated scopes, all scopes of the client when empty",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id when basic auth is not used",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client secret of confidential clients when basic auth is not used",
+                        "name": "client_secret",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.DeviceAuthorization"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.OAuthError"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid client",
+                        "schema": {
+                            "$ref": "#/definitions/response.OAuthError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.OAuthError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/introspect": {
+            "post": {
+                "security": [
+                    {
+                        "ClientAuth": []
+                    }
+                ],
+                "description": "RFC 7662 token introspection for clients registered as resource servers, other clients get 403. Expired, revoked and invalid tokens are reported as inactive.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Token introspection",
+                "operationId": "introspect",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token or refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id when basic auth is not used",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client secret when basic auth is not used",
+                        "name": "client_secret",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.Introspection"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid client",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "client is not a resource server",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/token": {
+            "post": {
+                "security": [
+                    {
+                        "ClientAuth": []
+                    }
+                ],
+                "description": "RFC 6749 token endpoint for these grants: the authorization_code grant with PKCE; the refresh_token grant; the client_credentials grant of machine clients; the RFC 8628 device_code grant, which answers authorization_pending and slow_down until the user decides; and the RFC 7523 jwt-bearer grant of service accounts. An ID token is returned when the openid scope was granted. Confidential clients authenticate with basic auth or client_secret, public clients send client_id only. Service accounts authenticate with the assertion alone.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "Token endpoint",
+                "operationId": "token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization_code, refresh_token, client_credentials, urn:ietf:params:oauth:grant-type:device_code or urn:ietf:params:oauth:grant-type:jwt-bearer",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "authorization code",
+                        "name": "code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "redirect uri of the authorization request",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code verifier",
+                        "name": "code_verifier",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "device code of the device authorization response",
+                        "name": "device_code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT signed by a key of the service account, iss and sub are the account id",
+                        "name": "assertion",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "space separated scopes of the client_credentials grant, all scopes of the client when empty",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id when basic auth is not used",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client secret of confidential clients when basic auth is not used",
+                        "name": "client_secret",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.OAuthToken"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.OAuthError"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid client",
+                        "schema": {
+                            "$ref": "#/definitions/response.OAuthError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.OAuthError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/userinfo": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Claims of the access token owner. The token must have the openid scope, the profile and email scopes add their claims.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "oauth"
+                ],
+                "summary": "OpenID Connect userinfo endpoint",
+                "operationId": "userinfo",
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.UserInfo"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid token"
+                    },
+                    "403": {
+                        "description": "insufficient scope"
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs": {
+            "post": {
+                "description": "Create a tenant, requires the orgs:write permission",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Create organization",
+                "operationId": "createOrganization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "organization",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.CreateOrganization"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.Organization"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/{org}": {
+            "get": {
+                "description": "Requires members:read in the organization",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get organization",
+                "operationId": "getOrganization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "organization id",
+                        "name": "org",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.Organization"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/{org}/members": {
+            "get": {
+                "description": "Members of the organization with their roles there, requires members:read in the organization",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "List members",
+                "operationId": "organizationMembers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "organization id",
+                        "name": "org",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.Member"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add an account to the organization or replace the roles of a member, requires members:write in the organization. Roles may only grant users:create, members:read and members:write",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Invite member",
+                "operationId": "inviteMember",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "organization id",
+                        "name": "org",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "member",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.InviteMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.Member"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/orgs/{org}/members/{id}": {
+            "delete": {
+                "description": "Remove the user from the organization, requires members:write in the organization. Its sessions there end with their next refresh.",
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Remove member",
+                "operationId": "removeMember",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "organization id",
+                        "name": "org",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "Sends a password reset link to the account. The answer is the same whether the account exists or not.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request password reset",
+                "operationId": "forgotPassword",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ForgotPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "accepted"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "Sets the password with the token of a reset link. The token works once, every session of the user is logged out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password",
+                "operationId": "resetPassword",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.ResetPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "invalid or expired token",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new pair. The refresh token is read from the body or, when it is empty, from the cookie. Every refresh token can be used once. Refresh tokens issued to OAuth clients are refused, clients use the refresh_token grant of /oauth/token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh tokens",
+                "operationId": "refresh",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "request body",
+                        "name": "refresh",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/requests.Refresh"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.TokenPair"
+                        },
+                        "headers": {
+                            "access_token": {
+                                "type": "string",
+                                "description": "token for access services"
+                            },
+                            "refresh_token": {
+                                "type": "string",
+                                "description": "token for refresh access_token"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "token expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "token invalid",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "get": {
+                "description": "Active logins of the user, most recently used first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "List sessions",
+                "operationId": "sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.Session"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Revoke every session of the user including the current one",
+                "tags": [
+                    "user"
+                ],
+                "summary": "Log out everywhere",
+                "operationId": "revokeSessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/{id}": {
+            "delete": {
+                "description": "Log out one session. Revoking the current session clears the token cookies.",
+                "tags": [
+                    "user"
+                ],
+                "summary": "Revoke session",
+                "operationId": "revokeSession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "session id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens": {
+            "get": {
+                "description": "Unexpired tokens of the user, newest first. Only the prefix of each token is shown.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "List personal access tokens",
+                "operationId": "personalTokens",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.PersonalAccessToken"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a token for scripts, sent as Authorization: Bearer header. It acts for the organization of the current login with the permissions of the scopes, as far as the user still holds them. The token is shown only this once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create a personal access token",
+                "operationId": "createPersonalToken",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.CreatePersonalToken"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.PersonalAccessToken"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, scope not held or lifetime too long",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden or called with a personal access token",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/{id}": {
+            "delete": {
+                "tags": [
+                    "user"
+                ],
+                "summary": "Revoke a personal access token",
+                "operationId": "revokePersonalToken",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "token id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/validate": {
+            "post": {
+                "security": [
+                    {
+                        "Auth": []
+                    }
+                ],
+                "description": "Validate tokens and refresh tokens if refresh token is valid. A refreshed pair is returned in cookies as well.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Validate tokens",
+                "operationId": "Validate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.TokenPair"
+                        }
+                    },
+                    "401": {
+                        "description": "token expired",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "token invalid",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webauthn/credentials": {
+            "get": {
+                "description": "Credentials of the user, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "List security keys and passkeys",
+                "operationId": "webauthnCredentials",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.WebAuthnCredential"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webauthn/credentials/{id}": {
+            "delete": {
+                "tags": [
+                    "user"
+                ],
+                "summary": "Remove a security key or passkey",
+                "operationId": "deleteWebAuthnCredential",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "credential id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "no content"
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webauthn/login/begin": {
+            "post": {
+                "description": "Returns the options for navigator.credentials.get. With the token of an MFA challenge the key confirms that login, otherwise it is a passwordless login. A passwordless login with a username offers the credentials of the account, without one the authenticator offers its passkeys.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start a login with a security key or passkey",
+                "operationId": "beginWebAuthnLogin",
+                "parameters": [
+                    {
+                        "description": "request body",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.WebAuthnLoginBegin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PublicKeyCredentialRequestOptions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request or no security key registered",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "invalid challenge",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webauthn/login/finish": {
+            "post": {
+                "description": "Checks the assertion of the authenticator and finishes the MFA challenge or the passwordless login. Return access and refresh tokens in cookies.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in with a security key or passkey",
+                "operationId": "finishWebAuthnLogin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "relative path, allowlisted uri or uri registered for client_id",
+                        "name": "redirect_uri",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "OAuth client the redirect uri is registered for",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "description": "request body",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.WebAuthnLoginFinish"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.TokenPair"
+                        },
+                        "headers": {
+                            "access_token": {
+                                "type": "string",
+                                "description": "token for access services"
+                            },
+                            "refresh_token": {
+                                "type": "string",
+                                "description": "token for refresh access_token"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request or redirect uri is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "invalid response or challenge",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "too many failed logins, retry after the Retry-After header seconds",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webauthn/register/begin": {
+            "post": {
+                "description": "Returns the options for navigator.credentials.create. Credentials the user already has are excluded.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Start registering a security key or passkey",
+                "operationId": "beginWebAuthnRegistration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PublicKeyCredentialCreationOptions",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/webauthn/register/finish": {
+            "post": {
+                "description": "Stores the credential created by the authenticator. It can be used as second factor and for passwordless login from now on.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Register a security key or passkey",
+                "operationId": "finishWebAuthnRegistration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "access token",
+                        "name": "access_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "refresh token",
+                        "name": "refresh_token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "request body",
+                        "name": "credential",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/requests.WebAuthnRegistration"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ok",
+                        "schema": {
+                            "$ref": "#/definitions/response.WebAuthnCredential"
+                        }
+                    },
+                    "400": {
+                        "description": "bad request, invalid response or already registered",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Error"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "requests.AssignRoles": {
+            "type": "object",
+            "required": [
+                "roles"
+            ],
+            "properties": {
+                "roles": {
+                    "description": "Roles replace the current roles of the user, empty removes all of them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "requests.ChangePassword": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string",
+                    "example": "qwerty"
+                },
+                "new_password": {
+                    "type": "string",
+                    "example": "qwerty123"
+                }
+            }
+        },
+        "requests.CreateOrganization": {
+            "type": "object",
+            "required": [
+                "id",
+                "name"
+            ],
+            "properties": {
+                "id": {
+                    "description": "ID is the slug used as the tenant claim, lowercase letters, digits and dashes",
+                    "type": "string",
+                    "example": "acme"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Acme Corporation"
+                }
+            }
+        },
+        "requests.CreatePersonalToken": {
+            "type": "object",
+            "required": [
+                "expiresIn",
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresIn": {
+                    "description": "ExpiresIn is the lifetime of the token in days",
+                    "type": "integer",
+                    "minimum": 1,
+                    "example": 90
+                },
+                "name": {
+                    "description": "Name tells what the token is used for",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "ci deploy"
+                },
+                "scopes": {
+                    "description": "Scopes are permissions of the user the token may use, empty for none",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "users:read"
+                    ]
+                }
+            }
+        },
+        "requests.CreateServiceAccount": {
+            "type": "object",
+            "required": [
+                "name",
+                "ownerId",
+                "ownerType",
+                "roles"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 256,
+                    "example": "Nightly export to the billing system"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "billing-sync"
+                },
+                "ownerId": {
+                    "type": "string",
+                    "example": "acme"
+                },
+                "ownerType": {
+                    "description": "OwnerType is user or organization, accounts of an organization act for it",
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "organization"
+                    ],
+                    "example": "organization"
+                },
+                "roles": {
+                    "description": "Roles of the account, assigning roles needs the roles:write permission",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "requests.CreateUser": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "qwerty@domen.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "example": "qwerty"
+                },
+                "last_name": {
+                    "type": "string",
+                    "example": "qwerty"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "qwerty"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "user123"
+                }
+            }
+        },
+        "requests.ForgotPassword": {
+            "type": "object",
+            "required": [
+                "login"
+            ],
+            "properties": {
+                "login": {
+                    "description": "Username of the account to reset",
+                    "type": "string",
+                    "example": "test123"
+                },
+                "tenant": {
+                    "description": "Tenant is the organization the account logs in to, empty for none",
+                    "type": "string",
+                    "example": "acme"
+                }
+            }
+        },
+        "requests.InviteMember": {
+            "type": "object",
+            "required": [
+                "roles",
+                "username"
+            ],
+            "properties": {
+                "roles": {
+                    "description": "Roles the member holds in the organization, replacing the current ones",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "org-admin"
+                    ]
+                },
+                "username": {
+                    "description": "Username of an account of the organization or a global account",
+                    "type": "string",
+                    "example": "test123"
+                }
+            }
+        },
+        "requests.Login": {
+            "type": "object",
+            "required": [
+                "login",
+                "password"
+            ],
+            "properties": {
+                "login": {
+                    "description": "Username for authentication",
+                    "type": "string",
+                    "example": "test123"
+                },
+                "password": {
+                    "description": "Password for authentication",
+                    "type": "string",
+                    "example": "qwerty"
+                },
+                "tenant": {
+                    "description": "Tenant is the organization to log in to, empty for none",
+                    "type": "string",
+                    "example": "acme"
+                }
+            }
+        },
+        "requests.LoginMFA": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfaToken"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code of the authenticator app or a recovery code",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "mfaToken": {
+                    "description": "MFAToken is the challenge token returned by the login",
+                    "type": "string",
+                    "example": "3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+                }
+            }
+        },
+        "requests.MFACode": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "Code of the authenticator app, or a recovery code where accepted",
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "requests.PolicyRule": {
+            "type": "object",
+            "required": [
+                "actions",
+                "effect",
+                "permissions",
+                "resources",
+                "roles"
+            ],
+            "properties": {
+                "actions": {
+                    "description": "Actions and Resources are patterns where * matches any run of characters",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "documents:*"
+                    ]
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Editors change documents"
+                },
+                "effect": {
+                    "description": "Effect is allow or deny, a matching deny rule wins",
+                    "type": "string",
+                    "enum": [
+                        "allow",
+                        "deny"
+                    ],
+                    "example": "allow"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "users:read"
+                    ]
+                },
+                "resources": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "projects/*/documents/*"
+                    ]
+                },
+                "roles": {
+                    "description": "Roles and Permissions select the subjects, a rule naming neither applies to everyone",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "editor"
+                    ]
+                }
+            }
+        },
+        "requests.Refresh": {
+            "type": "object",
+            "properties": {
+                "refreshToken": {
+                    "description": "RefreshToken to exchange, read from the refresh_token cookie when empty",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiJ9..."
+                }
+            }
+        },
+        "requests.ResetPassword": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "description": "Password to set",
+                    "type": "string",
+                    "example": "qwerty123"
+                },
+                "token": {
+                    "description": "Token of the reset link",
+                    "type": "string",
+                    "example": "3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+                }
+            }
+        },
+        "requests.Role": {
+            "type": "object",
+            "required": [
+                "permissions"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Manages users"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "users:read"
+                    ]
+                }
+            }
+        },
+        "requests.ServiceAccountAPIKey": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Name tells what the key is used for",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "production"
+                }
+            }
+        },
+        "requests.ServiceAccountKey": {
+            "type": "object",
+            "required": [
+                "algorithm",
+                "publicKey"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "Algorithm the account signs assertions with",
+                    "type": "string",
+                    "enum": [
+                        "RS256",
+                        "RS384",
+                        "RS512",
+                        "PS256",
+                        "PS384",
+                        "PS512",
+                        "ES256",
+                        "ES384",
+                        "ES512",
+                        "EdDSA"
+                    ],
+                    "example": "ES256"
+                },
+                "publicKey": {
+                    "description": "PublicKey is the PEM encoded public key",
+                    "type": "string",
+                    "example": "-----BEGIN PUBLIC KEY-----\nMFkw...\n-----END PUBLIC KEY-----"
+                }
+            }
+        },
+        "requests.UpdateUser": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Omitted fields are left unchanged",
+                    "type": "string",
+                    "example": "qwerty@domen.com"
+                },
+                "first_name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "qwerty"
+                },
+                "last_name": {
+                    "type": "string",
+                    "minLength": 1,
+                    "example": "qwerty"
+                }
+            }
+        },
+        "requests.WebAuthnLoginBegin": {
+            "type": "object",
+            "properties": {
+                "login": {
+                    "description": "Username offers the credentials of the account, empty lets the\nauthenticator choose a passkey",
+                    "type": "string",
+                    "example": "test123"
+                },
+                "mfaToken": {
+                    "description": "MFAToken is the challenge token of a login waiting for the second factor,\nempty for a passwordless login",
+                    "type": "string",
+                    "example": "3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+                },
+                "tenant": {
+                    "description": "Tenant is the organization to log in to, empty for none",
+                    "type": "string",
+                    "example": "acme"
+                }
+            }
+        },
+        "requests.WebAuthnLoginFinish": {
+            "type": "object",
+            "required": [
+                "credential"
+            ],
+            "properties": {
+                "credential": {
+                    "description": "Credential is the PublicKeyCredential returned by navigator.credentials.get",
+                    "type": "object"
+                },
+                "mfaToken": {
+                    "description": "MFAToken is the challenge token the ceremony was started with, if any",
+                    "type": "string",
+                    "example": "3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+                }
+            }
+        },
+        "requests.WebAuthnRegistration": {
+            "type": "object",
+            "required": [
+                "credential",
+                "name"
+            ],
+            "properties": {
+                "credential": {
+                    "description": "Credential is the PublicKeyCredential returned by navigator.credentials.create",
+                    "type": "object"
+                },
+                "name": {
+                    "description": "Name the user gives the security key or passkey",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "YubiKey"
+                }
+            }
+        },
+        "response.DeviceAuthorization": {
+            "type": "object",
+            "properties": {
+                "device_code": {
+                    "type": "string",
+                    "example": "GmRhmhcxhwAzkoEqiMEg_DnyEysNkuNhszIySk9eS"
+                },
+                "expires_in": {
+                    "type": "integer",
+                    "example": 600
+                },
+                "interval": {
+                    "description": "Interval is the minimum number of seconds between token requests",
+                    "type": "integer",
+                    "example": 5
+                },
+                "user_code": {
+                    "description": "UserCode is entered by the user at VerificationURI",
+                    "type": "string",
+                    "example": "WDJB-MJHT"
+                },
+                "verification_uri": {
+                    "type": "string",
+                    "example": "http://localhost:3000/oauth/device"
+                },
+                "verification_uri_complete": {
+                    "type": "string",
+                    "example": "http://localhost:3000/oauth/device?user_code=WDJB-MJHT"
+                }
+            }
+        },
+        "response.Error": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Introspection": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active is false for expired, revoked and invalid tokens",
+                    "type": "boolean",
+                    "example": true
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "exp": {
+                    "type": "integer",
+                    "example": 1656000000
+                },
+                "iat": {
+                    "type": "integer",
+                    "example": 1655999700
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "sub": {
+                    "description": "Sub is the user id",
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f7"
+                },
+                "tenant": {
+                    "description": "Tenant is the organization the token was issued for",
+                    "type": "string",
+                    "example": "acme"
+                },
+                "token_type": {
+                    "description": "TokenType is access_token or refresh_token",
+                    "type": "string",
+                    "example": "access_token"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "test123"
+                }
+            }
+        },
+        "response.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string",
+                    "example": "RS256"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string",
+                    "example": "AQAB"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string",
+                    "example": "RSA"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string",
+                    "example": "sig"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.JWKSet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.JWK"
+                    }
+                }
+            }
+        },
+        "response.Lockout": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "id": {
+                    "description": "ID names the counter in /admin/lockouts/{id}",
+                    "type": "string",
+                    "example": "user:62b1b6c3f0e1a2b3c4d5e6f7"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "ip"
+                    ],
+                    "example": "user"
+                },
+                "lastFailureAt": {
+                    "type": "string"
+                },
+                "lockedUntil": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f7"
+                }
+            }
+        },
+        "response.Member": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "organizationId": {
+                    "type": "string",
+                    "example": "acme"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "org-admin"
+                    ]
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f7"
+                }
+            }
+        },
+        "response.OAuthError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error is the RFC 6749 error code",
+                    "type": "string",
+                    "example": "invalid_grant"
+                },
+                "error_description": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.OAuthToken": {
+            "type": "object",
             "properties": {
-                "email": {
+                "access_token": {
                     "type": "string",
-                    "example": "qwerty@domen.com"
+                    "example": "eyJhbGciOiJIUzI1NiJ9..."
                 },
-                "first_name": {
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "id_token": {
+                    "description": "IDToken is returned when the openid scope was granted",
                     "type": "string",
-                    "example": "qwerty"
+                    "example": "eyJhbGciOiJIUzI1NiJ9..."
                 },
-                "last_name": {
+                "refresh_token": {
                     "type": "string",
-                    "example": "qwerty"
+                    "example": "eyJhbGciOiJIUzI1NiJ9..."
                 },
-                "password": {
+                "scope": {
                     "type": "string",
-                    "example": "qwerty"
+                    "example": "openid profile"
                 },
-                "username": {
+                "token_type": {
                     "type": "string",
-                    "example": "user123"
+                    "example": "Bearer"
                 }
             }
         },
-        "requests.Login": {
+        "response.OpenIDConfiguration": {
             "type": "object",
-            "required": [
-                "login",
-                "password"
-            ],
             "properties": {
-                "login": {
-                    "description": "Username for authentication",
+                "authorization_endpoint": {
                     "type": "string",
-                    "example": "test123"
+                    "example": "http://localhost:3000/oauth/authorize"
                 },
-                "password": {
-                    "description": "Password for authentication",
+                "claims_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "code_challenge_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "S256"
+                    ]
+                },
+                "device_authorization_endpoint": {
                     "type": "string",
-                    "example": "qwerty"
+                    "example": "http://localhost:3000/oauth/device_authorization"
+                },
+                "grant_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "authorization_code",
+                        "refresh_token"
+                    ]
+                },
+                "id_token_signing_alg_values_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "RS256"
+                    ]
+                },
+                "introspection_endpoint": {
+                    "type": "string",
+                    "example": "http://localhost:3000/oauth/introspect"
+                },
+                "issuer": {
+                    "description": "Issuer is the iss claim of ID tokens",
+                    "type": "string",
+                    "example": "http://localhost:3000"
+                },
+                "jwks_uri": {
+                    "type": "string",
+                    "example": "http://localhost:3000/.well-known/jwks.json"
+                },
+                "response_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "code"
+                    ]
+                },
+                "scopes_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "openid",
+                        "profile",
+                        "email"
+                    ]
+                },
+                "subject_types_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "public"
+                    ]
+                },
+                "token_endpoint": {
+                    "type": "string",
+                    "example": "http://localhost:3000/oauth/token"
+                },
+                "token_endpoint_auth_methods_supported": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "client_secret_basic",
+                        "client_secret_post",
+                        "none"
+                    ]
+                },
+                "userinfo_endpoint": {
+                    "type": "string",
+                    "example": "http://localhost:3000/oauth/userinfo"
                 }
             }
         },
-        "response.Error": {
+        "response.Organization": {
             "type": "object",
             "properties": {
-                "error": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "acme"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Acme Corporation"
+                }
+            }
+        },
+        "response.PersonalAccessToken": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f7"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "ci deploy"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "pat_3q2-7wEj"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "users:read"
+                    ]
+                },
+                "tenant": {
+                    "type": "string",
+                    "example": "acme"
+                },
+                "token": {
+                    "description": "Token is returned once on creation, it is not stored",
+                    "type": "string",
+                    "example": "pat_3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f8"
+                }
+            }
+        },
+        "response.PolicyRule": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "documents:*"
+                    ]
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Editors change documents"
+                },
+                "effect": {
+                    "type": "string",
+                    "example": "allow"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "edit-documents"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "users:read"
+                    ]
+                },
+                "resources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "projects/*/documents/*"
+                    ]
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "editor"
+                    ]
+                }
+            }
+        },
+        "response.RecoveryCodes": {
+            "type": "object",
+            "properties": {
+                "recoveryCodes": {
+                    "description": "RecoveryCodes are shown once, each can be used once instead of a code of the authenticator app",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ABCD-EFGH-IJKL-MNOP"
+                    ]
+                }
+            }
+        },
+        "response.Role": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Manages users"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "admin"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "users:read"
+                    ]
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.ServiceAccount": {
+            "type": "object",
+            "properties": {
+                "apiKeys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.ServiceAccountAPIKey"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Nightly export to the billing system"
+                },
+                "disabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "disabledAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f7"
+                },
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.ServiceAccountKey"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "example": "billing-sync"
+                },
+                "ownerId": {
+                    "type": "string",
+                    "example": "acme"
+                },
+                "ownerType": {
+                    "type": "string",
+                    "example": "organization"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "response.ServiceAccountAPIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6fa"
+                },
+                "key": {
+                    "description": "Key is returned once on creation, it is not stored",
+                    "type": "string",
+                    "example": "sak_3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "production"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "sak_3q2-7wEj"
+                }
+            }
+        },
+        "response.ServiceAccountKey": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string",
+                    "example": "ES256"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is the kid the assertions name the key by",
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f9"
+                },
+                "publicKey": {
+                    "type": "string",
+                    "example": "-----BEGIN PUBLIC KEY-----\nMFkw...\n-----END PUBLIC KEY-----"
+                }
+            }
+        },
+        "response.Session": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "current": {
+                    "description": "Current is true for the session making the request",
+                    "type": "boolean"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f7"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "192.168.0.1"
+                },
+                "lastSeenAt": {
                     "type": "string"
+                },
+                "userAgent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f8"
+                }
+            }
+        },
+        "response.TOTPEnrollment": {
+            "type": "object",
+            "properties": {
+                "qrCode": {
+                    "description": "QRCode is the base64 encoded PNG image of the URI",
+                    "type": "string",
+                    "format": "base64"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                },
+                "uri": {
+                    "description": "URI is the otpauth key URI for authenticator apps",
+                    "type": "string",
+                    "example": "otpauth://totp/auth-service:test123?algorithm=SHA1\u0026digits=6\u0026issuer=auth-service\u0026period=30\u0026secret=JBSWY3DPEHPK3PXP"
                 }
             }
         },
@@ -417,15 +5162,103 @@ const docTemplate = `{
                 "last_name": {
                     "type": "string"
                 },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant": {
+                    "type": "string"
+                },
                 "username": {
                     "type": "string"
                 }
             }
+        },
+        "response.UserInfo": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Email needs the email scope",
+                    "type": "string",
+                    "example": "test123@ya.ru"
+                },
+                "family_name": {
+                    "type": "string",
+                    "example": "123"
+                },
+                "given_name": {
+                    "type": "string",
+                    "example": "test"
+                },
+                "preferred_username": {
+                    "description": "PreferredUsername, GivenName and FamilyName need the profile scope",
+                    "type": "string",
+                    "example": "test123"
+                },
+                "sub": {
+                    "description": "Sub is the user id",
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f7"
+                }
+            }
+        },
+        "response.UserPage": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "description": "NextCursor is omitted on the last page",
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.User"
+                    }
+                }
+            }
+        },
+        "response.WebAuthnCredential": {
+            "type": "object",
+            "properties": {
+                "attestationType": {
+                    "type": "string",
+                    "example": "none"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "AXkXWXv5K8Jc5g"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "YubiKey"
+                },
+                "userId": {
+                    "type": "string",
+                    "example": "62b1b6c3f0e1a2b3c4d5e6f8"
+                }
+            }
         }
     },
     "securityDefinitions": {
         "Auth": {
             "type": "basic"
+        },
+        "BearerAuth": {
+            "description": "Access token as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "ClientAuth": {
+            "type": "basic"
         }
     }
 }`
@@ -437,7 +5270,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/v1/auth",
 	Schemes:          []string{"http"},
 	Title:            "Auth-service",
-	Description:      "Signed token protects our admin endpoints",
+	Description:      "Registered client id and secret",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 }
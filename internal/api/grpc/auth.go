@@ -2,36 +2,155 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"gitlab.com/g6834/team17/api/pkg/auth_service"
 	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/policy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type AuthApi struct {
-	authS interfaces.AuthService
+	authS   interfaces.AuthService
+	policyS interfaces.PolicyService
 	auth_service.UnimplementedAuthServiceServer
 }
 
-func NewAuthAPI(authS interfaces.AuthService) *AuthApi {
-	return &AuthApi{authS: authS}
+func NewAuthAPI(authS interfaces.AuthService, policyS interfaces.PolicyService) *AuthApi {
+	return &AuthApi{authS: authS, policyS: policyS}
 }
 
+// Validate checks a token pair and rotates it when the access token has
+// expired. An access token presented alone, as machine clients do, is only
+// verified and never rotated, the same goes for personal access tokens.
 func (a *AuthApi) Validate(ctx context.Context, req *auth_service.ValidateTokenRequest) (*auth_service.ValidateTokenResponse, error) {
+	if req.RefreshToken == "" {
+		principal, err := a.authS.VerifyAccessToken(ctx, req.AccessToken)
+		if err != nil {
+			st, err := tokenStatus(err)
+			return &auth_service.ValidateTokenResponse{Status: st}, err
+		}
+
+		return &auth_service.ValidateTokenResponse{
+			AccessToken: req.AccessToken,
+			Status:      auth_service.Statuses_valid,
+			Principal:   principalMessage(principal),
+		}, nil
+	}
+
 	tokens, err := a.authS.VerifyToken(ctx, &models.TokenPair{
 		AccessToken:  req.AccessToken,
 		RefreshToken: req.RefreshToken,
 	})
 	if err != nil {
-		return &auth_service.ValidateTokenResponse{
-			AccessToken:  "",
-			RefreshToken: "",
-			Status:       auth_service.Statuses_invalid,
-		}, err
+		st, err := tokenStatus(err)
+		return &auth_service.ValidateTokenResponse{Status: st}, err
+	}
+
+	user, _, err := a.authS.ParseToken(ctx, tokens.AccessToken)
+	if err != nil {
+		st, err := tokenStatus(err)
+		return &auth_service.ValidateTokenResponse{Status: st}, err
+	}
+
+	st := auth_service.Statuses_valid
+	if tokens.AccessToken != req.AccessToken {
+		st = auth_service.Statuses_refreshed
 	}
 
 	return &auth_service.ValidateTokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Status:       st,
+		Principal:    principalMessage(&models.Principal{Type: models.PrincipalUser, User: user}),
+	}, nil
+}
+
+func (a *AuthApi) Refresh(ctx context.Context, req *auth_service.RefreshTokenRequest) (*auth_service.RefreshTokenResponse, error) {
+	tokens, err := a.authS.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		st, err := tokenStatus(err)
+		return &auth_service.RefreshTokenResponse{Status: st}, err
+	}
+
+	return &auth_service.RefreshTokenResponse{
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
 		Status:       auth_service.Statuses_valid,
 	}, nil
 }
+
+func (a *AuthApi) Check(ctx context.Context, req *auth_service.CheckRequest) (*auth_service.CheckResponse, error) {
+	subject, _, err := a.authS.ParseToken(ctx, req.SubjectToken)
+	if err != nil {
+		st, err := tokenStatus(err)
+		return &auth_service.CheckResponse{Decision: auth_service.Decisions_deny, Status: st}, err
+	}
+
+	decision, err := a.policyS.Check(ctx, subject, req.Action, req.Resource)
+	if errors.Is(err, policy.ActionRequiredErr) || errors.Is(err, policy.ResourceRequiredErr) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &auth_service.CheckResponse{
+		Decision: auth_service.Decisions_deny,
+		Status:   auth_service.Statuses_valid,
+	}
+	if decision.Allowed {
+		resp.Decision = auth_service.Decisions_allow
+	}
+	if rule := decision.Rule; rule != nil {
+		resp.Rule = &auth_service.PolicyRule{
+			Id:          rule.ID,
+			Description: rule.Description,
+			Effect:      rule.Effect,
+			Roles:       rule.Roles,
+			Permissions: rule.Permissions,
+			Actions:     rule.Actions,
+			Resources:   rule.Resources,
+		}
+	}
+
+	return resp, nil
+}
+
+func principalMessage(principal *models.Principal) *auth_service.Principal {
+	if principal.Type == models.PrincipalService {
+		return &auth_service.Principal{
+			Type:     auth_service.PrincipalTypes_service,
+			Id:       principal.Service.ClientID,
+			ClientId: principal.Service.ClientID,
+			Scope:    principal.Service.Scope,
+		}
+	}
+
+	principalType := auth_service.PrincipalTypes_user
+	if principal.User.IsServiceAccount() {
+		principalType = auth_service.PrincipalTypes_service_account
+	}
+
+	return &auth_service.Principal{
+		Type:        principalType,
+		Id:          principal.User.ID.Hex(),
+		Tenant:      principal.User.Tenant,
+		Roles:       principal.User.Roles,
+		Permissions: principal.User.Permissions,
+	}
+}
+
+// tokenStatus reports rejected tokens in the response status, only failures
+// of the service itself are returned as errors.
+func tokenStatus(err error) (auth_service.Statuses, error) {
+	switch {
+	case errors.Is(err, models.TokenExpiredErr):
+		return auth_service.Statuses_expired, nil
+	case errors.Is(err, models.InvalidTokenErr):
+		return auth_service.Statuses_invalid, nil
+	default:
+		return auth_service.Statuses_invalid, status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimitUnaryServerInterceptor rejects calls over the limits of the rules
+// matching their method with RESOURCE_EXHAUSTED, carrying the wait as
+// RetryInfo. Calls are counted by client address or by method, callers are
+// only known to the methods themselves. The client address is the peer, or
+// the one forwarded in x-forwarded-for or x-real-ip metadata when the peer
+// is a trusted proxy.
+func RateLimitUnaryServerInterceptor(limiter interfaces.RateLimiter, proxies utils.TrustedProxies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		request := &models.RateLimitRequest{GRPCMethod: info.FullMethod}
+		if p, ok := peer.FromContext(ctx); ok {
+			md, _ := metadata.FromIncomingContext(ctx)
+			var realIP string
+			if values := md.Get("x-real-ip"); len(values) > 0 {
+				realIP = values[0]
+			}
+			request.IP = proxies.ClientIP(p.Addr.String(), md.Get("x-forwarded-for"), realIP)
+		}
+
+		err := limiter.Limit(ctx, request, models.RateLimitByIP, models.RateLimitByRoute)
+		var limited *models.RateLimitedError
+		if errors.As(err, &limited) {
+			st := status.New(codes.ResourceExhausted, err.Error())
+			if detailed, detailsErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(limited.RetryAfter)}); detailsErr == nil {
+				st = detailed
+			}
+			return nil, st.Err()
+		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		return handler(ctx, req)
+	}
+}
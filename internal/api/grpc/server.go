@@ -12,6 +12,7 @@ import (
 	"gitlab.com/g6834/team17/api/pkg/auth_service"
 	"gitlab.com/g6834/team17/auth-service/internal/config"
 	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/reflection"
@@ -24,13 +25,19 @@ import (
 )
 
 type GrpcServer struct {
-	authS interfaces.AuthService
+	authS   interfaces.AuthService
+	policyS interfaces.PolicyService
+	limiter interfaces.RateLimiter
+	proxies utils.TrustedProxies
 }
 
 // NewGrpcServer returns gRPC server
-func NewGrpcServer(authS interfaces.AuthService) *GrpcServer {
+func NewGrpcServer(authS interfaces.AuthService, policyS interfaces.PolicyService, limiter interfaces.RateLimiter, proxies utils.TrustedProxies) *GrpcServer {
 	return &GrpcServer{
-		authS: authS,
+		authS:   authS,
+		policyS: policyS,
+		limiter: limiter,
+		proxies: proxies,
 	}
 }
 
@@ -61,10 +68,11 @@ func (s *GrpcServer) Start(cfg *config.Config) error {
 			grpc_prometheus.UnaryServerInterceptor,
 			grpc_opentracing.UnaryServerInterceptor(),
 			grpc_recovery.UnaryServerInterceptor(),
+			RateLimitUnaryServerInterceptor(s.limiter, s.proxies),
 		)),
 	)
 
-	as := NewAuthAPI(s.authS)
+	as := NewAuthAPI(s.authS, s.policyS)
 
 	auth_service.RegisterAuthServiceServer(grpcServer, as)
 	grpc_prometheus.EnableHandlingTimeHistogram()
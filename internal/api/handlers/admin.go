@@ -0,0 +1,652 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/middlewares"
+	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/api/response"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/lockout_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/policy"
+	"gitlab.com/g6834/team17/auth-service/internal/services/role_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type adminHandlers struct {
+	logger         *zerolog.Logger
+	presenters     interfaces.Presenters
+	userService    interfaces.UserService
+	sessionService interfaces.SessionService
+	roleService    interfaces.RoleService
+	lockoutService interfaces.LockoutService
+	policyService  interfaces.PolicyService
+}
+
+func newAdminHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, userService interfaces.UserService, sessionService interfaces.SessionService, roleService interfaces.RoleService, lockoutService interfaces.LockoutService, policyService interfaces.PolicyService) *adminHandlers {
+	return &adminHandlers{
+		logger:         logger,
+		presenters:     presenter,
+		userService:    userService,
+		sessionService: sessionService,
+		roleService:    roleService,
+		lockoutService: lockoutService,
+		policyService:  policyService,
+	}
+}
+
+// AdminRouter must be mounted behind the Validate middleware, every route
+// checks its own permission.
+func AdminRouter(logger *zerolog.Logger, presenter interfaces.Presenters, userService interfaces.UserService, sessionService interfaces.SessionService, roleService interfaces.RoleService, serviceAccountService interfaces.ServiceAccountService, lockoutService interfaces.LockoutService, policyService interfaces.PolicyService) http.Handler {
+	handlers := newAdminHandlers(logger, presenter, userService, sessionService, roleService, lockoutService, policyService)
+
+	can := func(permission string) func(http.Handler) http.Handler {
+		return middlewares.RequirePermission(presenter, permission)
+	}
+
+	r := chi.NewRouter()
+	r.With(can(models.PermissionUsersRead)).Get("/users", handlers.users)
+	r.With(can(models.PermissionUsersRead)).Get("/users/{id}", handlers.user)
+	r.With(can(models.PermissionUsersWrite)).Patch("/users/{id}", handlers.updateUser)
+	r.With(can(models.PermissionUsersDelete)).Delete("/users/{id}", handlers.deleteUser)
+	r.With(can(models.PermissionRolesWrite)).Put("/users/{id}/roles", handlers.assignRoles)
+	r.With(can(models.PermissionUsersWrite)).Delete("/users/{id}/lockout", handlers.unlockUser)
+
+	r.With(can(models.PermissionRolesRead)).Get("/roles", handlers.roles)
+	r.With(can(models.PermissionRolesWrite)).Put("/roles/{name}", handlers.saveRole)
+	r.With(can(models.PermissionRolesWrite)).Delete("/roles/{name}", handlers.deleteRole)
+
+	r.With(can(models.PermissionRolesRead)).Get("/policies", handlers.policies)
+	r.With(can(models.PermissionRolesWrite)).Put("/policies/{id}", handlers.savePolicy)
+	r.With(can(models.PermissionRolesWrite)).Delete("/policies/{id}", handlers.deletePolicy)
+
+	r.With(can(models.PermissionUsersRead)).Get("/lockouts", handlers.lockouts)
+	r.With(can(models.PermissionUsersWrite)).Delete("/lockouts/{id}", handlers.unlock)
+
+	r.Mount("/service-accounts", ServiceAccountRouter(logger, presenter, serviceAccountService))
+
+	return r
+}
+
+// Users
+// @ID adminUsers
+// @tags admin
+// @Summary List users
+// @Description Page through users. Pass nextCursor of the previous page as cursor with the same filters and sorting.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param username query string false "username prefix"
+// @Param email query string false "email prefix"
+// @Param created_from query string false "created at or after, RFC 3339"
+// @Param created_to query string false "created at or before, RFC 3339"
+// @Param sort query string false "username, email or creation_date, prefixed with - for descending order" default(creation_date)
+// @Param limit query int false "page size, at most 100" default(20)
+// @Param cursor query string false "cursor of the next page"
+// @Success 200 {object} response.UserPage "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/users [get]
+func (handlers *adminHandlers) users(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	query, err := parseUserQuery(r.URL.Query())
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	page, err := handlers.userService.Find(ctx, query)
+	if errors.Is(err, repositories.InvalidCursorErr) || errors.Is(err, repositories.InvalidSortErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, page)
+}
+
+func parseUserQuery(values url.Values) (models.UserQuery, error) {
+	query := models.UserQuery{
+		UsernamePrefix: values.Get("username"),
+		EmailPrefix:    values.Get("email"),
+		SortBy:         strings.TrimPrefix(values.Get("sort"), "-"),
+		Desc:           strings.HasPrefix(values.Get("sort"), "-"),
+		Cursor:         values.Get("cursor"),
+	}
+
+	var err error
+	if v := values.Get("limit"); v != "" {
+		if query.Limit, err = strconv.Atoi(v); err != nil {
+			return query, err
+		}
+	}
+	if v := values.Get("created_from"); v != "" {
+		if query.CreatedFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, err
+		}
+	}
+	if v := values.Get("created_to"); v != "" {
+		if query.CreatedTo, err = time.Parse(time.RFC3339, v); err != nil {
+			return query, err
+		}
+	}
+
+	return query, nil
+}
+
+// User
+// @ID adminUser
+// @tags admin
+// @Summary Get user
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "user id"
+// @Success 200 {object} response.User "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/users/{id} [get]
+func (handlers *adminHandlers) user(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user, err := handlers.getUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		handlers.presenters.Error(w, r, err)
+		return
+	}
+
+	handlers.presenters.JSON(w, r, user)
+}
+
+// UpdateUser
+// @ID adminUpdateUser
+// @tags admin
+// @Summary Update user
+// @Description Update profile fields of a user, omitted fields are left unchanged
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "user id"
+// @Param user body requests.UpdateUser true "request body"
+// @Success 200 {object} response.User "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/users/{id} [patch]
+func (handlers *adminHandlers) updateUser(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.UpdateUser
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	user, err := handlers.getUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		handlers.presenters.Error(w, r, err)
+		return
+	}
+
+	input.Apply(user)
+
+	err = handlers.userService.Update(ctx, user)
+	if errors.Is(err, repositories.DuplicateUserErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, user)
+}
+
+// DeleteUser
+// @ID adminDeleteUser
+// @tags admin
+// @Summary Delete user
+// @Description Delete a user and log out all of their sessions
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "user id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/users/{id} [delete]
+func (handlers *adminHandlers) deleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	id := chi.URLParam(r, "id")
+
+	err := handlers.userService.Delete(ctx, id)
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	err = handlers.sessionService.RevokeAll(ctx, id, "")
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AssignRoles
+// @ID adminAssignRoles
+// @tags admin
+// @Summary Assign roles
+// @Description Replace the roles of a user. Permissions change when the user gets the next access token.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "user id"
+// @Param roles body requests.AssignRoles true "request body"
+// @Success 200 {object} response.User "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/users/{id}/roles [put]
+func (handlers *adminHandlers) assignRoles(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.AssignRoles
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(repositories.NotFoundUserErr))
+		return
+	}
+
+	user, err := handlers.roleService.Assign(ctx, id, input.Roles)
+	if errors.Is(err, role_service.UnknownRoleErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, user)
+}
+
+// Roles
+// @ID adminRoles
+// @tags admin
+// @Summary List roles
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {array} response.Role "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/roles [get]
+func (handlers *adminHandlers) roles(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	roles, err := handlers.roleService.GetAll(ctx)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, roles)
+}
+
+// SaveRole
+// @ID adminSaveRole
+// @tags admin
+// @Summary Create or replace role
+// @Description Define the permissions of a role. Users holding it get them with their next access token.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param name path string true "role name"
+// @Param role body requests.Role true "request body"
+// @Success 200 {object} response.Role "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/roles/{name} [put]
+func (handlers *adminHandlers) saveRole(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.Role
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	role := &models.Role{
+		Name:        chi.URLParam(r, "name"),
+		Description: input.Description,
+		Permissions: input.Permissions,
+	}
+
+	err = handlers.roleService.Save(ctx, role)
+	if errors.Is(err, role_service.UnknownPermissionErr) || errors.Is(err, role_service.RoleNameRequiredErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, role)
+}
+
+// DeleteRole
+// @ID adminDeleteRole
+// @tags admin
+// @Summary Delete role
+// @Description Delete a role definition. Users keep the role name but it no longer grants permissions.
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param name path string true "role name"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/roles/{name} [delete]
+func (handlers *adminHandlers) deleteRole(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	err := handlers.roleService.Delete(ctx, chi.URLParam(r, "name"))
+	if errors.Is(err, repositories.NotFoundRoleErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Policies
+// @ID adminPolicies
+// @tags admin
+// @Summary List policy rules
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {array} response.PolicyRule "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/policies [get]
+func (handlers *adminHandlers) policies(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	rules, err := handlers.policyService.GetAll(ctx)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, rules)
+}
+
+// SavePolicy
+// @ID adminSavePolicy
+// @tags admin
+// @Summary Create or replace policy rule
+// @Description Define a rule of the policy Check RPC, it applies to the next checks.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "rule id"
+// @Param rule body requests.PolicyRule true "request body"
+// @Success 200 {object} response.PolicyRule "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/policies/{id} [put]
+func (handlers *adminHandlers) savePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.PolicyRule
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	rule := &models.PolicyRule{
+		ID:          chi.URLParam(r, "id"),
+		Description: input.Description,
+		Effect:      input.Effect,
+		Roles:       input.Roles,
+		Permissions: input.Permissions,
+		Actions:     input.Actions,
+		Resources:   input.Resources,
+	}
+
+	err = handlers.policyService.Save(ctx, rule)
+	if errors.Is(err, policy.InvalidRuleErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, rule)
+}
+
+// DeletePolicy
+// @ID adminDeletePolicy
+// @tags admin
+// @Summary Delete policy rule
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "rule id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/policies/{id} [delete]
+func (handlers *adminHandlers) deletePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	err := handlers.policyService.Delete(ctx, chi.URLParam(r, "id"))
+	if errors.Is(err, repositories.NotFoundPolicyRuleErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Lockouts
+// @ID adminLockouts
+// @tags admin
+// @Summary List lockouts
+// @Description List the accounts and client addresses locked out after too many failed logins.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {array} response.Lockout "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/lockouts [get]
+func (handlers *adminHandlers) lockouts(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	locked, err := handlers.lockoutService.Locked(ctx)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	result := make([]*response.Lockout, 0, len(locked))
+	for _, attempts := range locked {
+		result = append(result, &response.Lockout{
+			ID:            attempts.ID,
+			Kind:          attempts.Kind,
+			Value:         attempts.Value,
+			Failures:      attempts.Failures,
+			LastFailureAt: attempts.LastFailureAt,
+			LockedUntil:   attempts.LockedUntil,
+		})
+	}
+
+	handlers.presenters.JSON(w, r, result)
+}
+
+// Unlock
+// @ID adminUnlock
+// @tags admin
+// @Summary Unlock account or address
+// @Description Forget the failed logins of an account or client address, ending its lockout and login delays.
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "lockout id, user:<user id> or ip:<address>"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/lockouts/{id} [delete]
+func (handlers *adminHandlers) unlock(w http.ResponseWriter, r *http.Request) {
+	handlers.unlockID(w, r, chi.URLParam(r, "id"))
+}
+
+// UnlockUser
+// @ID adminUnlockUser
+// @tags admin
+// @Summary Unlock user
+// @Description Forget the failed logins of the user, ending its lockout and login delays. Addresses stay locked.
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "user id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/users/{id}/lockout [delete]
+func (handlers *adminHandlers) unlockUser(w http.ResponseWriter, r *http.Request) {
+	handlers.unlockID(w, r, models.LoginAttemptsID(models.LoginAttemptUser, chi.URLParam(r, "id")))
+}
+
+func (handlers *adminHandlers) unlockID(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	err := handlers.lockoutService.Unlock(ctx, id)
+	if errors.Is(err, lockout_service.NotFoundLockoutErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getUser loads the user by id, errors are status errors.
+func (handlers *adminHandlers) getUser(ctx context.Context, id string) (*models.User, error) {
+	if _, err := primitive.ObjectIDFromHex(id); err != nil {
+		return nil, models.ErrorNotFound(repositories.NotFoundUserErr)
+	}
+
+	user, err := handlers.userService.Get(ctx, id)
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		return nil, models.ErrorNotFound(err)
+	}
+	if err != nil {
+		return nil, models.ErrorInternal(err)
+	}
+
+	return user, nil
+}
@@ -1,40 +1,57 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
 	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/api/response"
 	"gitlab.com/g6834/team17/auth-service/internal/constants"
 	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/redirect_service"
 	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
 type authHandlers struct {
-	logger      *zerolog.Logger
-	presenters  interfaces.Presenters
-	authService interfaces.AuthService
+	logger               *zerolog.Logger
+	presenters           interfaces.Presenters
+	authService          interfaces.AuthService
+	redirectService      interfaces.RedirectService
+	webauthnService      interfaces.WebAuthnService
+	passwordResetService interfaces.PasswordResetService
 }
 
-func newAuthHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService) *authHandlers {
+func newAuthHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService, redirectService interfaces.RedirectService, webauthnService interfaces.WebAuthnService, passwordResetService interfaces.PasswordResetService) *authHandlers {
 	return &authHandlers{
-		logger:      logger,
-		presenters:  presenter,
-		authService: authService,
+		logger:               logger,
+		presenters:           presenter,
+		authService:          authService,
+		redirectService:      redirectService,
+		webauthnService:      webauthnService,
+		passwordResetService: passwordResetService,
 	}
 }
 
-func AuthRouter(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService) http.Handler {
-	handlers := newAuthHandlers(logger, presenter, authService)
+func AuthRouter(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService, redirectService interfaces.RedirectService, webauthnService interfaces.WebAuthnService, passwordResetService interfaces.PasswordResetService) http.Handler {
+	handlers := newAuthHandlers(logger, presenter, authService, redirectService, webauthnService, passwordResetService)
 
 	r := chi.NewRouter()
-	r.Get("/all", handlers.all)
 	r.Post("/login", handlers.login)
+	r.Post("/login/mfa", handlers.loginMFA)
+	r.Post("/webauthn/login/begin", handlers.beginWebAuthnLogin)
+	r.Post("/webauthn/login/finish", handlers.finishWebAuthnLogin)
 	r.Post("/logout", handlers.logout)
 	r.Post("/validate", handlers.validate)
+	r.Post("/refresh", handlers.refresh)
+	r.Post("/password/forgot", handlers.forgotPassword)
+	r.Post("/password/reset", handlers.resetPassword)
 
 	return r
 }
@@ -43,23 +60,31 @@ func AuthRouter(logger *zerolog.Logger, presenter interfaces.Presenters, authSer
 // @ID login
 // @tags auth
 // @Summary Authorized user
-// @Description Authenticate and authorized user. Return access and refresh tokens in cookies.
+// @Description Authenticate and authorized user. Return access and refresh tokens in cookies. Users with a second factor get an MFA challenge instead and finish the login at /login/mfa.
 // @Accept json
 // @Produce json
-// @Param redirect_uri query string false "redirect uri"
+// @Param redirect_uri query string false "relative path, allowlisted uri or uri registered for client_id"
+// @Param client_id query string false "OAuth client the redirect uri is registered for"
 // @Param login body requests.Login true "request body"
-// @Success 200 {object} response.TokenPair true "ok"
+// @Success 200 {object} response.TokenPair true "ok, or response.MFAChallenge when a second factor is required"
 // @Header 200 {string} access_token	"token for access services"
 // @Header 200 {string} refresh_token	"token for refresh access_token"
-// @Failure 400 {object} response.Error "bad request"
+// @Failure 400 {object} response.Error "bad request or redirect uri is not allowed"
 // @Failure 404 {string} string "404 page not found"
 // @Failure 403 {object} response.Error "forbidden"
+// @Failure 429 {object} response.Error "too many failed logins, retry after the Retry-After header seconds"
+// @Header 429 {integer} Retry-After "seconds to wait before the next login"
 // @Failure 500 {object} response.Error "internal error"
 // @Router /login [post]
 func (handlers *authHandlers) login(w http.ResponseWriter, r *http.Request) {
 	ctx, span := utils.StartSpan(r.Context())
 	defer span.End()
 
+	redirectUrl, ok := handlers.redirectURI(w, r)
+	if !ok {
+		return
+	}
+
 	var input requests.Login
 	err := utils.ReadJson(r, &input)
 	if err != nil {
@@ -74,30 +99,104 @@ func (handlers *authHandlers) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	td, err := handlers.authService.Authorize(ctx, input.Username, input.Password)
+	td, err := handlers.authService.Authorize(ctx, input.Tenant, input.Username, input.Password)
+	var mfaRequired *models.MFARequiredError
+	if errors.As(err, &mfaRequired) {
+		w.Header().Set("Cache-Control", "no-store")
+		handlers.presenters.JSON(w, r, &response.MFAChallenge{
+			MFARequired: true,
+			MFAToken:    mfaRequired.Token,
+			Methods:     mfaRequired.Methods,
+			ExpiresIn:   int64(time.Until(mfaRequired.ExpiresAt) / time.Second),
+		})
+		return
+	}
+	if handlers.loginThrottled(w, r, err) {
+		return
+	}
 	if err != nil {
 		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
 		return
 	}
 
-	atCookie := http.Cookie{
-		Name:    constants.ACCESS_TOKEN,
-		Value:   td.AccessToken,
-		Path:    "/",
-		Expires: td.AtExpires,
+	handlers.loggedIn(w, r, td, redirectUrl)
+}
+
+// LoginMFA
+// @ID loginMFA
+// @tags auth
+// @Summary Finish login with the second factor
+// @Description Finishes a login that returned an MFA challenge with a code of the authenticator app or a recovery code, security keys finish it at /webauthn/login/finish. Return access and refresh tokens in cookies. A challenge is dropped after five wrong codes, wrong codes count as failed logins of the account.
+// @Accept json
+// @Produce json
+// @Param redirect_uri query string false "relative path, allowlisted uri or uri registered for client_id"
+// @Param client_id query string false "OAuth client the redirect uri is registered for"
+// @Param login body requests.LoginMFA true "request body"
+// @Success 200 {object} response.TokenPair true "ok"
+// @Header 200 {string} access_token	"token for access services"
+// @Header 200 {string} refresh_token	"token for refresh access_token"
+// @Failure 400 {object} response.Error "bad request or redirect uri is not allowed"
+// @Failure 403 {object} response.Error "invalid code or challenge"
+// @Failure 429 {object} response.Error "too many failed logins, retry after the Retry-After header seconds"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /login/mfa [post]
+func (handlers *authHandlers) loginMFA(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	redirectUrl, ok := handlers.redirectURI(w, r)
+	if !ok {
+		return
+	}
+
+	var input requests.LoginMFA
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	td, err := handlers.authService.AuthorizeMFA(ctx, input.MFAToken, input.Code)
+	if handlers.loginThrottled(w, r, err) {
+		return
+	}
+	if errors.Is(err, auth_service.InvalidMFAChallengeErr) || errors.Is(err, auth_service.InvalidMFACodeErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
 	}
-	rtCookie := http.Cookie{
-		Name:     constants.REFRESH_TOKEN,
-		Value:    td.RefreshToken,
-		Path:     "/",
-		Expires:  td.RtExpires,
-		HttpOnly: true,
+
+	handlers.loggedIn(w, r, td, redirectUrl)
+}
+
+// loginThrottled answers logins refused by the lockout with the seconds to
+// wait, it returns false for other errors.
+func (handlers *authHandlers) loginThrottled(w http.ResponseWriter, r *http.Request, err error) bool {
+	var throttled *models.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
 	}
 
-	http.SetCookie(w, &atCookie)
-	http.SetCookie(w, &rtCookie)
+	w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(throttled.RetryAfter.Seconds())), 10))
+	handlers.presenters.Error(w, r, models.ErrorTooManyRequests(err))
 
-	redirectUrl := r.URL.Query().Get("redirect_uri")
+	return true
+}
+
+// loggedIn sets the token cookies and sends the user to the redirect uri,
+// or returns the tokens when there is none.
+func (handlers *authHandlers) loggedIn(w http.ResponseWriter, r *http.Request, td *models.TokenDetails, redirectUrl string) {
+	utils.SetTokenCookies(w, td)
 
 	if len(redirectUrl) > 0 {
 		http.Redirect(w, r, redirectUrl, http.StatusFound)
@@ -110,40 +209,42 @@ func (handlers *authHandlers) login(w http.ResponseWriter, r *http.Request) {
 // @ID logout
 // @tags auth
 // @Summary Clears tokens
-// @Description Clears access and refresh tokens
+// @Description Revokes access and refresh tokens server side and clears them
 // @Security Auth
 // @Produce json
-// @Param redirect_uri query string false "redirect uri"
+// @Param redirect_uri query string false "relative path, allowlisted uri or uri registered for client_id"
+// @Param client_id query string false "OAuth client the redirect uri is registered for"
 // @Param access_token header string true "access token"
 // @Param refresh_token header string true "refresh token"
 // @Success 200  "ok"
 // @Failure 302  "redirect"
+// @Failure 400 {object} response.Error "redirect uri is not allowed"
 // @Failure 500  "internal error"
 // @Router /logout [post]
 func (handlers *authHandlers) logout(w http.ResponseWriter, r *http.Request) {
-	_, span := utils.StartSpan(r.Context())
+	ctx, span := utils.StartSpan(r.Context())
 	defer span.End()
 
-	rtCookie := http.Cookie{
-		Name:     constants.REFRESH_TOKEN,
-		Value:    "",
-		Path:     "/",
-		Expires:  time.Unix(0, 0),
-		Secure:   true,
-		HttpOnly: true,
+	redirectUrl, ok := handlers.redirectURI(w, r)
+	if !ok {
+		return
 	}
 
-	atCookie := http.Cookie{
-		Name:    constants.ACCESS_TOKEN,
-		Value:   "",
-		Path:    "/",
-		Expires: time.Unix(0, 0),
+	var tokens models.TokenPair
+	if at, err := r.Cookie(constants.ACCESS_TOKEN); err == nil {
+		tokens.AccessToken = at.Value
+	}
+	if rt, err := r.Cookie(constants.REFRESH_TOKEN); err == nil {
+		tokens.RefreshToken = rt.Value
 	}
 
-	http.SetCookie(w, &atCookie)
-	http.SetCookie(w, &rtCookie)
+	utils.ClearTokenCookies(w)
 
-	redirectUrl := r.URL.Query().Get(constants.REDIRECT_URI)
+	err := handlers.authService.Logout(ctx, &tokens)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
 
 	if len(redirectUrl) > 0 {
 		http.Redirect(w, r, redirectUrl, http.StatusFound)
@@ -151,18 +252,41 @@ func (handlers *authHandlers) logout(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// redirectURI returns the redirect_uri of the request after checking it
+// against the allowlist, so it is checked before anything else happens.
+func (handlers *authHandlers) redirectURI(w http.ResponseWriter, r *http.Request) (string, bool) {
+	query := r.URL.Query()
+	redirectUrl := query.Get(constants.REDIRECT_URI)
+	if redirectUrl == "" {
+		return "", true
+	}
+
+	err := handlers.redirectService.Check(r.Context(), redirectUrl, query.Get("client_id"))
+	if errors.Is(err, redirect_service.RedirectNotAllowedErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return "", false
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return "", false
+	}
+
+	return redirectUrl, true
+}
+
 // Validate
 // @ID Validate
 // @tags auth
 // @Summary Validate tokens
-// @Description Validate tokens and refresh tokens if refresh token is valid
+// @Description Validate tokens and refresh tokens if refresh token is valid. A refreshed pair is returned in cookies as well.
 // @Security Auth
 // @Produce json
 // @Param access_token header string true "access token"
 // @Param refresh_token header string true "refresh token"
 // @Success 200 {object} response.TokenPair true "ok"
-// @Failure 403 {string} string "forbidden"
-// @Failure 500 {string} string "internal error"
+// @Failure 401 {object} response.Error "token expired"
+// @Failure 403 {object} response.Error "token invalid"
+// @Failure 500 {object} response.Error "internal error"
 // @Router /validate [post]
 func (handlers *authHandlers) validate(w http.ResponseWriter, r *http.Request) {
 	ctx, span := utils.StartSpan(r.Context())
@@ -180,16 +304,66 @@ func (handlers *authHandlers) validate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = handlers.authService.VerifyToken(ctx, &models.TokenPair{
+	td, err := handlers.authService.VerifyToken(ctx, &models.TokenPair{
 		AccessToken:  at.Value,
 		RefreshToken: rt.Value,
 	})
 	if err != nil {
-		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		handlers.presenters.Error(w, r, models.ErrorToken(err))
 		return
 	}
+
+	if td.AccessToken != at.Value {
+		utils.SetTokenCookies(w, td)
+	}
+
+	handlers.presenters.JSON(w, r, td)
 }
 
-func (handlers *authHandlers) all(w http.ResponseWriter, r *http.Request) {
+// Refresh
+// @ID refresh
+// @tags auth
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new pair. The refresh token is read from the body or, when it is empty, from the cookie. Every refresh token can be used once. Refresh tokens issued to OAuth clients are refused, clients use the refresh_token grant of /oauth/token.
+// @Accept json
+// @Produce json
+// @Param refresh_token header string false "refresh token"
+// @Param refresh body requests.Refresh false "request body"
+// @Success 200 {object} response.TokenPair true "ok"
+// @Header 200 {string} access_token	"token for access services"
+// @Header 200 {string} refresh_token	"token for refresh access_token"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 401 {object} response.Error "token expired"
+// @Failure 403 {object} response.Error "token invalid"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /refresh [post]
+func (handlers *authHandlers) refresh(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.Refresh
+	if r.ContentLength != 0 {
+		if err := utils.ReadJson(r, &input); err != nil {
+			handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+			return
+		}
+	}
+
+	if input.RefreshToken == "" {
+		rt, err := r.Cookie(constants.REFRESH_TOKEN)
+		if err != nil {
+			handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+			return
+		}
+		input.RefreshToken = rt.Value
+	}
+
+	td, err := handlers.authService.Refresh(ctx, input.RefreshToken)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorToken(err))
+		return
+	}
 
+	utils.SetTokenCookies(w, td)
+	handlers.presenters.JSON(w, r, td)
 }
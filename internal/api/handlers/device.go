@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/api/response"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/oauth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"html/template"
+	"net/http"
+	"net/url"
+)
+
+// deviceCSRFCookie holds the double submit token of the verification form,
+// the decision is made with the cookies of the signed in user.
+const (
+	deviceCSRFCookie = "device_csrf"
+	deviceCSRFBytes  = 32
+)
+
+var deviceTemplate = template.Must(template.New("device").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Device sign in</title></head>
+<body>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Form}}
+<form method="post">
+	<input type="hidden" name="csrf" value="{{.CSRF}}">
+	<label>Code shown on your device <input name="user_code" value="{{.UserCode}}" autocomplete="off" required></label>
+	{{if .Grant}}<p>{{.Grant.ClientID}} asks for: {{.Grant.Scope}}</p>{{end}}
+	<button name="action" value="approve">Approve</button>
+	<button name="action" value="deny">Deny</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+type devicePage struct {
+	Message  string
+	Form     bool
+	CSRF     string
+	UserCode string
+	Grant    *models.DeviceGrant
+}
+
+// DeviceAuthorization
+// @ID deviceAuthorization
+// @tags oauth
+// @Summary Device authorization endpoint
+// @Description RFC 8628 device authorization request. The device shows the user code and polls the token endpoint with the device code while the user approves it at the verification uri.
+// @Security ClientAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param scope formData string false "space separated scopes, all scopes of the client when empty"
+// @Param client_id formData string false "client id when basic auth is not used"
+// @Param client_secret formData string false "client secret of confidential clients when basic auth is not used"
+// @Success 200 {object} response.DeviceAuthorization "ok"
+// @Failure 400 {object} response.OAuthError "bad request"
+// @Failure 401 {object} response.OAuthError "invalid client"
+// @Failure 500 {object} response.OAuthError "internal error"
+// @Router /oauth/device_authorization [post]
+func (handlers *oauthHandlers) deviceAuthorization(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	client, ok := handlers.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	authorization, err := handlers.oauthService.DeviceAuthorization(ctx, client, r.PostFormValue("scope"))
+	if err != nil {
+		handlers.oauthError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = utils.WriteJson(w, &response.DeviceAuthorization{
+		DeviceCode:              authorization.DeviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         authorization.VerificationURI,
+		VerificationURIComplete: authorization.VerificationURIComplete,
+		ExpiresIn:               authorization.ExpiresIn,
+		Interval:                authorization.Interval,
+	})
+}
+
+// DevicePage
+// @ID devicePage
+// @tags oauth
+// @Summary Device verification page
+// @Description Form where the signed in user enters the user code of a device and approves or denies it. Users without a session are sent to the login page first.
+// @Produce html
+// @Param user_code query string false "user code, prefilled from verification_uri_complete"
+// @Success 200 "form"
+// @Success 302 "redirect to the login page"
+// @Router /oauth/device [get]
+func (handlers *oauthHandlers) devicePage(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := handlers.sessionUser(w, r)
+	if user == nil {
+		handlers.deviceLogin(w, r)
+		return
+	}
+
+	csrf, err := utils.RandomToken(deviceCSRFBytes)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     deviceCSRFCookie,
+		Value:    csrf,
+		Path:     r.URL.Path,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	page := &devicePage{Form: true, CSRF: csrf, UserCode: r.URL.Query().Get("user_code")}
+	if page.UserCode != "" {
+		page.Grant, err = handlers.oauthService.DeviceGrant(ctx, page.UserCode)
+		if errors.Is(err, oauth_service.InvalidUserCodeErr) {
+			page.Message = err.Error()
+		} else if err != nil {
+			handlers.presenters.Error(w, r, models.ErrorInternal(err))
+			return
+		}
+	}
+
+	handlers.renderDevice(w, http.StatusOK, page)
+}
+
+// DeviceDecision
+// @ID deviceDecision
+// @tags oauth
+// @Summary Device verification
+// @Description Approves or denies the device with the user code on behalf of the signed in user.
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param user_code formData string true "user code shown on the device"
+// @Param action formData string true "approve or deny"
+// @Param csrf formData string true "token of the verification form"
+// @Success 200 "result"
+// @Failure 400 "invalid user code"
+// @Failure 401 "not signed in"
+// @Failure 403 "invalid form token"
+// @Router /oauth/device [post]
+func (handlers *oauthHandlers) deviceDecision(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := handlers.sessionUser(w, r)
+	if user == nil {
+		handlers.renderDevice(w, http.StatusUnauthorized, &devicePage{Message: "Sign in to continue."})
+		return
+	}
+
+	cookie, err := r.Cookie(deviceCSRFCookie)
+	csrf := r.PostFormValue("csrf")
+	if err != nil || csrf == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(csrf)) != 1 {
+		handlers.renderDevice(w, http.StatusForbidden, &devicePage{Message: "The form has expired, open the page again."})
+		return
+	}
+
+	approve := r.PostFormValue("action") == "approve"
+	err = handlers.oauthService.DecideDevice(ctx, r.PostFormValue("user_code"), user, approve)
+	if errors.Is(err, oauth_service.InvalidUserCodeErr) {
+		handlers.renderDevice(w, http.StatusBadRequest, &devicePage{Message: err.Error(), Form: true, CSRF: csrf})
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	// the form token is single use
+	http.SetCookie(w, &http.Cookie{Name: deviceCSRFCookie, Path: r.URL.Path, MaxAge: -1})
+
+	message := "The device has been denied."
+	if approve {
+		message = "The device is signed in, you can return to it."
+	}
+	handlers.renderDevice(w, http.StatusOK, &devicePage{Message: message})
+}
+
+// deviceLogin sends the user to the login page, which returns to the
+// verification page once signed in.
+func (handlers *oauthHandlers) deviceLogin(w http.ResponseWriter, r *http.Request) {
+	if handlers.loginURL == "" {
+		handlers.renderDevice(w, http.StatusUnauthorized, &devicePage{Message: "Sign in to continue."})
+		return
+	}
+
+	login, err := withQuery(handlers.loginURL, url.Values{constants.REDIRECT_URI: {r.URL.RequestURI()}})
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+	http.Redirect(w, r, login, http.StatusFound)
+}
+
+func (handlers *oauthHandlers) renderDevice(w http.ResponseWriter, status int, page *devicePage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.WriteHeader(status)
+
+	if err := deviceTemplate.Execute(w, page); err != nil {
+		handlers.logger.Error().Err(err).Msg("render device page")
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/api/response"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/mfa_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+)
+
+type mfaHandlers struct {
+	logger     *zerolog.Logger
+	presenters interfaces.Presenters
+	mfaService interfaces.MFAService
+}
+
+func newMFAHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, mfaService interfaces.MFAService) *mfaHandlers {
+	return &mfaHandlers{
+		logger:     logger,
+		presenters: presenter,
+		mfaService: mfaService,
+	}
+}
+
+func MFARouter(logger *zerolog.Logger, presenter interfaces.Presenters, mfaService interfaces.MFAService) http.Handler {
+	handlers := newMFAHandlers(logger, presenter, mfaService)
+
+	r := chi.NewRouter()
+	r.Post("/totp", handlers.enroll)
+	r.Post("/totp/confirm", handlers.confirm)
+	r.Delete("/totp", handlers.disable)
+
+	return r
+}
+
+// EnrollTOTP
+// @ID enrollTOTP
+// @tags user
+// @Summary Set up an authenticator app
+// @Description Generates a TOTP secret and returns it as otpauth URI and QR code. It becomes the second factor of the user once confirmed with a first code, until then enrolling again replaces it.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {object} response.TOTPEnrollment "ok"
+// @Failure 400 {object} response.Error "already enabled"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /mfa/totp [post]
+func (handlers *mfaHandlers) enroll(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	enrollment, err := handlers.mfaService.Enroll(ctx, user)
+	if errors.Is(err, mfa_service.AlreadyEnrolledErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	handlers.presenters.JSON(w, r, &response.TOTPEnrollment{
+		Secret: enrollment.Secret,
+		URI:    enrollment.URI,
+		QRCode: enrollment.QRCode,
+	})
+}
+
+// ConfirmTOTP
+// @ID confirmTOTP
+// @tags user
+// @Summary Enable the authenticator app
+// @Description Enables the enrolled authenticator app with its first code and returns the recovery codes. They are shown only this once.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param code body requests.MFACode true "request body"
+// @Success 200 {object} response.RecoveryCodes "ok"
+// @Failure 400 {object} response.Error "bad request, not enrolled or already enabled"
+// @Failure 403 {object} response.Error "invalid code"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /mfa/totp/confirm [post]
+func (handlers *mfaHandlers) confirm(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	input, ok := handlers.readCode(w, r)
+	if !ok {
+		return
+	}
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	codes, err := handlers.mfaService.Confirm(ctx, user.ID.Hex(), input.Code)
+	if errors.Is(err, mfa_service.InvalidCodeErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if errors.Is(err, mfa_service.NotEnrolledErr) || errors.Is(err, mfa_service.AlreadyEnrolledErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	handlers.presenters.JSON(w, r, &response.RecoveryCodes{RecoveryCodes: codes})
+}
+
+// DisableTOTP
+// @ID disableTOTP
+// @tags user
+// @Summary Disable the authenticator app
+// @Description Removes the authenticator app and the recovery codes. A code of the app or a recovery code is required once it is enabled.
+// @Accept json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param code body requests.MFACode true "request body"
+// @Success 204 "no content"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "invalid code"
+// @Failure 404 {object} response.Error "not enrolled"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /mfa/totp [delete]
+func (handlers *mfaHandlers) disable(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	input, ok := handlers.readCode(w, r)
+	if !ok {
+		return
+	}
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	err := handlers.mfaService.Disable(ctx, user.ID.Hex(), input.Code)
+	if errors.Is(err, mfa_service.InvalidCodeErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if errors.Is(err, mfa_service.NotEnrolledErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handlers *mfaHandlers) readCode(w http.ResponseWriter, r *http.Request) (*requests.MFACode, bool) {
+	var input requests.MFACode
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return nil, false
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return nil, false
+	}
+
+	return &input, true
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/middlewares"
+	"gitlab.com/g6834/team17/auth-service/internal/api/response"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/client_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/oauth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var TokenRequiredErr = errors.New("token is required")
+
+type oauthHandlers struct {
+	logger        *zerolog.Logger
+	presenters    interfaces.Presenters
+	authService   interfaces.AuthService
+	clientService interfaces.ClientService
+	oauthService  interfaces.OAuthService
+	loginURL      string
+}
+
+func newOAuthHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService, clientService interfaces.ClientService, oauthService interfaces.OAuthService, loginURL string) *oauthHandlers {
+	return &oauthHandlers{
+		logger:        logger,
+		presenters:    presenter,
+		authService:   authService,
+		clientService: clientService,
+		oauthService:  oauthService,
+		loginURL:      loginURL,
+	}
+}
+
+// OAuthRouter serves the authorization server. Users without a session are
+// sent from the authorize endpoint to loginURL, which gets the authorize URL
+// to return to in redirect_uri as the login endpoint expects it.
+func OAuthRouter(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService, clientService interfaces.ClientService, oauthService interfaces.OAuthService, loginURL string) http.Handler {
+	handlers := newOAuthHandlers(logger, presenter, authService, clientService, oauthService, loginURL)
+
+	r := chi.NewRouter()
+	r.Get("/authorize", handlers.authorize)
+	r.Post("/token", handlers.token)
+	r.Get("/userinfo", handlers.userInfo)
+	r.Post("/userinfo", handlers.userInfo)
+	r.Post("/device_authorization", handlers.deviceAuthorization)
+	r.Get("/device", handlers.devicePage)
+	r.Post("/device", handlers.deviceDecision)
+	r.With(middlewares.ClientAuth(presenter, clientService), middlewares.RequireResourceServer(presenter)).
+		Post("/introspect", handlers.introspect)
+
+	return r
+}
+
+// Authorize
+// @ID authorize
+// @tags oauth
+// @Summary Authorization endpoint
+// @Description RFC 6749 authorization code request with mandatory S256 PKCE. Signed in users are redirected to redirect_uri with a code, others to the login page first. Unknown clients and redirect URIs are answered with 400, other errors are sent to redirect_uri.
+// @Param response_type query string true "code"
+// @Param client_id query string true "client id"
+// @Param redirect_uri query string false "registered redirect uri, optional when the client has only one"
+// @Param scope query string false "space separated scopes, all scopes of the client when empty"
+// @Param state query string false "opaque value returned to the client"
+// @Param code_challenge query string true "base64url SHA-256 of the code verifier"
+// @Param code_challenge_method query string true "S256"
+// @Param nonce query string false "OpenID Connect nonce copied to the ID token"
+// @Success 302 "redirect"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /oauth/authorize [get]
+func (handlers *oauthHandlers) authorize(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	query := r.URL.Query()
+	req := &models.AuthorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+		Nonce:               query.Get("nonce"),
+	}
+
+	_, err := handlers.oauthService.ValidateAuthorization(ctx, req)
+	if err != nil {
+		handlers.authorizeError(w, r, req, err)
+		return
+	}
+
+	user := handlers.sessionUser(w, r)
+	if user == nil {
+		if handlers.loginURL == "" {
+			handlers.authorizeError(w, r, req, models.NewOAuthError(models.OAuthLoginRequired, ""))
+			return
+		}
+
+		login, err := withQuery(handlers.loginURL, url.Values{constants.REDIRECT_URI: {r.URL.RequestURI()}})
+		if err != nil {
+			handlers.presenters.Error(w, r, models.ErrorInternal(err))
+			return
+		}
+		http.Redirect(w, r, login, http.StatusFound)
+		return
+	}
+
+	code, err := handlers.oauthService.Authorize(ctx, req, user)
+	if err != nil {
+		handlers.authorizeError(w, r, req, err)
+		return
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	redirect, err := withQuery(req.RedirectURI, params)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// authorizeError sends OAuth errors back to the client through the redirect
+// URI, which has been checked by then, anything else is shown to the user.
+func (handlers *oauthHandlers) authorizeError(w http.ResponseWriter, r *http.Request, req *models.AuthorizationRequest, err error) {
+	var oauthErr *models.OAuthError
+	switch {
+	case errors.Is(err, oauth_service.UnknownClientErr) || errors.Is(err, oauth_service.InvalidRedirectURIErr):
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+	case errors.As(err, &oauthErr):
+		params := url.Values{"error": {oauthErr.Code}}
+		if oauthErr.Description != "" {
+			params.Set("error_description", oauthErr.Description)
+		}
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+		redirect, err := withQuery(req.RedirectURI, params)
+		if err != nil {
+			handlers.presenters.Error(w, r, models.ErrorInternal(err))
+			return
+		}
+		http.Redirect(w, r, redirect, http.StatusFound)
+	default:
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+	}
+}
+
+// sessionUser returns the user signed in with the token cookies, or nil.
+func (handlers *oauthHandlers) sessionUser(w http.ResponseWriter, r *http.Request) *models.User {
+	at, err := r.Cookie(constants.ACCESS_TOKEN)
+	if err != nil {
+		return nil
+	}
+	rt, err := r.Cookie(constants.REFRESH_TOKEN)
+	if err != nil {
+		return nil
+	}
+
+	td, err := handlers.authService.VerifyToken(r.Context(), &models.TokenPair{
+		AccessToken:  at.Value,
+		RefreshToken: rt.Value,
+	})
+	if err != nil {
+		return nil
+	}
+	if td.AccessToken != at.Value {
+		utils.SetTokenCookies(w, td)
+	}
+
+	user, _, err := handlers.authService.ParseToken(r.Context(), td.AccessToken)
+	if err != nil {
+		return nil
+	}
+
+	return user
+}
+
+// Token
+// @ID token
+// @tags oauth
+// @Summary Token endpoint
+// @Description RFC 6749 token endpoint for these grants: the authorization_code grant with PKCE; the refresh_token grant; the client_credentials grant of machine clients; the RFC 8628 device_code grant, which answers authorization_pending and slow_down until the user decides; and the RFC 7523 jwt-bearer grant of service accounts. An ID token is returned when the openid scope was granted. Confidential clients authenticate with basic auth or client_secret, public clients send client_id only. Service accounts authenticate with the assertion alone.
+// @Security ClientAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token, client_credentials, urn:ietf:params:oauth:grant-type:device_code or urn:ietf:params:oauth:grant-type:jwt-bearer"
+// @Param code formData string false "authorization code"
+// @Param redirect_uri formData string false "redirect uri of the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param refresh_token formData string false "refresh token"
+// @Param device_code formData string false "device code of the device authorization response"
+// @Param assertion formData string false "JWT signed by a key of the service account, iss and sub are the account id"
+// @Param scope formData string false "space separated scopes of the client_credentials grant, all scopes of the client when empty"
+// @Param client_id formData string false "client id when basic auth is not used"
+// @Param client_secret formData string false "client secret of confidential clients when basic auth is not used"
+// @Success 200 {object} response.OAuthToken "ok"
+// @Failure 400 {object} response.OAuthError "bad request"
+// @Failure 401 {object} response.OAuthError "invalid client"
+// @Failure 500 {object} response.OAuthError "internal error"
+// @Router /oauth/token [post]
+func (handlers *oauthHandlers) token(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	var (
+		td  *models.TokenDetails
+		err error
+	)
+
+	// the assertion authenticates the service account, RFC 7523 makes client authentication optional
+	if r.PostFormValue("grant_type") == models.GrantTypeJWTBearer {
+		td, err = handlers.oauthService.JWTBearer(ctx, r.PostFormValue("assertion"))
+		if err != nil {
+			handlers.oauthError(w, r, http.StatusBadRequest, err)
+			return
+		}
+		handlers.writeToken(w, td)
+		return
+	}
+
+	client, ok := handlers.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.PostFormValue("grant_type") {
+	case models.GrantTypeAuthorization:
+		td, err = handlers.oauthService.Exchange(ctx, client, r.PostFormValue("code"), r.PostFormValue("redirect_uri"), r.PostFormValue("code_verifier"))
+	case models.GrantTypeRefreshToken:
+		td, err = handlers.oauthService.Refresh(ctx, client, r.PostFormValue("refresh_token"))
+	case models.GrantTypeClientCredentials:
+		td, err = handlers.oauthService.ClientCredentials(ctx, client, r.PostFormValue("scope"))
+	case models.GrantTypeDeviceCode:
+		td, err = handlers.oauthService.DeviceToken(ctx, client, r.PostFormValue("device_code"))
+	default:
+		err = models.NewOAuthError(models.OAuthUnsupportedGrantType, "")
+	}
+	if err != nil {
+		handlers.oauthError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	handlers.writeToken(w, td)
+}
+
+func (handlers *oauthHandlers) writeToken(w http.ResponseWriter, td *models.TokenDetails) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = utils.WriteJson(w, &response.OAuthToken{
+		AccessToken:  td.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(td.AtExpires) / time.Second),
+		RefreshToken: td.RefreshToken,
+		Scope:        td.Scope,
+		IDToken:      td.IDToken,
+	})
+}
+
+// UserInfo
+// @ID userinfo
+// @tags oauth
+// @Summary OpenID Connect userinfo endpoint
+// @Description Claims of the access token owner. The token must have the openid scope, the profile and email scopes add their claims.
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.UserInfo "ok"
+// @Failure 401 "invalid token"
+// @Failure 403 "insufficient scope"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /oauth/userinfo [get]
+func (handlers *oauthHandlers) userInfo(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	token, ok := middlewares.BearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	info, err := handlers.oauthService.UserInfo(ctx, token)
+	switch {
+	case errors.Is(err, models.InvalidTokenErr):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo", error="invalid_token"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Is(err, oauth_service.InsufficientScopeErr):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="userinfo", error="insufficient_scope", scope="openid"`)
+		w.WriteHeader(http.StatusForbidden)
+	case err != nil:
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+	default:
+		handlers.presenters.JSON(w, r, info)
+	}
+}
+
+// authenticateClient authenticates the client of a token endpoint request
+// with basic auth or form parameters, public clients send client_id only.
+// Failures are written to w.
+func (handlers *oauthHandlers) authenticateClient(w http.ResponseWriter, r *http.Request) (*models.Client, bool) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+
+	client, err := handlers.clientService.Authenticate(r.Context(), clientID, secret)
+	if errors.Is(err, client_service.InvalidClientErr) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		handlers.oauthError(w, r, http.StatusUnauthorized, models.NewOAuthError(models.OAuthInvalidClient, err.Error()))
+		return nil, false
+	}
+	if err != nil {
+		handlers.oauthError(w, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// oauthError writes an RFC 6749 error response, errors that are not
+// *models.OAuthError are logged and reported as server_error.
+func (handlers *oauthHandlers) oauthError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	var oauthErr *models.OAuthError
+	if !errors.As(err, &oauthErr) {
+		handlers.logger.Error().Err(err).Str("request-id", middlewares.GetReqID(r.Context())).Msg("oauth endpoint")
+		status, oauthErr = http.StatusInternalServerError, models.NewOAuthError(models.OAuthServerError, "")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = utils.WriteJson(w, &response.OAuthError{
+		Error:            oauthErr.Code,
+		ErrorDescription: oauthErr.Description,
+	})
+}
+
+// withQuery adds params to the query of rawURL, keeping the ones it has.
+func withQuery(rawURL string, params url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Introspect
+// @ID introspect
+// @tags oauth
+// @Summary Token introspection
+// @Description RFC 7662 token introspection for clients registered as resource servers, other clients get 403. Expired, revoked and invalid tokens are reported as inactive.
+// @Security ClientAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Param client_id formData string false "client id when basic auth is not used"
+// @Param client_secret formData string false "client secret when basic auth is not used"
+// @Success 200 {object} response.Introspection "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 401 {object} response.Error "invalid client"
+// @Failure 403 {object} response.Error "client is not a resource server"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /oauth/introspect [post]
+func (handlers *oauthHandlers) introspect(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(TokenRequiredErr))
+		return
+	}
+
+	introspection, err := handlers.authService.Introspect(ctx, token, r.PostFormValue("token_type_hint"))
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, introspection)
+}
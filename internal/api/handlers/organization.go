@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/middlewares"
+	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/organization_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+)
+
+type organizationHandlers struct {
+	logger              *zerolog.Logger
+	presenters          interfaces.Presenters
+	organizationService interfaces.OrganizationService
+}
+
+func newOrganizationHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, organizationService interfaces.OrganizationService) *organizationHandlers {
+	return &organizationHandlers{
+		logger:              logger,
+		presenters:          presenter,
+		organizationService: organizationService,
+	}
+}
+
+// OrganizationRouter must be mounted behind the Validate middleware. Members
+// are managed with a token issued for the organization, or by users allowed
+// to manage every organization.
+func OrganizationRouter(logger *zerolog.Logger, presenter interfaces.Presenters, organizationService interfaces.OrganizationService) http.Handler {
+	handlers := newOrganizationHandlers(logger, presenter, organizationService)
+
+	can := func(permission string) func(http.Handler) http.Handler {
+		return middlewares.RequireTenantPermission(presenter, "org", permission)
+	}
+
+	r := chi.NewRouter()
+	r.With(middlewares.RequirePermission(presenter, models.PermissionOrgsWrite)).Post("/", handlers.create)
+	r.With(can(models.PermissionMembersRead)).Get("/{org}", handlers.get)
+	r.With(can(models.PermissionMembersRead)).Get("/{org}/members", handlers.members)
+	r.With(can(models.PermissionMembersWrite)).Post("/{org}/members", handlers.invite)
+	r.With(can(models.PermissionMembersWrite)).Delete("/{org}/members/{id}", handlers.remove)
+
+	return r
+}
+
+// CreateOrganization
+// @ID createOrganization
+// @tags organizations
+// @Summary Create organization
+// @Description Create a tenant, requires the orgs:write permission
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param organization body requests.CreateOrganization true "request body"
+// @Success 200 {object} response.Organization "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /orgs [post]
+func (handlers *organizationHandlers) create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.CreateOrganization
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	org := &models.Organization{
+		ID:   input.ID,
+		Name: input.Name,
+	}
+
+	err = handlers.organizationService.Create(ctx, org)
+	if errors.Is(err, organization_service.InvalidOrganizationIDErr) || errors.Is(err, repositories.DuplicateOrganizationErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, org)
+}
+
+// GetOrganization
+// @ID getOrganization
+// @tags organizations
+// @Summary Get organization
+// @Description Requires members:read in the organization
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param org path string true "organization id"
+// @Success 200 {object} response.Organization "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /orgs/{org} [get]
+func (handlers *organizationHandlers) get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	org, err := handlers.organizationService.Get(ctx, chi.URLParam(r, "org"))
+	if errors.Is(err, repositories.NotFoundOrganizationErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, org)
+}
+
+// Members
+// @ID organizationMembers
+// @tags organizations
+// @Summary List members
+// @Description Members of the organization with their roles there, requires members:read in the organization
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param org path string true "organization id"
+// @Success 200 {array} response.Member "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /orgs/{org}/members [get]
+func (handlers *organizationHandlers) members(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	members, err := handlers.organizationService.Members(ctx, chi.URLParam(r, "org"))
+	if errors.Is(err, repositories.NotFoundOrganizationErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, members)
+}
+
+// InviteMember
+// @ID inviteMember
+// @tags organizations
+// @Summary Invite member
+// @Description Add an account to the organization or replace the roles of a member, requires members:write in the organization. Roles may only grant users:create, members:read and members:write
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param org path string true "organization id"
+// @Param member body requests.InviteMember true "request body"
+// @Success 200 {object} response.Member "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /orgs/{org}/members [post]
+func (handlers *organizationHandlers) invite(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.InviteMember
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	membership, err := handlers.organizationService.Invite(ctx, chi.URLParam(r, "org"), input.Username, input.Roles)
+	if errors.Is(err, organization_service.UnknownRoleErr) || errors.Is(err, organization_service.GlobalRoleErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if errors.Is(err, repositories.NotFoundOrganizationErr) || errors.Is(err, repositories.NotFoundUserErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, membership)
+}
+
+// RemoveMember
+// @ID removeMember
+// @tags organizations
+// @Summary Remove member
+// @Description Remove the user from the organization, requires members:write in the organization. Its sessions there end with their next refresh.
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param org path string true "organization id"
+// @Param id path string true "user id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /orgs/{org}/members/{id} [delete]
+func (handlers *organizationHandlers) remove(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	err := handlers.organizationService.Remove(ctx, chi.URLParam(r, "org"), chi.URLParam(r, "id"))
+	if errors.Is(err, repositories.NotFoundMembershipErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
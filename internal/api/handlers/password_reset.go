@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/password_reset_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+)
+
+// ForgotPassword
+// @ID forgotPassword
+// @tags auth
+// @Summary Request password reset
+// @Description Sends a password reset link to the account. The answer is the same whether the account exists or not.
+// @Accept json
+// @Param password body requests.ForgotPassword true "request body"
+// @Success 202 "accepted"
+// @Failure 400 {object} response.Error "bad request"
+// @Router /password/forgot [post]
+func (handlers *authHandlers) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.ForgotPassword
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	handlers.passwordResetService.Forgot(ctx, input.Tenant, input.Username)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword
+// @ID resetPassword
+// @tags auth
+// @Summary Reset password
+// @Description Sets the password with the token of a reset link. The token works once, every session of the user is logged out.
+// @Accept json
+// @Param password body requests.ResetPassword true "request body"
+// @Success 204 "no content"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "invalid or expired token"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /password/reset [post]
+func (handlers *authHandlers) resetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.ResetPassword
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	err = handlers.passwordResetService.Reset(ctx, input.Token, input.Password)
+	if errors.Is(err, password_reset_service.InvalidTokenErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
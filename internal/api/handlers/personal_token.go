@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/api/response"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/personal_token_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+	"time"
+)
+
+type personalTokenHandlers struct {
+	logger               *zerolog.Logger
+	presenters           interfaces.Presenters
+	personalTokenService interfaces.PersonalTokenService
+}
+
+func newPersonalTokenHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, personalTokenService interfaces.PersonalTokenService) *personalTokenHandlers {
+	return &personalTokenHandlers{
+		logger:               logger,
+		presenters:           presenter,
+		personalTokenService: personalTokenService,
+	}
+}
+
+func PersonalTokenRouter(logger *zerolog.Logger, presenter interfaces.Presenters, personalTokenService interfaces.PersonalTokenService) http.Handler {
+	handlers := newPersonalTokenHandlers(logger, presenter, personalTokenService)
+
+	r := chi.NewRouter()
+	r.Post("/", handlers.create)
+	r.Get("/", handlers.list)
+	r.Delete("/{id}", handlers.revoke)
+
+	return r
+}
+
+// CreatePersonalToken
+// @ID createPersonalToken
+// @tags user
+// @Summary Create a personal access token
+// @Description Creates a token for scripts, sent as Authorization: Bearer header. It acts for the organization of the current login with the permissions of the scopes, as far as the user still holds them. The token is shown only this once.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param token body requests.CreatePersonalToken true "request body"
+// @Success 200 {object} response.PersonalAccessToken "ok"
+// @Failure 400 {object} response.Error "bad request, scope not held or lifetime too long"
+// @Failure 403 {object} response.Error "forbidden or called with a personal access token"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /tokens [post]
+func (handlers *personalTokenHandlers) create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.CreatePersonalToken
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	token, secret, err := handlers.personalTokenService.Create(ctx, user, input.Name, input.Scopes, time.Duration(input.ExpiresIn)*24*time.Hour)
+	if errors.Is(err, personal_token_service.ScopeNotHeldErr) || errors.Is(err, personal_token_service.InvalidLifeTimeErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	handlers.presenters.JSON(w, r, &response.PersonalAccessToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		Tenant:    token.Tenant,
+		Name:      token.Name,
+		Prefix:    token.Prefix,
+		Scopes:    token.Scopes,
+		Token:     secret,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+// PersonalTokens
+// @ID personalTokens
+// @tags user
+// @Summary List personal access tokens
+// @Description Unexpired tokens of the user, newest first. Only the prefix of each token is shown.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {array} response.PersonalAccessToken "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /tokens [get]
+func (handlers *personalTokenHandlers) list(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	tokens, err := handlers.personalTokenService.GetAll(ctx, user.ID.Hex())
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, tokens)
+}
+
+// RevokePersonalToken
+// @ID revokePersonalToken
+// @tags user
+// @Summary Revoke a personal access token
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "token id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /tokens/{id} [delete]
+func (handlers *personalTokenHandlers) revoke(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	err := handlers.personalTokenService.Revoke(ctx, user.ID.Hex(), chi.URLParam(r, "id"))
+	if errors.Is(err, personal_token_service.NotFoundPersonalTokenErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/middlewares"
+	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/api/response"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/service_account_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+)
+
+type serviceAccountHandlers struct {
+	logger                *zerolog.Logger
+	presenters            interfaces.Presenters
+	serviceAccountService interfaces.ServiceAccountService
+}
+
+func newServiceAccountHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, serviceAccountService interfaces.ServiceAccountService) *serviceAccountHandlers {
+	return &serviceAccountHandlers{
+		logger:                logger,
+		presenters:            presenter,
+		serviceAccountService: serviceAccountService,
+	}
+}
+
+// ServiceAccountRouter is mounted by AdminRouter, every route checks its own
+// permission. Assigning roles needs roles:write too, as it does for users.
+func ServiceAccountRouter(logger *zerolog.Logger, presenter interfaces.Presenters, serviceAccountService interfaces.ServiceAccountService) http.Handler {
+	handlers := newServiceAccountHandlers(logger, presenter, serviceAccountService)
+
+	can := func(permissions ...string) func(http.Handler) http.Handler {
+		return middlewares.RequirePermission(presenter, permissions...)
+	}
+
+	r := chi.NewRouter()
+	r.With(can(models.PermissionServiceAccountsRead)).Get("/", handlers.list)
+	r.With(can(models.PermissionServiceAccountsWrite)).Post("/", handlers.create)
+	r.With(can(models.PermissionServiceAccountsRead)).Get("/{id}", handlers.get)
+	r.With(can(models.PermissionServiceAccountsWrite)).Post("/{id}/disable", handlers.disable)
+	r.With(can(models.PermissionServiceAccountsWrite)).Post("/{id}/enable", handlers.enable)
+	r.With(can(models.PermissionServiceAccountsWrite, models.PermissionRolesWrite)).Put("/{id}/roles", handlers.assignRoles)
+	r.With(can(models.PermissionServiceAccountsWrite)).Post("/{id}/keys", handlers.addKey)
+	r.With(can(models.PermissionServiceAccountsWrite)).Delete("/{id}/keys/{keyID}", handlers.deleteKey)
+	r.With(can(models.PermissionServiceAccountsWrite)).Post("/{id}/api-keys", handlers.createAPIKey)
+	r.With(can(models.PermissionServiceAccountsWrite)).Delete("/{id}/api-keys/{keyID}", handlers.deleteAPIKey)
+
+	return r
+}
+
+// ServiceAccounts
+// @ID adminServiceAccounts
+// @tags admin
+// @Summary List service accounts
+// @Description Service accounts by name, all of them or those of one owner.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param owner query string false "user id or organization id of the owner"
+// @Success 200 {array} response.ServiceAccount "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts [get]
+func (handlers *serviceAccountHandlers) list(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	accounts, err := handlers.serviceAccountService.GetAll(ctx, r.URL.Query().Get("owner"))
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, accounts)
+}
+
+// CreateServiceAccount
+// @ID adminCreateServiceAccount
+// @tags admin
+// @Summary Create service account
+// @Description Create a principal without a password for automation, owned by a user or an organization. It signs in with an API key or an RFC 7523 assertion at the token endpoint once one is added.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param account body requests.CreateServiceAccount true "request body"
+// @Success 200 {object} response.ServiceAccount "ok"
+// @Failure 400 {object} response.Error "bad request, unknown owner or role"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts [post]
+func (handlers *serviceAccountHandlers) create(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.CreateServiceAccount
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+	if len(input.Roles) > 0 && !user.HasPermission(models.PermissionRolesWrite) {
+		err := fmt.Errorf("%w: %s is required", middlewares.PermissionDeniedErr, models.PermissionRolesWrite)
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+
+	account := &models.ServiceAccount{
+		Name:        input.Name,
+		Description: input.Description,
+		OwnerType:   input.OwnerType,
+		OwnerID:     input.OwnerID,
+		Roles:       input.Roles,
+	}
+	err := handlers.serviceAccountService.Create(ctx, account)
+	if errors.Is(err, service_account_service.NameRequiredErr) || errors.Is(err, service_account_service.UnknownOwnerErr) || errors.Is(err, service_account_service.UnknownRoleErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, account)
+}
+
+// ServiceAccount
+// @ID adminServiceAccount
+// @tags admin
+// @Summary Get service account
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Success 200 {object} response.ServiceAccount "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id} [get]
+func (handlers *serviceAccountHandlers) get(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	account, err := handlers.serviceAccountService.Get(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		handlers.presenters.Error(w, r, serviceAccountError(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, account)
+}
+
+// DisableServiceAccount
+// @ID adminDisableServiceAccount
+// @tags admin
+// @Summary Disable service account
+// @Description The API keys and access tokens of the account are rejected from the next request on, no new tokens are issued.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Success 200 {object} response.ServiceAccount "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id}/disable [post]
+func (handlers *serviceAccountHandlers) disable(w http.ResponseWriter, r *http.Request) {
+	handlers.setDisabled(w, r, true)
+}
+
+// EnableServiceAccount
+// @ID adminEnableServiceAccount
+// @tags admin
+// @Summary Enable service account
+// @Description Enable a disabled account, its keys and unexpired tokens work again.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Success 200 {object} response.ServiceAccount "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id}/enable [post]
+func (handlers *serviceAccountHandlers) enable(w http.ResponseWriter, r *http.Request) {
+	handlers.setDisabled(w, r, false)
+}
+
+func (handlers *serviceAccountHandlers) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	account, err := handlers.serviceAccountService.SetDisabled(ctx, chi.URLParam(r, "id"), disabled)
+	if err != nil {
+		handlers.presenters.Error(w, r, serviceAccountError(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, account)
+}
+
+// AssignServiceAccountRoles
+// @ID adminAssignServiceAccountRoles
+// @tags admin
+// @Summary Assign service account roles
+// @Description Replace the roles of a service account. API keys get the new permissions at once, access tokens with the next assertion.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Param roles body requests.AssignRoles true "request body"
+// @Success 200 {object} response.ServiceAccount "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id}/roles [put]
+func (handlers *serviceAccountHandlers) assignRoles(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.AssignRoles
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	account, err := handlers.serviceAccountService.AssignRoles(ctx, chi.URLParam(r, "id"), input.Roles)
+	if err != nil {
+		handlers.presenters.Error(w, r, serviceAccountError(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, account)
+}
+
+// AddServiceAccountKey
+// @ID adminAddServiceAccountKey
+// @tags admin
+// @Summary Add service account key
+// @Description Register a public key the account signs RFC 7523 assertions with. The assertion names the key by the returned id in its kid header, iss and sub are the account id and aud is the issuer or its token endpoint.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Param key body requests.ServiceAccountKey true "request body"
+// @Success 200 {object} response.ServiceAccountKey "ok"
+// @Failure 400 {object} response.Error "bad request or invalid public key"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id}/keys [post]
+func (handlers *serviceAccountHandlers) addKey(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.ServiceAccountKey
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	key, err := handlers.serviceAccountService.AddKey(ctx, chi.URLParam(r, "id"), input.Algorithm, input.PublicKey)
+	if err != nil {
+		handlers.presenters.Error(w, r, serviceAccountError(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, key)
+}
+
+// DeleteServiceAccountKey
+// @ID adminDeleteServiceAccountKey
+// @tags admin
+// @Summary Delete service account key
+// @Description Assertions signed with the key are rejected, access tokens already issued stay valid until they expire.
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Param keyID path string true "key id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id}/keys/{keyID} [delete]
+func (handlers *serviceAccountHandlers) deleteKey(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	err := handlers.serviceAccountService.DeleteKey(ctx, chi.URLParam(r, "id"), chi.URLParam(r, "keyID"))
+	if err != nil {
+		handlers.presenters.Error(w, r, serviceAccountError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateServiceAccountAPIKey
+// @ID adminCreateServiceAccountAPIKey
+// @tags admin
+// @Summary Create service account API key
+// @Description Creates a key the account sends as Authorization: Bearer header. The key is shown only this once.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Param key body requests.ServiceAccountAPIKey true "request body"
+// @Success 200 {object} response.ServiceAccountAPIKey "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id}/api-keys [post]
+func (handlers *serviceAccountHandlers) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.ServiceAccountAPIKey
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	key, secret, err := handlers.serviceAccountService.CreateAPIKey(ctx, chi.URLParam(r, "id"), input.Name)
+	if err != nil {
+		handlers.presenters.Error(w, r, serviceAccountError(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	handlers.presenters.JSON(w, r, &response.ServiceAccountAPIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Key:       secret,
+		CreatedAt: key.CreatedAt,
+	})
+}
+
+// DeleteServiceAccountAPIKey
+// @ID adminDeleteServiceAccountAPIKey
+// @tags admin
+// @Summary Delete service account API key
+// @Description The key is rejected from the next request on.
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "service account id"
+// @Param keyID path string true "API key id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /admin/service-accounts/{id}/api-keys/{keyID} [delete]
+func (handlers *serviceAccountHandlers) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	err := handlers.serviceAccountService.DeleteAPIKey(ctx, chi.URLParam(r, "id"), chi.URLParam(r, "keyID"))
+	if err != nil {
+		handlers.presenters.Error(w, r, serviceAccountError(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serviceAccountError maps the errors of the service account service to
+// responses, unknown ones to internal errors.
+func serviceAccountError(err error) error {
+	switch {
+	case errors.Is(err, service_account_service.NotFoundServiceAccountErr), errors.Is(err, service_account_service.NotFoundKeyErr):
+		return models.ErrorNotFound(err)
+	case errors.Is(err, service_account_service.UnknownRoleErr), errors.Is(err, service_account_service.InvalidPublicKeyErr):
+		return models.ErrorBadRequest(err)
+	default:
+		return models.ErrorInternal(err)
+	}
+}
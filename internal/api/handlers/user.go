@@ -1,37 +1,62 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/middlewares"
 	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
 	"gitlab.com/g6834/team17/auth-service/internal/constants"
 	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/session_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/user_service"
 	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"net/http"
 )
 
 type userHandlers struct {
-	logger      *zerolog.Logger
-	presenters  interfaces.Presenters
-	userService interfaces.UserService
+	logger              *zerolog.Logger
+	presenters          interfaces.Presenters
+	userService         interfaces.UserService
+	sessionService      interfaces.SessionService
+	organizationService interfaces.OrganizationService
 }
 
-func newUserHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, userService interfaces.UserService) *userHandlers {
+func newUserHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, userService interfaces.UserService, sessionService interfaces.SessionService, organizationService interfaces.OrganizationService) *userHandlers {
 	return &userHandlers{
-		logger:      logger,
-		presenters:  presenter,
-		userService: userService,
+		logger:              logger,
+		presenters:          presenter,
+		userService:         userService,
+		sessionService:      sessionService,
+		organizationService: organizationService,
 	}
 }
 
-func UserRouter(logger *zerolog.Logger, presenter interfaces.Presenters, userService interfaces.UserService) http.Handler {
-	handlers := newUserHandlers(logger, presenter, userService)
+func UserRouter(logger *zerolog.Logger, presenter interfaces.Presenters, userService interfaces.UserService, sessionService interfaces.SessionService, organizationService interfaces.OrganizationService, mfaService interfaces.MFAService, webauthnService interfaces.WebAuthnService, personalTokenService interfaces.PersonalTokenService) http.Handler {
+	handlers := newUserHandlers(logger, presenter, userService, sessionService, organizationService)
 
 	r := chi.NewRouter()
 	r.Get("/i", handlers.get)
-	r.Post("/create", handlers.create)
+	r.With(middlewares.RequirePermission(presenter, models.PermissionUsersCreate)).
+		Post("/create", handlers.create)
+
+	// personal access tokens and tokens of OAuth clients only read the
+	// profile and act within their scopes
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.RequireDirectLogin(presenter))
+
+		r.Patch("/i", handlers.update)
+		r.Post("/i/password", handlers.changePassword)
+		r.Get("/sessions", handlers.sessions)
+		r.Delete("/sessions", handlers.revokeSessions)
+		r.Delete("/sessions/{id}", handlers.revokeSession)
+		r.Mount("/mfa", MFARouter(logger, presenter, mfaService))
+		r.Mount("/webauthn", WebAuthnRouter(logger, presenter, webauthnService))
+		r.Mount("/tokens", PersonalTokenRouter(logger, presenter, personalTokenService))
+	})
 
 	return r
 }
@@ -40,7 +65,7 @@ func UserRouter(logger *zerolog.Logger, presenter interfaces.Presenters, userSer
 // @ID create
 // @tags user
 // @Summary Created new user
-// @Description Created new user
+// @Description Created new user, requires the users:create permission. With a token issued for an organization the account belongs to it and becomes a member without roles.
 // @Accept json
 // @Produce json
 // @Param access_token header string true "access token"
@@ -69,17 +94,32 @@ func (handlers *userHandlers) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	creator := ctx.Value(constants.CTX_USER).(*models.User)
+
 	err = handlers.userService.Create(ctx, &models.User{
 		Username:  input.Username,
 		Password:  input.Password,
 		Email:     input.Email,
 		FirstName: input.FirstName,
 		LastName:  input.LastName,
+		Tenant:    creator.Tenant,
 	})
+	if errors.Is(err, repositories.DuplicateUserErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
 	if err != nil {
 		handlers.presenters.Error(w, r, err)
 		return
 	}
+
+	if creator.Tenant != "" {
+		_, err = handlers.organizationService.Invite(ctx, creator.Tenant, input.Username, nil)
+		if err != nil {
+			handlers.presenters.Error(w, r, models.ErrorInternal(err))
+			return
+		}
+	}
 }
 
 // GetUserInfo
@@ -104,3 +144,210 @@ func (handlers *userHandlers) get(w http.ResponseWriter, r *http.Request) {
 
 	handlers.presenters.JSON(w, r, user)
 }
+
+// UpdateUserInfo
+// @ID UpdateUserInfo
+// @tags user
+// @Summary Update user info
+// @Description Update profile fields of the user, omitted fields are left unchanged
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param user body requests.UpdateUser true "request body"
+// @Success 200 {object} response.User "ok"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /i [patch]
+func (handlers *userHandlers) update(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.UpdateUser
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	ctxUser := ctx.Value(constants.CTX_USER).(*models.User)
+	user, err := handlers.userService.Get(ctx, ctxUser.ID.Hex())
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	input.Apply(user)
+
+	err = handlers.userService.Update(ctx, user)
+	if errors.Is(err, repositories.DuplicateUserErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, user)
+}
+
+// ChangePassword
+// @ID ChangePassword
+// @tags user
+// @Summary Change password
+// @Description Change the password of the user. Every other session of the user is logged out.
+// @Accept json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param password body requests.ChangePassword true "request body"
+// @Success 204 "no content"
+// @Failure 400 {object} response.Error "bad request"
+// @Failure 403 {object} response.Error "wrong current password"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /i/password [post]
+func (handlers *userHandlers) changePassword(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.ChangePassword
+	err := utils.ReadJson(r, &input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	v := validator.New()
+	err = v.Struct(input)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+	current, _ := ctx.Value(constants.CTX_SESSION).(string)
+
+	err = handlers.userService.ChangePassword(ctx, user.ID.Hex(), input.CurrentPassword, input.NewPassword)
+	if errors.Is(err, user_service.WrongPasswordErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	// a leaked password must not keep working through existing logins
+	err = handlers.sessionService.RevokeAll(ctx, user.ID.Hex(), current)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Sessions
+// @ID sessions
+// @tags user
+// @Summary List sessions
+// @Description Active logins of the user, most recently used first
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {array} response.Session "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /sessions [get]
+func (handlers *userHandlers) sessions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+	current, _ := ctx.Value(constants.CTX_SESSION).(string)
+
+	sessions, err := handlers.sessionService.GetAll(ctx, user.ID.Hex())
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == current
+	}
+
+	handlers.presenters.JSON(w, r, sessions)
+}
+
+// RevokeSession
+// @ID revokeSession
+// @tags user
+// @Summary Revoke session
+// @Description Log out one session. Revoking the current session clears the token cookies.
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "session id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /sessions/{id} [delete]
+func (handlers *userHandlers) revokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+	current, _ := ctx.Value(constants.CTX_SESSION).(string)
+	id := chi.URLParam(r, "id")
+
+	err := handlers.sessionService.Revoke(ctx, user.ID.Hex(), id)
+	if errors.Is(err, session_service.NotFoundSessionErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	if id == current {
+		utils.ClearTokenCookies(w)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeSessions
+// @ID revokeSessions
+// @tags user
+// @Summary Log out everywhere
+// @Description Revoke every session of the user including the current one
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /sessions [delete]
+func (handlers *userHandlers) revokeSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	err := handlers.sessionService.RevokeAll(ctx, user.ID.Hex(), "")
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	utils.ClearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
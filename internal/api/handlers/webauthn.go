@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/api/requests"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/webauthn_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+)
+
+type webauthnHandlers struct {
+	logger          *zerolog.Logger
+	presenters      interfaces.Presenters
+	webauthnService interfaces.WebAuthnService
+}
+
+func newWebAuthnHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, webauthnService interfaces.WebAuthnService) *webauthnHandlers {
+	return &webauthnHandlers{
+		logger:          logger,
+		presenters:      presenter,
+		webauthnService: webauthnService,
+	}
+}
+
+// WebAuthnRouter manages the security keys and passkeys of the signed in user.
+func WebAuthnRouter(logger *zerolog.Logger, presenter interfaces.Presenters, webauthnService interfaces.WebAuthnService) http.Handler {
+	handlers := newWebAuthnHandlers(logger, presenter, webauthnService)
+
+	r := chi.NewRouter()
+	r.Post("/register/begin", handlers.beginRegistration)
+	r.Post("/register/finish", handlers.finishRegistration)
+	r.Get("/credentials", handlers.credentials)
+	r.Delete("/credentials/{id}", handlers.deleteCredential)
+
+	return r
+}
+
+// BeginWebAuthnRegistration
+// @ID beginWebAuthnRegistration
+// @tags user
+// @Summary Start registering a security key or passkey
+// @Description Returns the options for navigator.credentials.create. Credentials the user already has are excluded.
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {object} object "PublicKeyCredentialCreationOptions"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /webauthn/register/begin [post]
+func (handlers *webauthnHandlers) beginRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	options, err := handlers.webauthnService.BeginRegistration(ctx, user)
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	handlers.presenters.JSON(w, r, options)
+}
+
+// FinishWebAuthnRegistration
+// @ID finishWebAuthnRegistration
+// @tags user
+// @Summary Register a security key or passkey
+// @Description Stores the credential created by the authenticator. It can be used as second factor and for passwordless login from now on.
+// @Accept json
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param credential body requests.WebAuthnRegistration true "request body"
+// @Success 200 {object} response.WebAuthnCredential "ok"
+// @Failure 400 {object} response.Error "bad request, invalid response or already registered"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /webauthn/register/finish [post]
+func (handlers *webauthnHandlers) finishRegistration(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.WebAuthnRegistration
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	credential, err := handlers.webauthnService.FinishRegistration(ctx, user, input.Name, input.Credential)
+	if errors.Is(err, webauthn_service.InvalidResponseErr) || errors.Is(err, webauthn_service.DuplicateCredentialErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, credential)
+}
+
+// WebAuthnCredentials
+// @ID webauthnCredentials
+// @tags user
+// @Summary List security keys and passkeys
+// @Description Credentials of the user, oldest first
+// @Produce json
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Success 200 {array} response.WebAuthnCredential "ok"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /webauthn/credentials [get]
+func (handlers *webauthnHandlers) credentials(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	credentials, err := handlers.webauthnService.Credentials(ctx, user.ID.Hex())
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.presenters.JSON(w, r, credentials)
+}
+
+// DeleteWebAuthnCredential
+// @ID deleteWebAuthnCredential
+// @tags user
+// @Summary Remove a security key or passkey
+// @Param access_token header string true "access token"
+// @Param refresh_token header string true "refresh token"
+// @Param id path string true "credential id"
+// @Success 204 "no content"
+// @Failure 403 {object} response.Error "forbidden"
+// @Failure 404 {object} response.Error "not found"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /webauthn/credentials/{id} [delete]
+func (handlers *webauthnHandlers) deleteCredential(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	user := ctx.Value(constants.CTX_USER).(*models.User)
+
+	err := handlers.webauthnService.DeleteCredential(ctx, user.ID.Hex(), chi.URLParam(r, "id"))
+	if errors.Is(err, webauthn_service.NotFoundCredentialErr) {
+		handlers.presenters.Error(w, r, models.ErrorNotFound(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// BeginWebAuthnLogin
+// @ID beginWebAuthnLogin
+// @tags auth
+// @Summary Start a login with a security key or passkey
+// @Description Returns the options for navigator.credentials.get. With the token of an MFA challenge the key confirms that login, otherwise it is a passwordless login. A passwordless login with a username offers the credentials of the account, without one the authenticator offers its passkeys.
+// @Accept json
+// @Produce json
+// @Param login body requests.WebAuthnLoginBegin true "request body"
+// @Success 200 {object} object "PublicKeyCredentialRequestOptions"
+// @Failure 400 {object} response.Error "bad request or no security key registered"
+// @Failure 403 {object} response.Error "invalid challenge"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /webauthn/login/begin [post]
+func (handlers *authHandlers) beginWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	var input requests.WebAuthnLoginBegin
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if input.MFAToken == "" {
+		options, err := handlers.webauthnService.BeginPasswordless(ctx, input.Tenant, input.Username)
+		if err != nil {
+			handlers.presenters.Error(w, r, models.ErrorInternal(err))
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		handlers.presenters.JSON(w, r, options)
+		return
+	}
+
+	challenge, err := handlers.authService.PendingMFA(ctx, input.MFAToken)
+	if errors.Is(err, auth_service.InvalidMFAChallengeErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	options, err := handlers.webauthnService.BeginLogin(ctx, challenge.UserID)
+	if errors.Is(err, webauthn_service.NoCredentialsErr) {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	handlers.presenters.JSON(w, r, options)
+}
+
+// FinishWebAuthnLogin
+// @ID finishWebAuthnLogin
+// @tags auth
+// @Summary Log in with a security key or passkey
+// @Description Checks the assertion of the authenticator and finishes the MFA challenge or the passwordless login. Return access and refresh tokens in cookies.
+// @Accept json
+// @Produce json
+// @Param redirect_uri query string false "relative path, allowlisted uri or uri registered for client_id"
+// @Param client_id query string false "OAuth client the redirect uri is registered for"
+// @Param login body requests.WebAuthnLoginFinish true "request body"
+// @Success 200 {object} response.TokenPair true "ok"
+// @Header 200 {string} access_token	"token for access services"
+// @Header 200 {string} refresh_token	"token for refresh access_token"
+// @Failure 400 {object} response.Error "bad request or redirect uri is not allowed"
+// @Failure 403 {object} response.Error "invalid response or challenge"
+// @Failure 429 {object} response.Error "too many failed logins, retry after the Retry-After header seconds"
+// @Failure 500 {object} response.Error "internal error"
+// @Router /webauthn/login/finish [post]
+func (handlers *authHandlers) finishWebAuthnLogin(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	redirectUrl, ok := handlers.redirectURI(w, r)
+	if !ok {
+		return
+	}
+
+	var input requests.WebAuthnLoginFinish
+	if err := utils.ReadJson(r, &input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if err := validator.New().Struct(input); err != nil {
+		handlers.presenters.Error(w, r, models.ErrorBadRequest(err))
+		return
+	}
+
+	if input.MFAToken != "" {
+		td, err := handlers.authService.AuthorizeMFA(ctx, input.MFAToken, string(input.Credential))
+		if handlers.loginThrottled(w, r, err) {
+			return
+		}
+		if errors.Is(err, auth_service.InvalidMFAChallengeErr) || errors.Is(err, auth_service.InvalidMFACodeErr) {
+			handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+			return
+		}
+		if err != nil {
+			handlers.presenters.Error(w, r, models.ErrorInternal(err))
+			return
+		}
+
+		handlers.loggedIn(w, r, td, redirectUrl)
+		return
+	}
+
+	userID, tenant, err := handlers.webauthnService.Login(ctx, input.Credential)
+	if errors.Is(err, webauthn_service.InvalidResponseErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	td, err := handlers.authService.AuthorizePasswordless(ctx, userID, tenant)
+	if handlers.loginThrottled(w, r, err) {
+		return
+	}
+	if errors.Is(err, auth_service.NotMemberErr) {
+		handlers.presenters.Error(w, r, models.ErrorForbidden(err))
+		return
+	}
+	if err != nil {
+		handlers.presenters.Error(w, r, models.ErrorInternal(err))
+		return
+	}
+
+	handlers.loggedIn(w, r, td, redirectUrl)
+}
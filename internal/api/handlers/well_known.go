@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+)
+
+type wellKnownHandlers struct {
+	logger      *zerolog.Logger
+	presenters  interfaces.Presenters
+	authService interfaces.AuthService
+	discovery   *models.OpenIDConfiguration
+}
+
+func newWellKnownHandlers(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService, discovery *models.OpenIDConfiguration) *wellKnownHandlers {
+	return &wellKnownHandlers{
+		logger:      logger,
+		presenters:  presenter,
+		authService: authService,
+		discovery:   discovery,
+	}
+}
+
+// WellKnownRouter publishes the signing keys and the OpenID Connect discovery
+// document of the provider running at issuer.
+func WellKnownRouter(logger *zerolog.Logger, presenter interfaces.Presenters, authService interfaces.AuthService, issuer string, algorithms []string) http.Handler {
+	handlers := newWellKnownHandlers(logger, presenter, authService, models.NewOpenIDConfiguration(issuer, algorithms))
+
+	r := chi.NewRouter()
+	r.Get("/jwks.json", handlers.jwks)
+	r.Get("/openid-configuration", handlers.openIDConfiguration)
+
+	return r
+}
+
+// JWKS
+// @ID jwks
+// @tags well-known
+// @Summary Token signing keys
+// @Description Public keys for offline token verification. Empty when tokens are signed with a shared secret.
+// @Produce json
+// @Success 200 {object} response.JWKSet "ok"
+// @Router /.well-known/jwks.json [get]
+func (handlers *wellKnownHandlers) jwks(w http.ResponseWriter, r *http.Request) {
+	ctx, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	handlers.presenters.JSON(w, r, handlers.authService.JWKS(ctx))
+}
+
+// OpenIDConfiguration
+// @ID openidConfiguration
+// @tags well-known
+// @Summary OpenID Connect discovery
+// @Description Provider metadata for OpenID Connect relying parties. Endpoints are relative to the configured issuer.
+// @Produce json
+// @Success 200 {object} response.OpenIDConfiguration "ok"
+// @Router /.well-known/openid-configuration [get]
+func (handlers *wellKnownHandlers) openIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	_, span := utils.StartSpan(r.Context())
+	defer span.End()
+
+	handlers.presenters.JSON(w, r, handlers.discovery)
+}
@@ -0,0 +1,62 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"net/http"
+)
+
+var (
+	ClientCredentialsErr      = errors.New("client credentials are required")
+	ResourceServerRequiredErr = errors.New("client is not a resource server")
+)
+
+// ClientAuth authenticates registered clients with HTTP Basic credentials or,
+// as RFC 6749 allows, client_id and client_secret form parameters.
+func ClientAuth(presenters interfaces.Presenters, clientService interfaces.ClientService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			clientID, secret, ok := r.BasicAuth()
+			if !ok {
+				clientID, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+			}
+			if clientID == "" || secret == "" {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+				presenters.Error(rw, r, models.ErrorUnauthorized(ClientCredentialsErr))
+				return
+			}
+
+			client, err := clientService.Authenticate(r.Context(), clientID, secret)
+			if err != nil {
+				rw.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+				presenters.Error(rw, r, models.ErrorUnauthorized(err))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), constants.CTX_CLIENT, client)
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RequireResourceServer rejects authenticated clients that are not
+// registered as resource servers. It must run after ClientAuth.
+func RequireResourceServer(presenters interfaces.Presenters) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			client, ok := r.Context().Value(constants.CTX_CLIENT).(*models.Client)
+			if !ok || !client.ResourceServer {
+				presenters.Error(rw, r, models.ErrorForbidden(ResourceServerRequiredErr))
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,64 @@
+package middlewares_test
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/api/middlewares"
+	"gitlab.com/g6834/team17/auth-service/internal/api/presenters"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/client_service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type clientAuthTestSuit struct {
+	suite.Suite
+}
+
+var (
+	clientSecret   = "qwerty"
+	clientHash     = "$2a$04$7M.stb53jLjW8xxd9j3idO0nAmjFDnm.45hyxHjcndvnZfmiEaanO"
+	resourceServer = models.Client{
+		ID:             "resource-server",
+		SecretHash:     clientHash,
+		ResourceServer: true,
+	}
+	thirdParty = models.Client{
+		ID:         "third-party",
+		SecretHash: clientHash,
+		GrantTypes: []string{models.GrantTypeAuthorization, models.GrantTypeClientCredentials},
+	}
+)
+
+func TestClientAuthTestSuite(t *testing.T) {
+	suite.Run(t, &clientAuthTestSuit{})
+}
+
+func (u *clientAuthTestSuit) introspect(clientID string) *httptest.ResponseRecorder {
+	r := new(repositories.MockClientRepository)
+	r.On("Get", resourceServer.ID).Return(&resourceServer)
+	r.On("Get", thirdParty.ID).Return(&thirdParty)
+
+	logger := zerolog.New(io.Discard)
+	p := presenters.NewPresenters(&logger)
+	handler := middlewares.RequestID(middlewares.ClientAuth(p, client_service.New(r))(
+		middlewares.RequireResourceServer(p)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})),
+	))
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/introspect", nil)
+	req.SetBasicAuth(clientID, clientSecret)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (u *clientAuthTestSuit) TestRequireResourceServer() {
+	u.Equal(http.StatusOK, u.introspect(resourceServer.ID).Code)
+	u.Equal(http.StatusForbidden, u.introspect(thirdParty.ID).Code, "confidential clients that are no resource server must not introspect")
+}
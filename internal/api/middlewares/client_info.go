@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+)
+
+// ClientInfo stores the client address and user agent in the context.
+// Requests through one of the trusted proxies report the address the proxy
+// forwarded, the headers of other callers are ignored.
+func ClientInfo(proxies utils.TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), constants.CTX_CLIENT_INFO, models.ClientInfo{
+				IP:        proxies.ClientIP(r.RemoteAddr, r.Header.Values("X-Forwarded-For"), r.Header.Get("X-Real-IP")),
+				UserAgent: r.UserAgent(),
+			})
+
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		}
+		return http.HandlerFunc(fn)
+	}
+}
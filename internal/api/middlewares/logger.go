@@ -3,6 +3,7 @@ package middlewares
 import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"go.opentelemetry.io/otel/trace"
 	"net/http"
 	"time"
@@ -21,7 +22,7 @@ func Logger(logger *zerolog.Logger) func(http.Handler) http.Handler {
 					Int("bytes", ww.BytesWritten()).
 					Str("method", r.Method).
 					Str("query", r.URL.RawQuery).
-					Str("ip", r.RemoteAddr).
+					Str("ip", utils.ClientInfo(r.Context()).IP).
 					Str("trace.id", trace.SpanFromContext(r.Context()).SpanContext().TraceID().String()).
 					Str("user-agent", r.UserAgent()).
 					Dur("latency", time.Since(start)).
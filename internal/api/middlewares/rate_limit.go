@@ -0,0 +1,48 @@
+package middlewares
+
+import (
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// RateLimit rejects requests over the limits of the rules counting by one
+// of the kinds with 429 and a Retry-After header. It must run after
+// ClientInfo, rules counting by user after Validate too, where the caller is
+// known. Machine clients count as users by their client id.
+func RateLimit(presenters interfaces.Presenters, limiter interfaces.RateLimiter, kinds ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			request := &models.RateLimitRequest{
+				Method: r.Method,
+				Path:   r.URL.Path,
+				IP:     utils.ClientInfo(r.Context()).IP,
+			}
+			if user, ok := r.Context().Value(constants.CTX_USER).(*models.User); ok {
+				request.UserID = user.ID.Hex()
+			} else if service, ok := r.Context().Value(constants.CTX_SERVICE).(*models.ServicePrincipal); ok {
+				request.UserID = "client:" + service.ClientID
+			}
+
+			err := limiter.Limit(r.Context(), request, kinds...)
+			var limited *models.RateLimitedError
+			if errors.As(err, &limited) {
+				rw.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(limited.RetryAfter.Seconds())), 10))
+				presenters.Error(rw, r, models.ErrorTooManyRequests(err))
+				return
+			}
+			if err != nil {
+				presenters.Error(rw, r, models.ErrorInternal(err))
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
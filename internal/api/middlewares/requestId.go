@@ -5,14 +5,12 @@ import (
 	"net/http"
 
 	uuid "github.com/satori/go.uuid"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
 )
 
-type ctxKey int
-
-const ridKey ctxKey = ctxKey(0)
-
 func GetReqID(ctx context.Context) string {
-	return ctx.Value(ridKey).(string)
+	return utils.RequestID(ctx)
 }
 
 func RequestID(next http.Handler) http.Handler {
@@ -21,7 +19,7 @@ func RequestID(next http.Handler) http.Handler {
 		if rid == "" {
 			rid = uuid.NewV4().String()
 		}
-		ctx := context.WithValue(r.Context(), ridKey, rid)
+		ctx := context.WithValue(r.Context(), constants.CTX_REQUEST_ID, rid)
 		rw.Header().Add("X-Request-ID", rid)
 
 		next.ServeHTTP(rw, r.WithContext(ctx))
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"net/http"
+)
+
+var DirectLoginRequiredErr = errors.New("personal access tokens and tokens of OAuth clients may not manage the account")
+
+// RequireDirectLogin rejects callers identified by a personal access token
+// or by a token the user granted to an OAuth client, the routes behind it
+// change the account or its credentials and a leaked or delegated token must
+// not be enough to take the account over. It must run after Validate.
+func RequireDirectLogin(presenters interfaces.Presenters) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			_, personal := r.Context().Value(constants.CTX_PERSONAL_TOKEN).(string)
+			_, client := r.Context().Value(constants.CTX_TOKEN_CLIENT).(string)
+			if personal || client {
+				presenters.Error(rw, r, models.ErrorForbidden(DirectLoginRequiredErr))
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"net/http"
+)
+
+var PermissionDeniedErr = errors.New("permission denied")
+
+// RequirePermission lets through users holding every listed permission, it
+// must run after Validate.
+func RequirePermission(presenters interfaces.Presenters, permissions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(constants.CTX_USER).(*models.User)
+			if !ok {
+				presenters.Error(rw, r, models.ErrorForbidden(PermissionDeniedErr))
+				return
+			}
+
+			for _, permission := range permissions {
+				if !user.HasPermission(permission) {
+					presenters.Error(rw, r, models.ErrorForbidden(fmt.Errorf("%w: %s is required", PermissionDeniedErr, permission)))
+					return
+				}
+			}
+
+			next.ServeHTTP(rw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
@@ -0,0 +1,39 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"net/http"
+)
+
+// RequireTenantPermission lets through users holding the permission in the
+// organization named by the URL parameter, that is with a token issued for
+// it, and users allowed to manage every organization. It must run after
+// Validate on a route declaring the parameter.
+func RequireTenantPermission(presenters interfaces.Presenters, param, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(constants.CTX_USER).(*models.User)
+			if !ok {
+				presenters.Error(rw, r, models.ErrorForbidden(PermissionDeniedErr))
+				return
+			}
+
+			if user.HasPermission(models.PermissionOrgsWrite) {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			if user.Tenant != chi.URLParam(r, param) || !user.HasPermission(permission) {
+				presenters.Error(rw, r, models.ErrorForbidden(fmt.Errorf("%w: %s in the organization is required", PermissionDeniedErr, permission)))
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
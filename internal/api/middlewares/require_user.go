@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"net/http"
+)
+
+var UserRequiredErr = errors.New("only users may call this endpoint")
+
+// RequireUser rejects service principals and service accounts, the routes
+// behind it act on a human's own account. It must run after Validate.
+func RequireUser(presenters interfaces.Presenters) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(rw http.ResponseWriter, r *http.Request) {
+			if user, ok := r.Context().Value(constants.CTX_USER).(*models.User); !ok || user.IsServiceAccount() {
+				presenters.Error(rw, r, models.ErrorForbidden(UserRequiredErr))
+				return
+			}
+
+			next.ServeHTTP(rw, r)
+		}
+		return http.HandlerFunc(fn)
+	}
+}
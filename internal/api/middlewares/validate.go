@@ -5,12 +5,48 @@ import (
 	"gitlab.com/g6834/team17/auth-service/internal/constants"
 	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"net/http"
+	"strings"
 )
 
+// Validate authenticates the token cookies of a browser session or an access
+// token in an Authorization: Bearer header. Users and service accounts are
+// put in the context under CTX_USER, told apart by User.Type, machine
+// clients under CTX_SERVICE as a service principal.
+// The login session of the token is put under CTX_SESSION. A bearer personal
+// access token puts its id under CTX_PERSONAL_TOKEN too, a token issued to an
+// OAuth client puts the client id under CTX_TOKEN_CLIENT.
 func Validate(presenters interfaces.Presenters, authService interfaces.AuthService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(rw http.ResponseWriter, r *http.Request) {
+			if token, ok := BearerToken(r); ok {
+				principal, err := authService.VerifyAccessToken(r.Context(), token)
+				if err != nil {
+					presenters.Error(rw, r, models.ErrorToken(err))
+					return
+				}
+
+				ctx := r.Context()
+				if principal.Type == models.PrincipalService {
+					ctx = context.WithValue(ctx, constants.CTX_SERVICE, principal.Service)
+				} else {
+					ctx = context.WithValue(ctx, constants.CTX_USER, principal.User)
+				}
+				if principal.PersonalTokenID != "" {
+					ctx = context.WithValue(ctx, constants.CTX_PERSONAL_TOKEN, principal.PersonalTokenID)
+				}
+				if principal.ClientID != "" {
+					ctx = context.WithValue(ctx, constants.CTX_TOKEN_CLIENT, principal.ClientID)
+				}
+				if principal.SessionID != "" {
+					ctx = context.WithValue(ctx, constants.CTX_SESSION, principal.SessionID)
+				}
+
+				next.ServeHTTP(rw, r.WithContext(ctx))
+				return
+			}
+
 			at, err := r.Cookie(constants.ACCESS_TOKEN)
 			if err != nil {
 				presenters.Error(rw, r, models.ErrorForbidden(err))
@@ -23,21 +59,44 @@ func Validate(presenters interfaces.Presenters, authService interfaces.AuthServi
 				return
 			}
 
-			_, err = authService.VerifyToken(r.Context(), &models.TokenPair{
+			td, err := authService.VerifyToken(r.Context(), &models.TokenPair{
 				AccessToken:  at.Value,
 				RefreshToken: rt.Value,
 			})
 			if err != nil {
-				presenters.Error(rw, r, models.ErrorForbidden(err))
+				presenters.Error(rw, r, models.ErrorToken(err))
 				return
 			}
 
-			user, _, _ := authService.ParseToken(r.Context(), at.Value)
+			// the refresh token has been rotated, the client must keep the new pair
+			if td.AccessToken != at.Value {
+				utils.SetTokenCookies(rw, td)
+			}
+
+			user, _, err := authService.ParseToken(r.Context(), td.AccessToken)
+			if err != nil {
+				presenters.Error(rw, r, models.ErrorToken(err))
+				return
+			}
 
 			ctx := context.WithValue(r.Context(), constants.CTX_USER, user)
+			ctx = context.WithValue(ctx, constants.CTX_SESSION, td.SessionID)
+			if td.ClientID != "" {
+				ctx = context.WithValue(ctx, constants.CTX_TOKEN_CLIENT, td.ClientID)
+			}
 
 			next.ServeHTTP(rw, r.WithContext(ctx))
 		}
 		return http.HandlerFunc(fn)
 	}
 }
+
+// BearerToken reads an RFC 6750 Authorization header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
@@ -0,0 +1,62 @@
+package middlewares_test
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/api/middlewares"
+	"gitlab.com/g6834/team17/auth-service/internal/api/presenters"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type validateTestSuit struct {
+	suite.Suite
+}
+
+var user = models.User{
+	ID:       primitive.NewObjectID(),
+	Username: "test123",
+	Password: "$2a$04$3Fwej2KBe58nKVdo0n9mqugGQrEdwzvJqF1JBUgDI3TLLzntYOW96",
+}
+
+func TestValidateTestSuite(t *testing.T) {
+	suite.Run(t, &validateTestSuit{})
+}
+
+func (u *validateTestSuit) TestBearerSession() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", user.Username).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	as := auth_service.New(&auth_service.JwtSettings{SecretKey: "628f955942efffd7e8e30256", AtLifeTime: 5, RtLifeTime: 5}, r,
+		auth_service.WithTokenFamilies(repositories.NewMemoryTokenFamilyRepo()),
+		auth_service.WithSessions(repositories.NewMemorySessionRepo()),
+	)
+	td, err := as.Authorize(context.Background(), "", user.Username, "qwerty")
+	u.Require().NoError(err)
+	u.Require().NotEmpty(td.SessionID)
+
+	var session string
+	logger := zerolog.New(io.Discard)
+	handler := middlewares.RequestID(middlewares.Validate(presenters.NewPresenters(&logger), as)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, _ = r.Context().Value(constants.CTX_SESSION).(string)
+		}),
+	))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/user/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+td.AccessToken)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	u.Equal(http.StatusOK, rec.Code)
+	u.Equal(td.SessionID, session, "bearer tokens must tell the current session as cookies do")
+}
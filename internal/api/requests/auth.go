@@ -1,5 +1,7 @@
 package requests
 
+import "encoding/json"
+
 // swagger:model Login
 type Login struct {
 	// Username for authentication
@@ -7,4 +9,63 @@ type Login struct {
 
 	// Password for authentication
 	Password string `json:"password" validate:"required" example:"qwerty"`
+
+	// Tenant is the organization to log in to, empty for none
+	Tenant string `json:"tenant,omitempty" example:"acme"`
+}
+
+// swagger:model Refresh
+type Refresh struct {
+	// RefreshToken to exchange, read from the refresh_token cookie when empty
+	RefreshToken string `json:"refreshToken" example:"eyJhbGciOiJIUzI1NiJ9..."`
+}
+
+// swagger:model LoginMFA
+type LoginMFA struct {
+	// MFAToken is the challenge token returned by the login
+	MFAToken string `json:"mfaToken" validate:"required" example:"3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"`
+
+	// Code of the authenticator app or a recovery code
+	Code string `json:"code" validate:"required" example:"123456"`
+}
+
+// swagger:model WebAuthnLoginBegin
+type WebAuthnLoginBegin struct {
+	// MFAToken is the challenge token of a login waiting for the second factor,
+	// empty for a passwordless login
+	MFAToken string `json:"mfaToken,omitempty" example:"3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"`
+
+	// Username offers the credentials of the account, empty lets the
+	// authenticator choose a passkey
+	Username string `json:"login,omitempty" example:"test123"`
+
+	// Tenant is the organization to log in to, empty for none
+	Tenant string `json:"tenant,omitempty" example:"acme"`
+}
+
+// swagger:model WebAuthnLoginFinish
+type WebAuthnLoginFinish struct {
+	// MFAToken is the challenge token the ceremony was started with, if any
+	MFAToken string `json:"mfaToken,omitempty" example:"3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"`
+
+	// Credential is the PublicKeyCredential returned by navigator.credentials.get
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
+// swagger:model ForgotPassword
+type ForgotPassword struct {
+	// Username of the account to reset
+	Username string `json:"login" validate:"required" example:"test123"`
+
+	// Tenant is the organization the account logs in to, empty for none
+	Tenant string `json:"tenant,omitempty" example:"acme"`
+}
+
+// swagger:model ResetPassword
+type ResetPassword struct {
+	// Token of the reset link
+	Token string `json:"token" validate:"required" example:"3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"`
+
+	// Password to set
+	Password string `json:"password" validate:"required" example:"qwerty123"`
 }
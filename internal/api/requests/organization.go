@@ -0,0 +1,16 @@
+package requests
+
+// swagger:model CreateOrganization
+type CreateOrganization struct {
+	// ID is the slug used as the tenant claim, lowercase letters, digits and dashes
+	ID   string `json:"id" validate:"required" example:"acme"`
+	Name string `json:"name" validate:"required" example:"Acme Corporation"`
+}
+
+// swagger:model InviteMember
+type InviteMember struct {
+	// Username of an account of the organization or a global account
+	Username string `json:"username" validate:"required" example:"test123"`
+	// Roles the member holds in the organization, replacing the current ones
+	Roles []string `json:"roles" validate:"dive,required" example:"org-admin"`
+}
@@ -0,0 +1,17 @@
+package requests
+
+// swagger:model PolicyRule
+type PolicyRule struct {
+	Description string `json:"description" example:"Editors change documents"`
+
+	// Effect is allow or deny, a matching deny rule wins
+	Effect string `json:"effect" validate:"required,oneof=allow deny" example:"allow"`
+
+	// Roles and Permissions select the subjects, a rule naming neither applies to everyone
+	Roles       []string `json:"roles" validate:"dive,required" example:"editor"`
+	Permissions []string `json:"permissions" validate:"dive,required" example:"users:read"`
+
+	// Actions and Resources are patterns where * matches any run of characters
+	Actions   []string `json:"actions" validate:"required,min=1,dive,required" example:"documents:*"`
+	Resources []string `json:"resources" validate:"required,min=1,dive,required" example:"projects/*/documents/*"`
+}
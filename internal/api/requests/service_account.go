@@ -0,0 +1,29 @@
+package requests
+
+// swagger:model CreateServiceAccount
+type CreateServiceAccount struct {
+	Name        string `json:"name" validate:"required,max=64" example:"billing-sync"`
+	Description string `json:"description" validate:"max=256" example:"Nightly export to the billing system"`
+
+	// OwnerType is user or organization, accounts of an organization act for it
+	OwnerType string `json:"ownerType" validate:"required,oneof=user organization" example:"organization"`
+	OwnerID   string `json:"ownerId" validate:"required" example:"acme"`
+
+	// Roles of the account, assigning roles needs the roles:write permission
+	Roles []string `json:"roles" validate:"dive,required" example:"admin"`
+}
+
+// swagger:model ServiceAccountKey
+type ServiceAccountKey struct {
+	// Algorithm the account signs assertions with
+	Algorithm string `json:"algorithm" validate:"required,oneof=RS256 RS384 RS512 PS256 PS384 PS512 ES256 ES384 ES512 EdDSA" example:"ES256"`
+
+	// PublicKey is the PEM encoded public key
+	PublicKey string `json:"publicKey" validate:"required" example:"-----BEGIN PUBLIC KEY-----\nMFkw...\n-----END PUBLIC KEY-----"`
+}
+
+// swagger:model ServiceAccountAPIKey
+type ServiceAccountAPIKey struct {
+	// Name tells what the key is used for
+	Name string `json:"name" validate:"required,max=64" example:"production"`
+}
@@ -1,5 +1,10 @@
 package requests
 
+import (
+	"encoding/json"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+)
+
 type CreateUser struct {
 	Username  string `json:"username" validate:"required" example:"user123"`
 	Password  string `json:"password" validate:"required" example:"qwerty"`
@@ -7,3 +12,69 @@ type CreateUser struct {
 	FirstName string `json:"first_name" validate:"required" example:"qwerty"`
 	LastName  string `json:"last_name" validate:"required" example:"qwerty"`
 }
+
+// swagger:model UpdateUser
+type UpdateUser struct {
+	// Omitted fields are left unchanged
+	Email     *string `json:"email,omitempty" validate:"omitempty,email" example:"qwerty@domen.com"`
+	FirstName *string `json:"first_name,omitempty" validate:"omitempty,min=1" example:"qwerty"`
+	LastName  *string `json:"last_name,omitempty" validate:"omitempty,min=1" example:"qwerty"`
+}
+
+// Apply copies the given fields to the user.
+func (u *UpdateUser) Apply(user *models.User) {
+	if u.Email != nil {
+		user.Email = *u.Email
+	}
+	if u.FirstName != nil {
+		user.FirstName = *u.FirstName
+	}
+	if u.LastName != nil {
+		user.LastName = *u.LastName
+	}
+}
+
+// swagger:model ChangePassword
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"required" example:"qwerty"`
+	NewPassword     string `json:"new_password" validate:"required,nefield=CurrentPassword" example:"qwerty123"`
+}
+
+// swagger:model AssignRoles
+type AssignRoles struct {
+	// Roles replace the current roles of the user, empty removes all of them
+	Roles []string `json:"roles" validate:"dive,required" example:"admin"`
+}
+
+// swagger:model Role
+type Role struct {
+	Description string   `json:"description" example:"Manages users"`
+	Permissions []string `json:"permissions" validate:"dive,required" example:"users:read"`
+}
+
+// swagger:model MFACode
+type MFACode struct {
+	// Code of the authenticator app, or a recovery code where accepted
+	Code string `json:"code" validate:"required" example:"123456"`
+}
+
+// swagger:model WebAuthnRegistration
+type WebAuthnRegistration struct {
+	// Name the user gives the security key or passkey
+	Name string `json:"name" validate:"required,max=64" example:"YubiKey"`
+
+	// Credential is the PublicKeyCredential returned by navigator.credentials.create
+	Credential json.RawMessage `json:"credential" validate:"required" swaggertype:"object"`
+}
+
+// swagger:model CreatePersonalToken
+type CreatePersonalToken struct {
+	// Name tells what the token is used for
+	Name string `json:"name" validate:"required,max=64" example:"ci deploy"`
+
+	// Scopes are permissions of the user the token may use, empty for none
+	Scopes []string `json:"scopes" validate:"dive,required" example:"users:read"`
+
+	// ExpiresIn is the lifetime of the token in days
+	ExpiresIn int `json:"expiresIn" validate:"required,min=1" example:"90"`
+}
@@ -0,0 +1,13 @@
+package response
+
+// swagger:model DeviceAuthorization
+type DeviceAuthorization struct {
+	DeviceCode string `json:"device_code" example:"GmRhmhcxhwAzkoEqiMEg_DnyEysNkuNhszIySk9eS"`
+	// UserCode is entered by the user at VerificationURI
+	UserCode                string `json:"user_code" example:"WDJB-MJHT"`
+	VerificationURI         string `json:"verification_uri" example:"http://localhost:3000/oauth/device"`
+	VerificationURIComplete string `json:"verification_uri_complete" example:"http://localhost:3000/oauth/device?user_code=WDJB-MJHT"`
+	ExpiresIn               int64  `json:"expires_in" example:"600"`
+	// Interval is the minimum number of seconds between token requests
+	Interval int64 `json:"interval" example:"5"`
+}
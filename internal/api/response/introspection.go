@@ -0,0 +1,18 @@
+package response
+
+// swagger:model Introspection
+type Introspection struct {
+	// Active is false for expired, revoked and invalid tokens
+	Active bool `json:"active" example:"true"`
+	// Sub is the user id
+	Sub      string `json:"sub,omitempty" example:"62b1b6c3f0e1a2b3c4d5e6f7"`
+	Username string `json:"username,omitempty" example:"test123"`
+	Exp      int64  `json:"exp,omitempty" example:"1656000000"`
+	Iat      int64  `json:"iat,omitempty" example:"1655999700"`
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// Tenant is the organization the token was issued for
+	Tenant string `json:"tenant,omitempty" example:"acme"`
+	// TokenType is access_token or refresh_token
+	TokenType string `json:"token_type,omitempty" example:"access_token"`
+}
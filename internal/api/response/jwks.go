@@ -0,0 +1,19 @@
+package response
+
+// swagger:model JWK
+type JWK struct {
+	Kty string `json:"kty" example:"RSA"`
+	Use string `json:"use,omitempty" example:"sig"`
+	Alg string `json:"alg,omitempty" example:"RS256"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty" example:"AQAB"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// swagger:model JWKSet
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,14 @@
+package response
+
+import "time"
+
+// swagger:model Lockout
+type Lockout struct {
+	// ID names the counter in /admin/lockouts/{id}
+	ID            string    `json:"id" example:"user:62b1b6c3f0e1a2b3c4d5e6f7"`
+	Kind          string    `json:"kind" example:"user" enums:"user,ip"`
+	Value         string    `json:"value" example:"62b1b6c3f0e1a2b3c4d5e6f7"`
+	Failures      int       `json:"failures" example:"10"`
+	LastFailureAt time.Time `json:"lastFailureAt"`
+	LockedUntil   time.Time `json:"lockedUntil"`
+}
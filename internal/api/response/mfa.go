@@ -0,0 +1,38 @@
+package response
+
+import "time"
+
+// swagger:model MFAChallenge
+type MFAChallenge struct {
+	MFARequired bool `json:"mfaRequired" example:"true"`
+	// MFAToken is sent to /login/mfa with the code of the second factor
+	MFAToken string `json:"mfaToken" example:"3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"`
+	// Methods the second factor may be given with
+	Methods   []string `json:"methods" example:"totp,recovery_code"`
+	ExpiresIn int64    `json:"expiresIn" example:"300"`
+}
+
+// swagger:model TOTPEnrollment
+type TOTPEnrollment struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	// URI is the otpauth key URI for authenticator apps
+	URI string `json:"uri" example:"otpauth://totp/auth-service:test123?algorithm=SHA1&digits=6&issuer=auth-service&period=30&secret=JBSWY3DPEHPK3PXP"`
+	// QRCode is the base64 encoded PNG image of the URI
+	QRCode []byte `json:"qrCode" swaggertype:"string" format:"base64"`
+}
+
+// swagger:model RecoveryCodes
+type RecoveryCodes struct {
+	// RecoveryCodes are shown once, each can be used once instead of a code of the authenticator app
+	RecoveryCodes []string `json:"recoveryCodes" example:"ABCD-EFGH-IJKL-MNOP"`
+}
+
+// swagger:model WebAuthnCredential
+type WebAuthnCredential struct {
+	ID              string    `json:"id" example:"AXkXWXv5K8Jc5g"`
+	UserID          string    `json:"userId" example:"62b1b6c3f0e1a2b3c4d5e6f8"`
+	Name            string    `json:"name" example:"YubiKey"`
+	AttestationType string    `json:"attestationType" example:"none"`
+	CreatedAt       time.Time `json:"createdAt"`
+	LastUsedAt      time.Time `json:"lastUsedAt"`
+}
@@ -0,0 +1,31 @@
+package response
+
+// swagger:model OAuthToken
+type OAuthToken struct {
+	AccessToken  string `json:"access_token" example:"eyJhbGciOiJIUzI1NiJ9..."`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"900"`
+	RefreshToken string `json:"refresh_token,omitempty" example:"eyJhbGciOiJIUzI1NiJ9..."`
+	Scope        string `json:"scope,omitempty" example:"openid profile"`
+	// IDToken is returned when the openid scope was granted
+	IDToken string `json:"id_token,omitempty" example:"eyJhbGciOiJIUzI1NiJ9..."`
+}
+
+// swagger:model OAuthError
+type OAuthError struct {
+	// Error is the RFC 6749 error code
+	Error            string `json:"error" example:"invalid_grant"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// swagger:model UserInfo
+type UserInfo struct {
+	// Sub is the user id
+	Sub string `json:"sub" example:"62b1b6c3f0e1a2b3c4d5e6f7"`
+	// PreferredUsername, GivenName and FamilyName need the profile scope
+	PreferredUsername string `json:"preferred_username,omitempty" example:"test123"`
+	GivenName         string `json:"given_name,omitempty" example:"test"`
+	FamilyName        string `json:"family_name,omitempty" example:"123"`
+	// Email needs the email scope
+	Email string `json:"email,omitempty" example:"test123@ya.ru"`
+}
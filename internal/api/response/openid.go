@@ -0,0 +1,21 @@
+package response
+
+// swagger:model OpenIDConfiguration
+type OpenIDConfiguration struct {
+	// Issuer is the iss claim of ID tokens
+	Issuer                            string   `json:"issuer" example:"http://localhost:3000"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint" example:"http://localhost:3000/oauth/authorize"`
+	TokenEndpoint                     string   `json:"token_endpoint" example:"http://localhost:3000/oauth/token"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint" example:"http://localhost:3000/oauth/userinfo"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint" example:"http://localhost:3000/oauth/introspect"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint" example:"http://localhost:3000/oauth/device_authorization"`
+	JWKSURI                           string   `json:"jwks_uri" example:"http://localhost:3000/.well-known/jwks.json"`
+	ScopesSupported                   []string `json:"scopes_supported" example:"openid,profile,email"`
+	ResponseTypesSupported            []string `json:"response_types_supported" example:"code"`
+	GrantTypesSupported               []string `json:"grant_types_supported" example:"authorization_code,refresh_token"`
+	SubjectTypesSupported             []string `json:"subject_types_supported" example:"public"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported" example:"RS256"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported" example:"client_secret_basic,client_secret_post,none"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported" example:"S256"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
@@ -0,0 +1,18 @@
+package response
+
+import "time"
+
+// swagger:model Organization
+type Organization struct {
+	ID        string    `json:"id" example:"acme"`
+	Name      string    `json:"name" example:"Acme Corporation"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// swagger:model Member
+type Member struct {
+	OrganizationID string    `json:"organizationId" example:"acme"`
+	UserID         string    `json:"userId" example:"62b1b6c3f0e1a2b3c4d5e6f7"`
+	Roles          []string  `json:"roles" example:"org-admin"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
@@ -0,0 +1,18 @@
+package response
+
+import "time"
+
+// swagger:model PersonalAccessToken
+type PersonalAccessToken struct {
+	ID     string   `json:"id" example:"62b1b6c3f0e1a2b3c4d5e6f7"`
+	UserID string   `json:"userId" example:"62b1b6c3f0e1a2b3c4d5e6f8"`
+	Tenant string   `json:"tenant,omitempty" example:"acme"`
+	Name   string   `json:"name" example:"ci deploy"`
+	Prefix string   `json:"prefix" example:"pat_3q2-7wEj"`
+	Scopes []string `json:"scopes" example:"users:read"`
+	// Token is returned once on creation, it is not stored
+	Token      string    `json:"token,omitempty" example:"pat_3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"`
+	CreatedAt  time.Time `json:"createdAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+}
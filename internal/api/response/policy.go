@@ -0,0 +1,12 @@
+package response
+
+// swagger:model PolicyRule
+type PolicyRule struct {
+	ID          string   `json:"id" example:"edit-documents"`
+	Description string   `json:"description" example:"Editors change documents"`
+	Effect      string   `json:"effect" example:"allow"`
+	Roles       []string `json:"roles" example:"editor"`
+	Permissions []string `json:"permissions" example:"users:read"`
+	Actions     []string `json:"actions" example:"documents:*"`
+	Resources   []string `json:"resources" example:"projects/*/documents/*"`
+}
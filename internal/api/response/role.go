@@ -0,0 +1,11 @@
+package response
+
+import "time"
+
+// swagger:model Role
+type Role struct {
+	Name        string    `json:"name" example:"admin"`
+	Description string    `json:"description" example:"Manages users"`
+	Permissions []string  `json:"permissions" example:"users:read"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,37 @@
+package response
+
+import "time"
+
+// swagger:model ServiceAccount
+type ServiceAccount struct {
+	ID          string                 `json:"id" example:"62b1b6c3f0e1a2b3c4d5e6f7"`
+	Name        string                 `json:"name" example:"billing-sync"`
+	Description string                 `json:"description,omitempty" example:"Nightly export to the billing system"`
+	OwnerType   string                 `json:"ownerType" example:"organization"`
+	OwnerID     string                 `json:"ownerId" example:"acme"`
+	Roles       []string               `json:"roles" example:"admin"`
+	Disabled    bool                   `json:"disabled" example:"false"`
+	DisabledAt  time.Time              `json:"disabledAt,omitempty"`
+	Keys        []ServiceAccountKey    `json:"keys"`
+	APIKeys     []ServiceAccountAPIKey `json:"apiKeys"`
+	CreatedAt   time.Time              `json:"createdAt"`
+}
+
+// swagger:model ServiceAccountKey
+type ServiceAccountKey struct {
+	// ID is the kid the assertions name the key by
+	ID        string    `json:"id" example:"62b1b6c3f0e1a2b3c4d5e6f9"`
+	Algorithm string    `json:"algorithm" example:"ES256"`
+	PublicKey string    `json:"publicKey" example:"-----BEGIN PUBLIC KEY-----\nMFkw...\n-----END PUBLIC KEY-----"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// swagger:model ServiceAccountAPIKey
+type ServiceAccountAPIKey struct {
+	ID     string `json:"id" example:"62b1b6c3f0e1a2b3c4d5e6fa"`
+	Name   string `json:"name" example:"production"`
+	Prefix string `json:"prefix" example:"sak_3q2-7wEj"`
+	// Key is returned once on creation, it is not stored
+	Key       string    `json:"key,omitempty" example:"sak_3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,16 @@
+package response
+
+import "time"
+
+// swagger:model Session
+type Session struct {
+	ID         string    `json:"id" example:"62b1b6c3f0e1a2b3c4d5e6f7"`
+	UserID     string    `json:"userId" example:"62b1b6c3f0e1a2b3c4d5e6f8"`
+	IP         string    `json:"ip" example:"192.168.0.1"`
+	UserAgent  string    `json:"userAgent" example:"Mozilla/5.0"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	// Current is true for the session making the request
+	Current bool `json:"current"`
+}
@@ -1,10 +1,12 @@
 package response
 
 type User struct {
-	ID           string `json:"id"`
-	Username     string `json:"username"`
-	Email        string `json:"email"`
-	FirstName    string `json:"first_name"`
-	LastName     string `json:"last_name"`
-	CreationDate uint64 `json:"creationDate"`
+	ID           string   `json:"id"`
+	Username     string   `json:"username"`
+	Email        string   `json:"email"`
+	FirstName    string   `json:"first_name"`
+	LastName     string   `json:"last_name"`
+	CreationDate uint64   `json:"creationDate"`
+	Roles        []string `json:"roles"`
+	Tenant       string   `json:"tenant,omitempty"`
 }
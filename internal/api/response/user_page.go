@@ -0,0 +1,8 @@
+package response
+
+// swagger:model UserPage
+type UserPage struct {
+	Users []User `json:"users"`
+	// NextCursor is omitted on the last page
+	NextCursor string `json:"nextCursor,omitempty"`
+}
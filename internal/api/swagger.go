@@ -12,6 +12,12 @@ package api
 // @authorizationurl /validate
 // @name token
 // @description Signed token protects our admin endpoints
+// @securityDefinitions.basic ClientAuth
+// @description Registered client id and secret
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Access token as "Bearer <token>"
 // @contact.name   API Support
 // @contact.url    http://www.swagger.io/support
 // @contact.email  support@swagger.io
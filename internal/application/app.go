@@ -6,7 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -16,11 +16,29 @@ import (
 	"gitlab.com/g6834/team17/auth-service/internal/api/presenters"
 	"gitlab.com/g6834/team17/auth-service/internal/config"
 	"gitlab.com/g6834/team17/auth-service/internal/infrastructure"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
 	"gitlab.com/g6834/team17/auth-service/internal/repositories"
 	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/client_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/lockout_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/mfa_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/oauth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/organization_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/password_reset_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/personal_token_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/policy"
+	"gitlab.com/g6834/team17/auth-service/internal/services/rate_limit_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/redirect_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/role_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/service_account_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/session_service"
 	"gitlab.com/g6834/team17/auth-service/internal/services/user_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/webauthn_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"golang.org/x/sync/errgroup"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -51,22 +69,137 @@ func Start(ctx context.Context) {
 
 	// Repositories
 	userRepo := repositories.NewDatabaseRepo(mongo)
+	tokenFamilyRepo := repositories.NewTokenFamilyRepo(mongo)
+	revocationRepo := repositories.NewRevocationRepo(mongo)
+	clientRepo := repositories.NewClientRepo(mongo)
+	sessionRepo := repositories.NewSessionRepo(mongo)
+	roleRepo := repositories.NewRoleRepo(mongo)
+	policyRepo := repositories.NewPolicyRepo(mongo)
+	organizationRepo := repositories.NewOrganizationRepo(mongo)
+	membershipRepo := repositories.NewMembershipRepo(mongo)
+	authorizationCodeRepo := repositories.NewAuthorizationCodeRepo(mongo)
+	deviceGrantRepo := repositories.NewDeviceGrantRepo(mongo)
+	totpFactorRepo := repositories.NewTOTPFactorRepo(mongo)
+	mfaChallengeRepo := repositories.NewMFAChallengeRepo(mongo)
+	webauthnCredentialRepo := repositories.NewWebAuthnCredentialRepo(mongo)
+	webauthnSessionRepo := repositories.NewWebAuthnSessionRepo(mongo)
+	personalTokenRepo := repositories.NewPersonalTokenRepo(mongo)
+	serviceAccountRepo := repositories.NewServiceAccountRepo(mongo)
+	loginAttemptRepo := repositories.NewLoginAttemptRepo(mongo)
+	passwordResetRepo := repositories.NewPasswordResetRepo(mongo)
+	var rateLimitRepo interfaces.RateLimitRepo = repositories.NewMemoryRateLimitRepo()
+	if cfg.RateLimit.Backend == "mongo" {
+		rateLimitRepo = repositories.NewRateLimitRepo(mongo)
+	}
 
 	// Presenters
 	presenters := presenters.NewPresenters(logger)
 
 	// Services
+	keys, err := newKeyRing(cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed init token signing keys")
+	}
+
+	securityEvents := infrastructure.NewSecurityEvents(logger)
+	mfaService := mfa_service.New(totpFactorRepo, mfa_service.Settings{
+		Issuer:        cfg.MFA.Issuer,
+		RecoveryCodes: cfg.MFA.RecoveryCodes,
+	})
+	webauthnService, err := webauthn_service.New(webauthnCredentialRepo, webauthnSessionRepo, userRepo, securityEvents, webauthn_service.Settings{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		RPOrigin:      cfg.WebAuthn.RPOrigin,
+		Timeout:       time.Duration(cfg.WebAuthn.Timeout) * time.Second,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed init webauthn")
+	}
+	lockoutService := lockout_service.New(loginAttemptRepo, securityEvents, lockout_service.Settings{
+		UserThreshold:   cfg.Lockout.UserThreshold,
+		IPThreshold:     cfg.Lockout.IPThreshold,
+		BaseDelay:       time.Duration(cfg.Lockout.BaseDelay) * time.Second,
+		MaxDelay:        time.Duration(cfg.Lockout.MaxDelay) * time.Second,
+		LockoutDuration: time.Duration(cfg.Lockout.Duration) * time.Minute,
+		Window:          time.Duration(cfg.Lockout.Window) * time.Minute,
+	})
 	authService := auth_service.New(&auth_service.JwtSettings{
 		SecretKey:  cfg.Jwt.SecretKey,
 		AtLifeTime: cfg.Jwt.AtLifeTime,
 		RtLifeTime: cfg.Jwt.RtLifeTime,
-	}, userRepo)
+		Issuer:     cfg.Jwt.Issuer,
+		Audience:   cfg.Jwt.Audience,
+		Leeway:     time.Duration(cfg.Jwt.Leeway) * time.Second,
+		Keys:       keys,
+	}, userRepo,
+		auth_service.WithTokenFamilies(tokenFamilyRepo),
+		auth_service.WithRevocations(revocationRepo),
+		auth_service.WithSessions(sessionRepo),
+		auth_service.WithRoles(roleRepo),
+		auth_service.WithMemberships(membershipRepo),
+		auth_service.WithSecurityEvents(securityEvents),
+		auth_service.WithSecondFactor(mfaService),
+		auth_service.WithSecondFactor(webauthnService),
+		auth_service.WithMFAChallenges(mfaChallengeRepo, time.Duration(cfg.MFA.ChallengeLifeTime)*time.Second),
+		auth_service.WithPersonalTokens(personalTokenRepo),
+		auth_service.WithServiceAccounts(serviceAccountRepo),
+		auth_service.WithLoginThrottle(lockoutService),
+	)
 	userService := user_service.New(userRepo)
+	clientService := client_service.New(clientRepo)
+	sessionService := session_service.New(sessionRepo, tokenFamilyRepo, revocationRepo)
+	personalTokenService := personal_token_service.New(personalTokenRepo, personal_token_service.Settings{
+		MaxLifeTime: time.Duration(cfg.PersonalTokens.MaxLifeTime) * 24 * time.Hour,
+	})
+	roleService := role_service.New(roleRepo, userRepo)
+	serviceAccountService := service_account_service.New(serviceAccountRepo, userRepo, organizationRepo, roleRepo)
+	missingAdmins, err := roleService.Bootstrap(ctx, cfg.Bootstrap.Admins)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed bootstrap admins")
+	}
+	if len(missingAdmins) > 0 {
+		logger.Warn().Strs("usernames", missingAdmins).Msg("Bootstrap admins not found")
+	}
+	policyService := policy.New(policyRepo, time.Duration(cfg.Policy.RefreshInterval)*time.Second)
+	organizationService := organization_service.New(organizationRepo, membershipRepo, userRepo, roleRepo)
+	oauthService := oauth_service.New(clientRepo, authorizationCodeRepo, deviceGrantRepo, userRepo, authService, oauth_service.Settings{
+		CodeLifeTime:       time.Duration(cfg.OAuth.CodeLifeTime) * time.Second,
+		DeviceCodeLifeTime: time.Duration(cfg.OAuth.DeviceCodeLifeTime) * time.Second,
+		DeviceInterval:     time.Duration(cfg.OAuth.DeviceInterval) * time.Second,
+		VerificationURI:    strings.TrimSuffix(cfg.Jwt.Issuer, "/") + "/oauth/device",
+	})
+	redirectService, err := redirect_service.New(cfg.Redirects.Allowed, clientRepo, securityEvents)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed init redirect allowlist")
+	}
+
+	var notifier interfaces.Notifier = infrastructure.NewLogNotifier(logger)
+	if cfg.PasswordReset.Webhook != "" {
+		notifier = infrastructure.NewWebhookNotifier(cfg.PasswordReset.Webhook)
+	}
+	passwordResetService, err := password_reset_service.New(passwordResetRepo, userRepo, sessionService, notifier, securityEvents, logger, password_reset_service.Settings{
+		URL:        cfg.PasswordReset.URL,
+		LifeTime:   time.Duration(cfg.PasswordReset.LifeTime) * time.Minute,
+		MaxPending: cfg.PasswordReset.MaxPending,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed init password reset")
+	}
+	rateLimiter, err := rate_limit_service.New(rateLimitRepo, rateLimitRules(cfg))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed init rate limits")
+	}
+
+	trustedProxies, err := utils.ParseTrustedProxies(cfg.Http.TrustedProxies)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed init trusted proxies")
+	}
+	utils.InsecureCookies = cfg.Http.InsecureCookies
 
 	var g errgroup.Group
 
 	g.Go(func() error {
-		err := grpc.NewGrpcServer(authService).Start(cfg)
+		err := grpc.NewGrpcServer(authService, policyService, rateLimiter, trustedProxies).Start(cfg)
 
 		return fmt.Errorf("failed creating grpc server. %w", err)
 	})
@@ -86,6 +219,21 @@ func Start(ctx context.Context) {
 		return fmt.Errorf("debug server was terminated with an error. %w", err)
 	})
 
+	g.Go(func() error {
+		metricsRouter := chi.NewMux()
+		metricsRouter.Handle(cfg.Metrics.Path, promhttp.Handler())
+		metricsAddress := fmt.Sprintf("%v:%v", cfg.Metrics.Host, cfg.Metrics.Port)
+		metricsSrv, err := infrastructure.NewServer(logger, metricsRouter, metricsAddress, cfg)
+		if err != nil {
+			return fmt.Errorf("metrics server creating failed. %w", err)
+		}
+		servers = append(servers, metricsSrv)
+
+		err = metricsSrv.Start()
+
+		return fmt.Errorf("metrics server was terminated with an error. %w", err)
+	})
+
 	g.Go(func() error {
 		swaggerAddress := fmt.Sprintf("%v:%v", cfg.Http.Host, cfg.Http.SwaggerPort)
 		swaggerRouter := handlers.SwaggerRouter(swaggerAddress)
@@ -102,18 +250,32 @@ func Start(ctx context.Context) {
 
 	g.Go(func() error {
 		restRouter := chi.NewMux()
+		restRouter.Use(middlewares.ClientInfo(trustedProxies))
+		restRouter.Use(middlewares.RequestID)
+		restRouter.Use(middlewares.Tracer)
+		restRouter.Use(middlewares.Logger(logger))
+		restRouter.Use(middlewares.Recover(logger))
+		restRouter.Use(cors.Default().Handler)
+		restRouter.Use(middlewares.RateLimit(presenters, rateLimiter, models.RateLimitByIP, models.RateLimitByRoute))
+		limitUser := middlewares.RateLimit(presenters, rateLimiter, models.RateLimitByUser)
+
+		oauthRouter := handlers.OAuthRouter(logger, presenters, authService, clientService, oauthService, cfg.OAuth.LoginURL)
+
+		restRouter.Mount("/.well-known", handlers.WellKnownRouter(logger, presenters, authService, cfg.Jwt.Issuer, keys.Algorithms()))
+		restRouter.Mount("/oauth", oauthRouter)
+
 		restRouter.Route("/v1", func(r chi.Router) {
-			r.Use(middleware.RealIP)
-			r.Use(middlewares.RequestID)
-			r.Use(middlewares.Tracer)
-			r.Use(middlewares.Logger(logger))
-			r.Use(middlewares.Recover(logger))
-			r.Use(cors.Default().Handler)
+			r.Mount("/auth", handlers.AuthRouter(logger, presenters, authService, redirectService, webauthnService, passwordResetService))
+			r.Mount("/oauth", oauthRouter)
+
+			r.With(middlewares.Validate(presenters, authService), middlewares.RequireUser(presenters), limitUser).
+				Mount("/user", handlers.UserRouter(logger, presenters, userService, sessionService, organizationService, mfaService, webauthnService, personalTokenService))
 
-			r.Mount("/auth", handlers.AuthRouter(logger, presenters, authService))
+			r.With(middlewares.Validate(presenters, authService), limitUser).
+				Mount("/admin", handlers.AdminRouter(logger, presenters, userService, sessionService, roleService, serviceAccountService, lockoutService, policyService))
 
-			r.With(middlewares.Validate(presenters, authService)).
-				Mount("/user", handlers.UserRouter(logger, presenters, userService))
+			r.With(middlewares.Validate(presenters, authService), limitUser).
+				Mount("/orgs", handlers.OrganizationRouter(logger, presenters, organizationService))
 		})
 
 		restAddress := fmt.Sprintf("%v:%v", cfg.Http.Host, cfg.Http.Port)
@@ -134,12 +296,64 @@ func Start(ctx context.Context) {
 		Str("environment", cfg.App.Environment).
 		Msgf("Starting services: %s", cfg.App.Name)
 
-	err := g.Wait()
+	err = g.Wait()
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Fatal().Err(err).Msg("server start failed")
 	}
 }
 
+func rateLimitRules(cfg *config.Config) []models.RateLimitRule {
+	rules := make([]models.RateLimitRule, 0, len(cfg.RateLimit.Rules))
+	for _, rule := range cfg.RateLimit.Rules {
+		rules = append(rules, models.RateLimitRule{
+			Name:       rule.Name,
+			Method:     rule.Method,
+			Path:       rule.Path,
+			GRPCMethod: rule.GRPCMethod,
+			By:         rule.By,
+			Limit:      rule.Limit,
+			Period:     time.Duration(rule.Period) * time.Second,
+		})
+	}
+
+	return rules
+}
+
+func newKeyRing(cfg *config.Config) (*auth_service.KeyRing, error) {
+	keysCfg := cfg.Jwt.Keys
+	if len(keysCfg) == 0 {
+		keysCfg = []config.JwtKey{{
+			ID:         "default",
+			Algorithm:  cfg.Jwt.Algorithm,
+			SecretKey:  cfg.Jwt.SecretKey,
+			PrivateKey: cfg.Jwt.PrivateKey,
+		}}
+	}
+
+	keys := make([]*auth_service.Key, 0, len(keysCfg))
+	for _, keyCfg := range keysCfg {
+		key := &auth_service.Key{
+			ID:          keyCfg.ID,
+			State:       auth_service.KeyState(keyCfg.State),
+			ActivatesAt: keyCfg.ActivatesAt,
+			ExpiresAt:   keyCfg.ExpiresAt,
+		}
+
+		// retired keys are kept in the config for the record only
+		if key.State != auth_service.KeyRetired {
+			signer, err := auth_service.NewSigner(keyCfg.Algorithm, keyCfg.SecretKey, keyCfg.PrivateKey)
+			if err != nil {
+				return nil, fmt.Errorf("signing key %q: %w", keyCfg.ID, err)
+			}
+			key.Signer = signer
+		}
+
+		keys = append(keys, key)
+	}
+
+	return auth_service.NewKeyRing(keys...)
+}
+
 func Stop() {
 	logger.Warn().Msg("shutting down")
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(2)*time.Second)
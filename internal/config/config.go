@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -29,16 +30,23 @@ type Database struct {
 	Timeout    int    `yaml:"timeout"`
 }
 
-// Http - contains parameter rest json connection.
+// Http - contains parameter rest json connection. TrustedProxies are the
+// addresses or CIDR ranges of the reverse proxies whose X-Forwarded-For and
+// X-Real-IP headers name the client of REST requests and gRPC calls, the
+// headers of others are ignored.
+// InsecureCookies sends the token cookies over plain HTTP too, for local
+// development only.
 type Http struct {
-	Host            string `yaml:"host"`
-	Port            int    `yaml:"port"`
-	DebugPort       int    `yaml:"debugPort"`
-	SwaggerPort     int    `yaml:"swaggerPort"`
-	ShutdownTimeout int    `yaml:"shutdownTimeout"`
-	ReadTimeout     int    `yaml:"readTimeout"`
-	WriteTimeout    int    `yaml:"writeTimeout"`
-	IdleTimeout     int    `yaml:"idleTimeout"`
+	Host            string   `yaml:"host"`
+	Port            int      `yaml:"port"`
+	DebugPort       int      `yaml:"debugPort"`
+	SwaggerPort     int      `yaml:"swaggerPort"`
+	ShutdownTimeout int      `yaml:"shutdownTimeout"`
+	ReadTimeout     int      `yaml:"readTimeout"`
+	WriteTimeout    int      `yaml:"writeTimeout"`
+	IdleTimeout     int      `yaml:"idleTimeout"`
+	TrustedProxies  []string `yaml:"trustedProxies"`
+	InsecureCookies bool     `yaml:"insecureCookies"`
 }
 
 // App - contains all parameters project information.
@@ -49,10 +57,134 @@ type App struct {
 	Version     string `yaml:"version"`
 }
 
+// Jwt - contains token signing parameters. Asymmetric algorithms
+// (RS256, ES256, EdDSA...) read the key from the PEM file in PrivateKey.
+// When Keys is empty the top level key is used with the id "default".
+// Issuer is the public base URL of the service, OpenID Connect discovery and
+// its endpoints are published relative to it.
 type Jwt struct {
-	SecretKey  string `yaml:"secretKey"`
-	AtLifeTime int    `yaml:"atLifeTime"`
-	RtLifeTime int    `yaml:"rtLifeTime"`
+	SecretKey  string   `yaml:"secretKey"`
+	Algorithm  string   `yaml:"algorithm"`
+	PrivateKey string   `yaml:"privateKey"`
+	AtLifeTime int      `yaml:"atLifeTime"`
+	RtLifeTime int      `yaml:"rtLifeTime"`
+	Issuer     string   `yaml:"issuer"`
+	Audience   string   `yaml:"audience"`
+	Leeway     int      `yaml:"leeway"`
+	Keys       []JwtKey `yaml:"keys"`
+}
+
+// JwtKey - contains one key of the signing key ring and its rotation schedule.
+type JwtKey struct {
+	ID          string    `yaml:"id"`
+	State       string    `yaml:"state"`
+	SecretKey   string    `yaml:"secretKey"`
+	Algorithm   string    `yaml:"algorithm"`
+	PrivateKey  string    `yaml:"privateKey"`
+	ActivatesAt time.Time `yaml:"activatesAt"`
+	ExpiresAt   time.Time `yaml:"expiresAt"`
+}
+
+// OAuth - contains authorization server parameters. Users without a session
+// are sent from the authorize endpoint to LoginURL, when it is empty the
+// client gets the login_required error. Devices poll for their tokens no
+// more often than every DeviceInterval seconds.
+type OAuth struct {
+	LoginURL           string `yaml:"loginUrl"`
+	CodeLifeTime       int    `yaml:"codeLifeTime"`
+	DeviceCodeLifeTime int    `yaml:"deviceCodeLifeTime"`
+	DeviceInterval     int    `yaml:"deviceInterval"`
+}
+
+// Redirects - contains the allowed redirect targets of login and logout,
+// origins such as https://app.example.com or origins with a path pattern
+// such as https://*.example.com/callback/*. Relative paths are always allowed.
+type Redirects struct {
+	Allowed []string `yaml:"allowed"`
+}
+
+// MFA - contains second factor parameters. Issuer is the account name
+// prefix shown in authenticator apps, logins wait ChallengeLifeTime seconds
+// for the second factor.
+type MFA struct {
+	Issuer            string `yaml:"issuer"`
+	RecoveryCodes     int    `yaml:"recoveryCodes"`
+	ChallengeLifeTime int    `yaml:"challengeLifeTime"`
+}
+
+// WebAuthn - contains the relying party of security keys and passkeys. RPID
+// is the domain credentials are bound to, RPOrigin the origin of the login
+// pages, ceremonies wait Timeout seconds for the authenticator.
+type WebAuthn struct {
+	RPID          string `yaml:"rpId"`
+	RPDisplayName string `yaml:"rpDisplayName"`
+	RPOrigin      string `yaml:"rpOrigin"`
+	Timeout       int    `yaml:"timeout"`
+}
+
+// PersonalTokens - contains personal access token parameters, tokens are
+// valid for at most MaxLifeTime days.
+type PersonalTokens struct {
+	MaxLifeTime int `yaml:"maxLifeTime"`
+}
+
+// PasswordReset - contains password reset parameters. Reset links open URL
+// with the token in the token query parameter and are valid for LifeTime
+// minutes. They are posted as JSON to Webhook, or logged when it is empty.
+// At most MaxPending links are sent at once, further requests are dropped.
+type PasswordReset struct {
+	URL        string `yaml:"url"`
+	LifeTime   int    `yaml:"lifeTime"`
+	Webhook    string `yaml:"webhook"`
+	MaxPending int    `yaml:"maxPending"`
+}
+
+// Policy - contains policy check parameters. Rules are cached and read again
+// after RefreshInterval seconds, changes made through another replica apply
+// within it.
+type Policy struct {
+	RefreshInterval int `yaml:"refreshInterval"`
+}
+
+// Lockout - contains failed login parameters. After n failed logins the next
+// one waits BaseDelay seconds doubled n-1 times, at most MaxDelay seconds.
+// UserThreshold failures of an account or IPThreshold failures from a client
+// address lock it out for Duration minutes, zero never locks. Failures are
+// forgotten Window minutes after the last one.
+type Lockout struct {
+	UserThreshold int `yaml:"userThreshold"`
+	IPThreshold   int `yaml:"ipThreshold"`
+	BaseDelay     int `yaml:"baseDelay"`
+	MaxDelay      int `yaml:"maxDelay"`
+	Duration      int `yaml:"duration"`
+	Window        int `yaml:"window"`
+}
+
+// RateLimit - contains the request limits of the REST and gRPC servers.
+// Backend is memory, counting per instance, or mongo, counting across
+// replicas.
+type RateLimit struct {
+	Backend string          `yaml:"backend"`
+	Rules   []RateLimitRule `yaml:"rules"`
+}
+
+// RateLimitRule - allows Limit requests per Period seconds by ip, user or
+// route. REST requests match by Method and Path, gRPC calls by GRPCMethod,
+// patterns ending in /* match everything below.
+type RateLimitRule struct {
+	Name       string `yaml:"name"`
+	Method     string `yaml:"method"`
+	Path       string `yaml:"path"`
+	GRPCMethod string `yaml:"grpcMethod"`
+	By         string `yaml:"by"`
+	Limit      int    `yaml:"limit"`
+	Period     int    `yaml:"period"`
+}
+
+// Bootstrap - contains the accounts set up at startup. Admins are the
+// usernames of global accounts given the admin role.
+type Bootstrap struct {
+	Admins []string `yaml:"admins"`
 }
 
 // Metrics - contains all parameters metrics information.
@@ -80,13 +212,23 @@ type Grpc struct {
 
 // Config - contains all configuration parameters in config package.
 type Config struct {
-	App      App      `yaml:"app"`
-	Jwt      Jwt      `yaml:"jwt"`
-	Http     Http     `yaml:"http"`
-	Database Database `yaml:"database"`
-	Metrics  Metrics  `yaml:"metrics"`
-	Jaeger   Jaeger   `yaml:"jaeger"`
-	Grpc     Grpc     `yaml:"grpc"`
+	App            App            `yaml:"app"`
+	Jwt            Jwt            `yaml:"jwt"`
+	OAuth          OAuth          `yaml:"oauth"`
+	Redirects      Redirects      `yaml:"redirects"`
+	MFA            MFA            `yaml:"mfa"`
+	WebAuthn       WebAuthn       `yaml:"webauthn"`
+	PersonalTokens PersonalTokens `yaml:"personalTokens"`
+	PasswordReset  PasswordReset  `yaml:"passwordReset"`
+	Policy         Policy         `yaml:"policy"`
+	Lockout        Lockout        `yaml:"lockout"`
+	RateLimit      RateLimit      `yaml:"rateLimit"`
+	Bootstrap      Bootstrap      `yaml:"bootstrap"`
+	Http           Http           `yaml:"http"`
+	Database       Database       `yaml:"database"`
+	Metrics        Metrics        `yaml:"metrics"`
+	Jaeger         Jaeger         `yaml:"jaeger"`
+	Grpc           Grpc           `yaml:"grpc"`
 }
 
 // ReadConfigYML - read configurations from file and init instance Config.
@@ -1,8 +1,15 @@
 package constants
 
 const (
-	ACCESS_TOKEN  = "access_token"
-	REFRESH_TOKEN = "refresh_token"
-	REDIRECT_URI  = "redirect_uri"
-	CTX_USER      = "user"
+	ACCESS_TOKEN       = "access_token"
+	REFRESH_TOKEN      = "refresh_token"
+	REDIRECT_URI       = "redirect_uri"
+	CTX_USER           = "user"
+	CTX_CLIENT         = "client"
+	CTX_SERVICE        = "service"
+	CTX_SESSION        = "session"
+	CTX_CLIENT_INFO    = "client_info"
+	CTX_PERSONAL_TOKEN = "personal_token"
+	CTX_TOKEN_CLIENT   = "token_client"
+	CTX_REQUEST_ID     = "request_id"
 )
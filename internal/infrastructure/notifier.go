@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout bounds a notification delivery.
+const webhookTimeout = 10 * time.Second
+
+type logNotifier struct {
+	logger *zerolog.Logger
+}
+
+// NewLogNotifier writes notifications to the service log, for development
+// setups without a delivery service. The log then holds working reset links.
+func NewLogNotifier(logger *zerolog.Logger) *logNotifier {
+	return &logNotifier{logger: logger}
+}
+
+func (n *logNotifier) SendPasswordReset(ctx context.Context, user *models.User, link string, expiresAt time.Time) error {
+	n.logger.Info().
+		Str("user_id", user.ID.Hex()).
+		Str("email", user.Email).
+		Str("link", link).
+		Time("expires_at", expiresAt).
+		Msg("password reset link")
+
+	return nil
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier posts notifications as JSON to the URL, where a mail or
+// messaging service delivers them to the user.
+func NewWebhookNotifier(url string) *webhookNotifier {
+	return &webhookNotifier{
+		url: url,
+		client: &http.Client{
+			Timeout:   webhookTimeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+}
+
+type notification struct {
+	Type      string    `json:"type"`
+	UserID    string    `json:"userId"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Link      string    `json:"link"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (n *webhookNotifier) SendPasswordReset(ctx context.Context, user *models.User, link string, expiresAt time.Time) error {
+	body, err := json.Marshal(&notification{
+		Type:      "password_reset",
+		UserID:    user.ID.Hex(),
+		Username:  user.Username,
+		Email:     user.Email,
+		Link:      link,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("notification webhook answered %s", res.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package infrastructure
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// securityEventsTotal counts security events by type, so rejected redirects
+// and token replays can be alerted on from metrics as well.
+var securityEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "auth_security_events_total",
+	Help: "Number of security events by type.",
+}, []string{"type"})
+
+type securityEvents struct {
+	logger *zerolog.Logger
+}
+
+// NewSecurityEvents writes security events to the service log so they can be
+// picked up by the log pipeline alerting, and counts them.
+func NewSecurityEvents(logger *zerolog.Logger) *securityEvents {
+	return &securityEvents{logger: logger}
+}
+
+func (e *securityEvents) Emit(ctx context.Context, event *models.SecurityEvent) {
+	securityEventsTotal.WithLabelValues(event.Type).Inc()
+
+	entry := e.logger.Warn().
+		Str("security_event", event.Type).
+		Str("user_id", event.UserID).
+		Time("event_time", event.Time).
+		Str("trace.id", trace.SpanFromContext(ctx).SpanContext().TraceID().String())
+
+	for key, value := range event.Metadata {
+		entry = entry.Str(key, value)
+	}
+
+	entry.Msg("security event")
+}
@@ -3,13 +3,178 @@ package interfaces
 import (
 	"context"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"time"
 )
 
 type UserRepo interface {
 	Get(ctx context.Context, id string) (*models.User, error)
 	GetAll(ctx context.Context) ([]*models.User, error)
-	GetByName(ctx context.Context, uname string) (*models.User, error)
+	// GetByName looks the username up among the accounts of the tenant, empty for global accounts.
+	GetByName(ctx context.Context, tenant, uname string) (*models.User, error)
+	// Find returns one page of users matching the query.
+	Find(ctx context.Context, query models.UserQuery) (*models.UserPage, error)
 	Insert(ctx context.Context, user *models.User) error
 	Update(ctx context.Context, user *models.User) error
 	UpdatePassword(ctx context.Context, user *models.User) error
+	UpdateRoles(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id string) error
+}
+
+type TokenFamilyRepo interface {
+	Create(ctx context.Context, family *models.TokenFamily) error
+	Get(ctx context.Context, id string) (*models.TokenFamily, error)
+	// Rotate replaces the current token id only if it still equals currentID,
+	// returning false when the family was already rotated or revoked.
+	Rotate(ctx context.Context, id, currentID, nextID string, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id string) error
+}
+
+type RevocationRepo interface {
+	// Revoke keeps the id revoked until expiresAt, after which the token is rejected as expired anyway.
+	Revoke(ctx context.Context, id string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, ids ...string) (bool, error)
+}
+
+type ClientRepo interface {
+	Get(ctx context.Context, id string) (*models.Client, error)
+}
+
+type SessionRepo interface {
+	Create(ctx context.Context, session *models.Session) error
+	Get(ctx context.Context, id string) (*models.Session, error)
+	// GetByUser returns the active sessions of the user, most recently used first.
+	GetByUser(ctx context.Context, userID string) ([]*models.Session, error)
+	Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error
+	Revoke(ctx context.Context, id string) error
+}
+
+type RoleRepo interface {
+	GetAll(ctx context.Context) ([]*models.Role, error)
+	Get(ctx context.Context, name string) (*models.Role, error)
+	// GetMany returns the existing roles among names, unknown names are skipped.
+	GetMany(ctx context.Context, names []string) ([]*models.Role, error)
+	Save(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+}
+
+type OrganizationRepo interface {
+	Get(ctx context.Context, id string) (*models.Organization, error)
+	Insert(ctx context.Context, org *models.Organization) error
+}
+
+type MembershipRepo interface {
+	Get(ctx context.Context, orgID, userID string) (*models.Membership, error)
+	GetByOrganization(ctx context.Context, orgID string) ([]*models.Membership, error)
+	// Save creates the membership or replaces the roles of an existing one.
+	Save(ctx context.Context, membership *models.Membership) error
+	Delete(ctx context.Context, orgID, userID string) error
+}
+
+type AuthorizationCodeRepo interface {
+	Create(ctx context.Context, code *models.AuthorizationCode) error
+	// Consume deletes the code and returns it, so it can be redeemed only once.
+	Consume(ctx context.Context, id string) (*models.AuthorizationCode, error)
+}
+
+type DeviceGrantRepo interface {
+	Create(ctx context.Context, grant *models.DeviceGrant) error
+	GetByUserCode(ctx context.Context, userCode string) (*models.DeviceGrant, error)
+	// Decide records the decision of the user on a pending grant.
+	Decide(ctx context.Context, id string, status models.DeviceGrantStatus, userID, tenant string) error
+	// Poll records the poll time and returns the grant as it was before.
+	Poll(ctx context.Context, id string, at time.Time) (*models.DeviceGrant, error)
+	SlowDown(ctx context.Context, id string, seconds int64) error
+	// Consume deletes the grant and returns it, so it can be redeemed only once.
+	Consume(ctx context.Context, id string) (*models.DeviceGrant, error)
+}
+
+type TOTPFactorRepo interface {
+	Get(ctx context.Context, userID string) (*models.TOTPFactor, error)
+	// Save stores a new unconfirmed factor, a confirmed one is never replaced.
+	Save(ctx context.Context, factor *models.TOTPFactor) error
+	Confirm(ctx context.Context, userID string, recoveryCodes []string, step int64, at time.Time) error
+	// UseStep records the time step of an accepted code, returning false when it was used before.
+	UseStep(ctx context.Context, userID string, step int64) (bool, error)
+	// UseRecoveryCode removes the hashed code, returning false when the user has no such code.
+	UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error)
+	Delete(ctx context.Context, userID string) error
+}
+
+type MFAChallengeRepo interface {
+	Create(ctx context.Context, challenge *models.MFAChallenge) error
+	Get(ctx context.Context, id string) (*models.MFAChallenge, error)
+	// Attempt counts an attempt to answer the challenge and returns it with the attempt counted.
+	Attempt(ctx context.Context, id string) (*models.MFAChallenge, error)
+	// Consume deletes the challenge and returns it, so it can be answered only once.
+	Consume(ctx context.Context, id string) (*models.MFAChallenge, error)
+}
+
+type WebAuthnCredentialRepo interface {
+	Create(ctx context.Context, credential *models.WebAuthnCredential) error
+	Get(ctx context.Context, id string) (*models.WebAuthnCredential, error)
+	GetByUser(ctx context.Context, userID string) ([]*models.WebAuthnCredential, error)
+	UpdateSignCount(ctx context.Context, id string, signCount uint32, usedAt time.Time) error
+	// Delete removes a credential of the user, credentials of other users are not found.
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type WebAuthnSessionRepo interface {
+	Create(ctx context.Context, session *models.WebAuthnSession) error
+	// Consume deletes the session and returns it, so a challenge is answered only once.
+	Consume(ctx context.Context, id string) (*models.WebAuthnSession, error)
+}
+
+type PersonalTokenRepo interface {
+	Create(ctx context.Context, token *models.PersonalAccessToken) error
+	GetByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error)
+	// GetByUser returns the unexpired tokens of the user, newest first.
+	GetByUser(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error)
+	Touch(ctx context.Context, id string, lastUsedAt time.Time) error
+	Delete(ctx context.Context, userID, id string) error
+}
+
+type ServiceAccountRepo interface {
+	Create(ctx context.Context, account *models.ServiceAccount) error
+	Get(ctx context.Context, id string) (*models.ServiceAccount, error)
+	GetByAPIKey(ctx context.Context, hash string) (*models.ServiceAccount, error)
+	// GetAll lists the accounts of the owner by name, every account when ownerID is empty.
+	GetAll(ctx context.Context, ownerID string) ([]*models.ServiceAccount, error)
+	SetDisabled(ctx context.Context, id string, disabled bool, at time.Time) error
+	UpdateRoles(ctx context.Context, id string, roles []string) error
+	AddKey(ctx context.Context, id string, key *models.ServiceAccountKey) error
+	DeleteKey(ctx context.Context, id, keyID string) error
+	AddAPIKey(ctx context.Context, id string, key *models.ServiceAccountAPIKey) error
+	DeleteAPIKey(ctx context.Context, id, keyID string) error
+}
+
+type LoginAttemptRepo interface {
+	// Get returns the counters with the ids that have not expired at now.
+	Get(ctx context.Context, ids []string, now time.Time) ([]*models.LoginAttempts, error)
+	// Fail counts a failed login at the time, starting over when the counter has expired, and keeps the counter until expiresAt.
+	Fail(ctx context.Context, kind, value string, at, expiresAt time.Time) (*models.LoginAttempts, error)
+	// Lock locks the counter out until the time, it expires when the lockout ends.
+	Lock(ctx context.Context, id string, until time.Time) error
+	// GetLocked returns the counters locked out at now, the latest lockout first.
+	GetLocked(ctx context.Context, now time.Time) ([]*models.LoginAttempts, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type RateLimitRepo interface {
+	// Hit counts a request of the key in the window starting at the time and returns the requests of the window, kept until expiresAt.
+	Hit(ctx context.Context, key string, window, expiresAt time.Time) (int, error)
+	// Count returns the requests of the key in the window starting at the time.
+	Count(ctx context.Context, key string, window time.Time) (int, error)
+}
+
+type PasswordResetRepo interface {
+	Create(ctx context.Context, reset *models.PasswordReset) error
+	// Consume deletes the reset and returns it, so a token is used only once.
+	Consume(ctx context.Context, id string) (*models.PasswordReset, error)
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type PolicyRepo interface {
+	GetAll(ctx context.Context) ([]*models.PolicyRule, error)
+	Save(ctx context.Context, rule *models.PolicyRule) error
+	Delete(ctx context.Context, id string) error
 }
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"time"
+)
+
+type SecurityEvents interface {
+	Emit(ctx context.Context, event *models.SecurityEvent)
+}
+
+// Notifier delivers messages to users out of band.
+type Notifier interface {
+	// SendPasswordReset delivers the link resetting the password of the user, valid until expiresAt.
+	SendPasswordReset(ctx context.Context, user *models.User, link string, expiresAt time.Time) error
+}
@@ -2,18 +2,182 @@ package interfaces
 
 import (
 	"context"
+	"github.com/duo-labs/webauthn/protocol"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"time"
 )
 
 type AuthService interface {
-	Authorize(ctx context.Context, uname, pass string) (*models.TokenDetails, error)
-	VerifyToken(ctx context.Context, tokens *models.TokenPair) (*models.TokenPair, error)
+	// Authorize returns a *models.MFARequiredError when the user has a second factor.
+	Authorize(ctx context.Context, tenant, uname, pass string) (*models.TokenDetails, error)
+	// AuthorizeMFA finishes a login with the challenge token and a code of the second factor, subject to the lockout as Authorize is.
+	AuthorizeMFA(ctx context.Context, challengeToken, code string) (*models.TokenDetails, error)
+	// PendingMFA returns the login waiting for the second factor without answering it.
+	PendingMFA(ctx context.Context, challengeToken string) (*models.MFAChallenge, error)
+	// AuthorizePasswordless logs in the user of a passwordless credential, subject to the lockout as Authorize is.
+	AuthorizePasswordless(ctx context.Context, userID, tenant string) (*models.TokenDetails, error)
+	// IssueTokens starts a session of the user for an OAuth client once the client has proven its grant.
+	IssueTokens(ctx context.Context, userID string, grant *models.Grant) (*models.TokenDetails, error)
+	// IDToken issues an OpenID Connect ID token of the user for the client.
+	IDToken(ctx context.Context, userID, clientID, scope, nonce string) (string, error)
+	VerifyToken(ctx context.Context, tokens *models.TokenPair) (*models.TokenDetails, error)
+	Refresh(ctx context.Context, refreshToken string) (*models.TokenDetails, error)
+	// RefreshClient rotates a refresh token issued to the OAuth client, Refresh refuses those.
+	RefreshClient(ctx context.Context, clientID, refreshToken string) (*models.TokenDetails, error)
 	ParseToken(ctx context.Context, tokenString string) (*models.User, bool, error)
+	// IssueServiceToken issues an access token to a machine client of the client_credentials grant.
+	IssueServiceToken(ctx context.Context, client *models.Client, scope string) (*models.TokenDetails, error)
+	// IssueServiceAccountToken exchanges an RFC 7523 JWT assertion signed by a service account for an access token.
+	IssueServiceAccountToken(ctx context.Context, assertion string) (*models.TokenDetails, error)
+	// VerifyAccessToken identifies the user or machine client of an access token presented without a refresh token,
+	// the user of a personal access token or the service account of an API key.
+	VerifyAccessToken(ctx context.Context, tokenString string) (*models.Principal, error)
+	Logout(ctx context.Context, tokens *models.TokenPair) error
+	JWKS(ctx context.Context) *models.JWKSet
+	Introspect(ctx context.Context, token, tokenTypeHint string) (*models.Introspection, error)
 }
 
 type UserService interface {
 	GetAll(ctx context.Context) ([]*models.User, error)
+	Get(ctx context.Context, id string) (*models.User, error)
+	Find(ctx context.Context, query models.UserQuery) (*models.UserPage, error)
 	Create(ctx context.Context, user *models.User) (err error)
 	Update(ctx context.Context, user *models.User) (err error)
 	UpdatePassword(ctx context.Context, user *models.User) (err error)
+	ChangePassword(ctx context.Context, id, current, password string) error
+	Delete(ctx context.Context, id string) error
+}
+
+type PasswordResetService interface {
+	// Forgot sends a reset link to the user in the background, unknown usernames are ignored silently.
+	Forgot(ctx context.Context, tenant, username string)
+	// Reset sets the password with the token of a reset link and ends every session of the user.
+	Reset(ctx context.Context, token, password string) error
+}
+
+type ClientService interface {
+	Authenticate(ctx context.Context, clientID, secret string) (*models.Client, error)
+}
+
+type SessionService interface {
+	GetAll(ctx context.Context, userID string) ([]*models.Session, error)
+	Revoke(ctx context.Context, userID, id string) error
+	RevokeAll(ctx context.Context, userID, exceptID string) error
+}
+
+type PersonalTokenService interface {
+	// Create returns the token with its secret, which is not shown again.
+	Create(ctx context.Context, user *models.User, name string, scopes []string, lifeTime time.Duration) (*models.PersonalAccessToken, string, error)
+	GetAll(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id string) error
+}
+
+type ServiceAccountService interface {
+	Create(ctx context.Context, account *models.ServiceAccount) error
+	Get(ctx context.Context, id string) (*models.ServiceAccount, error)
+	GetAll(ctx context.Context, ownerID string) ([]*models.ServiceAccount, error)
+	SetDisabled(ctx context.Context, id string, disabled bool) (*models.ServiceAccount, error)
+	AssignRoles(ctx context.Context, id string, roles []string) (*models.ServiceAccount, error)
+	// AddKey registers a PEM encoded public key the account signs assertions with.
+	AddKey(ctx context.Context, id, algorithm, publicKey string) (*models.ServiceAccountKey, error)
+	DeleteKey(ctx context.Context, id, keyID string) error
+	// CreateAPIKey returns the key with its secret, which is not shown again.
+	CreateAPIKey(ctx context.Context, id, name string) (*models.ServiceAccountAPIKey, string, error)
+	DeleteAPIKey(ctx context.Context, id, keyID string) error
+}
+
+type RoleService interface {
+	GetAll(ctx context.Context) ([]*models.Role, error)
+	Save(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, name string) error
+	Assign(ctx context.Context, userID string, roles []string) (*models.User, error)
+	Bootstrap(ctx context.Context, usernames []string) (missing []string, err error)
+}
+
+type OrganizationService interface {
+	Create(ctx context.Context, org *models.Organization) error
+	Get(ctx context.Context, id string) (*models.Organization, error)
+	Members(ctx context.Context, orgID string) ([]*models.Membership, error)
+	Invite(ctx context.Context, orgID, username string, roles []string) (*models.Membership, error)
+	Remove(ctx context.Context, orgID, userID string) error
+}
+
+type OAuthService interface {
+	ValidateAuthorization(ctx context.Context, req *models.AuthorizationRequest) (*models.Client, error)
+	Authorize(ctx context.Context, req *models.AuthorizationRequest, user *models.User) (string, error)
+	Exchange(ctx context.Context, client *models.Client, code, redirectURI, verifier string) (*models.TokenDetails, error)
+	Refresh(ctx context.Context, client *models.Client, refreshToken string) (*models.TokenDetails, error)
+	ClientCredentials(ctx context.Context, client *models.Client, scope string) (*models.TokenDetails, error)
+	JWTBearer(ctx context.Context, assertion string) (*models.TokenDetails, error)
+	DeviceAuthorization(ctx context.Context, client *models.Client, scope string) (*models.DeviceAuthorization, error)
+	DeviceToken(ctx context.Context, client *models.Client, deviceCode string) (*models.TokenDetails, error)
+	DeviceGrant(ctx context.Context, userCode string) (*models.DeviceGrant, error)
+	DecideDevice(ctx context.Context, userCode string, user *models.User, approve bool) error
+	UserInfo(ctx context.Context, accessToken string) (*models.UserInfo, error)
+}
+
+// SecondFactor is asked by the auth service for the second factor of a login.
+type SecondFactor interface {
+	// Methods returns the second factor methods of the user, none when it has no second factor.
+	Methods(ctx context.Context, userID string) ([]string, error)
+	// Verify returns models.InvalidMFACodeErr when the code is not one of this second factor.
+	Verify(ctx context.Context, userID, code string) error
+}
+
+// LoginThrottle is asked by the auth service before and after checking a
+// password or a second factor code, it slows down and locks out guessing.
+type LoginThrottle interface {
+	// Check returns a *models.LoginThrottledError while the user, empty for unknown usernames, or the address may not try a password.
+	Check(ctx context.Context, userID, ip string) error
+	// Failed counts a wrong password or code of the user, empty for unknown usernames, from the address.
+	Failed(ctx context.Context, userID, ip string) error
+	// Succeeded forgets the failed logins of the user once a login started a session.
+	Succeeded(ctx context.Context, userID string) error
+}
+
+type LockoutService interface {
+	LoginThrottle
+	// Locked returns the accounts and addresses locked out now.
+	Locked(ctx context.Context) ([]*models.LoginAttempts, error)
+	// Unlock drops the counter with the id, ending its lockout and delays.
+	Unlock(ctx context.Context, id string) error
+}
+
+type RateLimiter interface {
+	// Limit counts the request against the matching rules that count by one of the kinds, a *models.RateLimitedError is returned once a limit is spent.
+	Limit(ctx context.Context, request *models.RateLimitRequest, kinds ...string) error
+}
+
+type MFAService interface {
+	SecondFactor
+	Enroll(ctx context.Context, user *models.User) (*models.TOTPEnrollment, error)
+	// Confirm enables the enrolled factor with its first code and returns the recovery codes.
+	Confirm(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, code string) error
+}
+
+type WebAuthnService interface {
+	SecondFactor
+	BeginRegistration(ctx context.Context, user *models.User) (*protocol.CredentialCreation, error)
+	FinishRegistration(ctx context.Context, user *models.User, name string, response []byte) (*models.WebAuthnCredential, error)
+	// BeginLogin starts an assertion for the second factor of the user.
+	BeginLogin(ctx context.Context, userID string) (*protocol.CredentialAssertion, error)
+	// BeginPasswordless starts an assertion that logs in the owner of the credential, username may be empty for passkeys.
+	BeginPasswordless(ctx context.Context, tenant, username string) (*protocol.CredentialAssertion, error)
+	// Login checks a passwordless assertion and returns the user and tenant to log in.
+	Login(ctx context.Context, response []byte) (userID, tenant string, err error)
+	Credentials(ctx context.Context, userID string) ([]*models.WebAuthnCredential, error)
+	DeleteCredential(ctx context.Context, userID, id string) error
+}
+
+type PolicyService interface {
+	Check(ctx context.Context, subject *models.User, action, resource string) (*models.PolicyDecision, error)
+	GetAll(ctx context.Context) ([]*models.PolicyRule, error)
+	Save(ctx context.Context, rule *models.PolicyRule) error
+	Delete(ctx context.Context, id string) error
+}
+
+type RedirectService interface {
+	// Check returns an error when redirectURI is not an allowed redirect target.
+	Check(ctx context.Context, redirectURI, clientID string) error
 }
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ResponseTypeCode           = "code"
+	GrantTypeAuthorization     = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+	GrantTypeJWTBearer         = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	CodeChallengeS256          = "S256"
+)
+
+// OpenID Connect scopes: openid asks for an ID token, profile and email add
+// their claims to the ID token and the userinfo response.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// AuthorizationRequest holds the parameters of an authorization endpoint request.
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce is copied to the ID token to bind it to the client session.
+	Nonce string
+}
+
+// AuthorizationCode is a pending code grant. The code itself is never
+// stored, ID is its SHA-256 hash.
+type AuthorizationCode struct {
+	ID                  string    `bson:"_id"`
+	ClientID            string    `bson:"client_id"`
+	UserID              string    `bson:"user_id"`
+	Tenant              string    `bson:"tenant,omitempty"`
+	RedirectURI         string    `bson:"redirect_uri"`
+	Scope               string    `bson:"scope"`
+	CodeChallenge       string    `bson:"code_challenge"`
+	CodeChallengeMethod string    `bson:"code_challenge_method"`
+	Nonce               string    `bson:"nonce,omitempty"`
+	CreatedAt           time.Time `bson:"created_at"`
+	ExpiresAt           time.Time `bson:"expires_at"`
+}
+
+// Grant describes who a session is started for besides the user: the
+// organization, and the OAuth client with the scope it was granted.
+type Grant struct {
+	Tenant   string
+	ClientID string
+	Scope    string
+}
+
+// ScopeContains reports whether the space separated scope has value.
+func ScopeContains(scope, value string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == value {
+			return true
+		}
+	}
+
+	return false
+}
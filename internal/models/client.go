@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Client is a registered OAuth client, e.g. a resource server calling the
+// introspection endpoint or an app using the authorization code grant. Only
+// the bcrypt hash of its secret is stored, public clients such as SPAs and
+// mobile apps have none.
+type Client struct {
+	ID         string `bson:"_id" json:"clientId"`
+	Name       string `bson:"name" json:"name"`
+	SecretHash string `bson:"secret_hash,omitempty" json:"-"`
+	Public     bool   `bson:"public" json:"public"`
+	// RedirectURIs are the exact redirect URIs the client may ask for.
+	RedirectURIs []string `bson:"redirect_uris" json:"redirectUris"`
+	// Scopes are the scopes the client may request.
+	Scopes []string `bson:"scopes" json:"scopes"`
+	// GrantTypes are the grants the client may use, clients registered
+	// without any use the authorization_code and refresh_token grants.
+	GrantTypes []string `bson:"grant_types,omitempty" json:"grantTypes,omitempty"`
+	// TokenLifeTime is the lifetime in seconds of client_credentials access
+	// tokens, the user access token lifetime applies when it is zero.
+	TokenLifeTime int `bson:"token_life_time,omitempty" json:"tokenLifeTime,omitempty"`
+	// ResourceServer clients may introspect the tokens of every user and
+	// client, other clients may not.
+	ResourceServer bool      `bson:"resource_server,omitempty" json:"resourceServer,omitempty"`
+	CreatedAt      time.Time `bson:"created_at" json:"createdAt"`
+}
+
+func (c *Client) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) HasGrantType(grantType string) bool {
+	if len(c.GrantTypes) == 0 {
+		return grantType == GrantTypeAuthorization || grantType == GrantTypeRefreshToken
+	}
+
+	for _, g := range c.GrantTypes {
+		if g == grantType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,7 @@
+package models
+
+// ClientInfo describes the device a request comes from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type DeviceGrantStatus string
+
+const (
+	DeviceGrantPending  DeviceGrantStatus = "pending"
+	DeviceGrantApproved DeviceGrantStatus = "approved"
+	DeviceGrantDenied   DeviceGrantStatus = "denied"
+)
+
+// DeviceAuthorization is the RFC 8628 device authorization response. The
+// device polls the token endpoint with DeviceCode while the user enters
+// UserCode at VerificationURI.
+type DeviceAuthorization struct {
+	DeviceCode              string
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresIn               int64
+	Interval                int64
+}
+
+// DeviceGrant is a pending device authorization. The device code is never
+// stored, ID is its SHA-256 hash. UserID and Tenant are set once the user
+// approves the request.
+type DeviceGrant struct {
+	ID       string            `bson:"_id"`
+	UserCode string            `bson:"user_code"`
+	ClientID string            `bson:"client_id"`
+	Scope    string            `bson:"scope"`
+	Status   DeviceGrantStatus `bson:"status"`
+	UserID   string            `bson:"user_id,omitempty"`
+	Tenant   string            `bson:"tenant,omitempty"`
+	// Interval is the minimum number of seconds between polls, it grows
+	// every time the device polls too fast.
+	Interval     int64     `bson:"interval"`
+	LastPolledAt time.Time `bson:"last_polled_at,omitempty"`
+	CreatedAt    time.Time `bson:"created_at"`
+	ExpiresAt    time.Time `bson:"expires_at"`
+}
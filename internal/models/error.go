@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
@@ -46,3 +47,24 @@ func ErrorNotFound(err error) StatusError {
 func ErrorInternal(err error) StatusError {
 	return newError(err, http.StatusInternalServerError)
 }
+
+func ErrorUnauthorized(err error) StatusError {
+	return newError(err, http.StatusUnauthorized)
+}
+
+func ErrorTooManyRequests(err error) StatusError {
+	return newError(err, http.StatusTooManyRequests)
+}
+
+// ErrorToken reports an expired token as unauthorized, so the client knows
+// it may refresh, and any other invalid token as forbidden.
+func ErrorToken(err error) StatusError {
+	switch {
+	case errors.Is(err, TokenExpiredErr):
+		return newError(err, http.StatusUnauthorized)
+	case errors.Is(err, InvalidTokenErr):
+		return newError(err, http.StatusForbidden)
+	default:
+		return newError(err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+// Introspection is the RFC 7662 description of a token. Inactive tokens
+// carry no other member.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Subject   string `json:"sub,omitempty"`
+	Username  string `json:"username,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Tenant    string `json:"tenant,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
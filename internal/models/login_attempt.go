@@ -0,0 +1,51 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// LoginAttemptUser counts the failed logins of an account, by user id.
+	LoginAttemptUser = "user"
+	// LoginAttemptIP counts the failed logins from a client address.
+	LoginAttemptIP = "ip"
+)
+
+// LoginAttemptsID is the id of the counter of the user id or address.
+func LoginAttemptsID(kind, value string) string {
+	return kind + ":" + value
+}
+
+// LoginAttempts counts the failed logins of an account or of a client
+// address. The counter is dropped once ExpiresAt passes, that is a while
+// after the last failure or when the lockout ends.
+type LoginAttempts struct {
+	ID            string    `bson:"_id" json:"id"`
+	Kind          string    `bson:"kind" json:"kind"`
+	Value         string    `bson:"value" json:"value"`
+	Failures      int       `bson:"failures" json:"failures"`
+	LastFailureAt time.Time `bson:"last_failure_at" json:"lastFailureAt"`
+	LockedUntil   time.Time `bson:"locked_until,omitempty" json:"lockedUntil,omitempty"`
+	ExpiresAt     time.Time `bson:"expires_at" json:"expiresAt"`
+}
+
+func (a *LoginAttempts) Locked(now time.Time) bool {
+	return now.Before(a.LockedUntil)
+}
+
+// LoginThrottledError is returned for logins refused without checking the
+// password, while the account or the address backs off after failed logins
+// or is locked out. The login may be tried again after RetryAfter.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed logins, locked for %v", e.RetryAfter.Round(time.Second))
+	}
+
+	return fmt.Sprintf("too many failed logins, retry in %v", e.RetryAfter.Round(time.Second))
+}
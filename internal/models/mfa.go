@@ -0,0 +1,65 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// MFAMethodTOTP is a code of an RFC 6238 authenticator app.
+	MFAMethodTOTP = "totp"
+	// MFAMethodRecoveryCode is one of the one-time codes shown on enrollment.
+	MFAMethodRecoveryCode = "recovery_code"
+)
+
+// InvalidMFACodeErr is returned for second factor codes that are wrong or
+// have been used before.
+var InvalidMFACodeErr = errors.New("mfa code is invalid")
+
+// TOTPFactor is the authenticator app of a user, ID is the user id. It only
+// counts as a second factor once the user has confirmed it with a first code.
+// Recovery codes are stored as SHA-256 hashes and removed once used.
+type TOTPFactor struct {
+	ID            string   `bson:"_id"`
+	Secret        string   `bson:"secret"`
+	Confirmed     bool     `bson:"confirmed"`
+	RecoveryCodes []string `bson:"recovery_codes,omitempty"`
+	// LastStep is the time step of the last accepted code, a code is
+	// accepted only once.
+	LastStep    int64     `bson:"last_step"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ConfirmedAt time.Time `bson:"confirmed_at,omitempty"`
+}
+
+// TOTPEnrollment is shown to the user once to set up the authenticator app.
+type TOTPEnrollment struct {
+	Secret string
+	// URI is the otpauth:// key URI, QRCode is the PNG image encoding it.
+	URI    string
+	QRCode []byte
+}
+
+// MFAChallenge is a login that passed the password check and waits for the
+// second factor. The challenge token is never stored, ID is its SHA-256 hash.
+type MFAChallenge struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	Tenant    string    `bson:"tenant,omitempty"`
+	Methods   []string  `bson:"methods"`
+	Attempts  int       `bson:"attempts"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// MFARequiredError is returned by a login with a correct password when the
+// user has a second factor. The login is finished with the token and a code
+// of one of the methods.
+type MFARequiredError struct {
+	Token     string
+	Methods   []string
+	ExpiresAt time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return "second factor is required"
+}
@@ -0,0 +1,43 @@
+package models
+
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthLoginRequired           = "login_required"
+	OAuthServerError             = "server_error"
+	// RFC 8628 3.5 device access token errors
+	OAuthAuthorizationPending = "authorization_pending"
+	OAuthSlowDown             = "slow_down"
+	OAuthAccessDenied         = "access_denied"
+	OAuthExpiredToken         = "expired_token"
+)
+
+// OAuthError is an RFC 6749 error response. Errors with the same code match
+// with errors.Is.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+
+	return e.Code + ": " + e.Description
+}
+
+func (e *OAuthError) Is(target error) bool {
+	t, ok := target.(*OAuthError)
+
+	return ok && t.Code == e.Code
+}
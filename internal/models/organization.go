@@ -0,0 +1,21 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Organization is a tenant, its ID is the slug carried in the tenant claim.
+type Organization struct {
+	ID        string    `bson:"_id" json:"id"`
+	Name      string    `bson:"name" json:"name"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// Membership grants a user access to an organization with the roles it holds there.
+type Membership struct {
+	OrganizationID string             `bson:"org_id" json:"organizationId"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"userId"`
+	Roles          []string           `bson:"roles" json:"roles"`
+	CreatedAt      time.Time          `bson:"created_at" json:"createdAt"`
+}
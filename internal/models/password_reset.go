@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PasswordReset is a pending password reset of a user. The token is sent to
+// the user and never stored, ID is its SHA-256 hash. It is deleted when
+// used, expired ones are removed by a TTL index.
+type PasswordReset struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"user_id"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PersonalTokenPrefix starts every personal access token, so they are told
+// apart from JWTs and found by secret scanners.
+const PersonalTokenPrefix = "pat_"
+
+// PersonalAccessToken lets scripts act as the user without a password. The
+// token is shown once and never stored, Hash is its SHA-256 hash and Prefix
+// its first characters, enough for the user to recognize it. Scopes are the
+// permissions the token may use, of those the user holds when it is used.
+type PersonalAccessToken struct {
+	ID         string    `bson:"_id" json:"id"`
+	UserID     string    `bson:"user_id" json:"userId"`
+	Tenant     string    `bson:"tenant,omitempty" json:"tenant,omitempty"`
+	Name       string    `bson:"name" json:"name"`
+	Prefix     string    `bson:"prefix" json:"prefix"`
+	Hash       string    `bson:"hash" json:"-"`
+	Scopes     []string  `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time `bson:"created_at" json:"createdAt"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expiresAt"`
+	LastUsedAt time.Time `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+const (
+	PolicyEffectAllow = "allow"
+	PolicyEffectDeny  = "deny"
+)
+
+// PolicyRule grants or denies actions on resources to the subjects holding
+// one of Roles or Permissions, a rule naming neither applies to every
+// authenticated subject. Actions and Resources are patterns where * matches
+// any run of characters, e.g. "documents:*" or "projects/*/files/*".
+type PolicyRule struct {
+	ID          string   `bson:"_id" json:"id"`
+	Description string   `bson:"description" json:"description"`
+	Effect      string   `bson:"effect" json:"effect"`
+	Roles       []string `bson:"roles" json:"roles"`
+	Permissions []string `bson:"permissions" json:"permissions"`
+	Actions     []string `bson:"actions" json:"actions"`
+	Resources   []string `bson:"resources" json:"resources"`
+}
+
+// PolicyDecision is the outcome of a policy check, Rule is nil when no rule
+// matched and the request was denied by default.
+type PolicyDecision struct {
+	Allowed bool
+	Rule    *PolicyRule
+}
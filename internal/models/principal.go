@@ -0,0 +1,36 @@
+package models
+
+type PrincipalType string
+
+const (
+	PrincipalUser    PrincipalType = "user"
+	PrincipalService PrincipalType = "service"
+	// PrincipalServiceAccount is a service account, it is put in the context
+	// as a *User with this Type so permission checks apply to it unchanged.
+	PrincipalServiceAccount PrincipalType = "service_account"
+)
+
+// ServicePrincipal is a machine client authenticated with the
+// client_credentials grant, it acts on its own behalf and not for a user.
+type ServicePrincipal struct {
+	ClientID string `json:"clientId"`
+	Scope    string `json:"scope"`
+}
+
+func (s *ServicePrincipal) HasScope(scope string) bool {
+	return ScopeContains(s.Scope, scope)
+}
+
+// Principal is the caller identified by an access token, exactly one of
+// User and Service is set as Type tells, service accounts are set as User.
+// PersonalTokenID is set when a user is identified by a personal access
+// token, ClientID when the token of the user was issued to an OAuth client.
+// SessionID is the login session the access token of a user belongs to.
+type Principal struct {
+	Type            PrincipalType
+	User            *User
+	Service         *ServicePrincipal
+	PersonalTokenID string
+	ClientID        string
+	SessionID       string
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// What a rate limit rule counts requests by.
+const (
+	// RateLimitByIP gives every client address its own limit.
+	RateLimitByIP = "ip"
+	// RateLimitByUser gives every authenticated user its own limit.
+	RateLimitByUser = "user"
+	// RateLimitByRoute shares one limit among all clients of the route.
+	RateLimitByRoute = "route"
+)
+
+// RateLimitRule allows Limit requests per Period. REST requests match by
+// Method, empty for any, and Path, a path ending in /* matches the paths
+// below it. gRPC calls match by GRPCMethod, the full method name or a
+// service followed by /*. The Name keeps the counters of rules apart.
+type RateLimitRule struct {
+	Name       string
+	Method     string
+	Path       string
+	GRPCMethod string
+	By         string
+	Limit      int
+	Period     time.Duration
+}
+
+// RateLimitRequest is a request as the rate limiter sees it, Path for REST
+// requests and GRPCMethod for gRPC calls. UserID is empty for anonymous
+// requests.
+type RateLimitRequest struct {
+	Method     string
+	Path       string
+	GRPCMethod string
+	IP         string
+	UserID     string
+}
+
+// RateLimitedError is returned for requests over the limit of a rule, the
+// client may try again after RetryAfter.
+type RateLimitedError struct {
+	Rule       string
+	Limit      int
+	Period     time.Duration
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit of %d requests per %v exceeded, retry in %v", e.Limit, e.Period, e.RetryAfter.Round(time.Second))
+}
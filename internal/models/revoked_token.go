@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RevokedToken marks a token id as unusable until the token would have expired anyway.
+type RevokedToken struct {
+	ID        string    `bson:"_id"`
+	RevokedAt time.Time `bson:"revoked_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
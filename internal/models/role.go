@@ -0,0 +1,90 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	PermissionUsersCreate = "users:create"
+	PermissionUsersRead   = "users:read"
+	PermissionUsersWrite  = "users:write"
+	PermissionUsersDelete = "users:delete"
+	PermissionRolesRead   = "roles:read"
+	PermissionRolesWrite  = "roles:write"
+	// PermissionOrgsWrite manages every organization, members:* only the
+	// organization the token was issued for.
+	PermissionOrgsWrite    = "orgs:write"
+	PermissionMembersRead  = "members:read"
+	PermissionMembersWrite = "members:write"
+	// PermissionServiceAccountsRead and PermissionServiceAccountsWrite manage
+	// every service account.
+	PermissionServiceAccountsRead  = "service_accounts:read"
+	PermissionServiceAccountsWrite = "service_accounts:write"
+)
+
+// RoleAdmin is the role created by the migrations, it is given to the
+// accounts named in the bootstrap config at startup.
+const RoleAdmin = "admin"
+
+// Permissions lists every permission a role can grant.
+var Permissions = []string{
+	PermissionUsersCreate,
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersDelete,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionOrgsWrite,
+	PermissionMembersRead,
+	PermissionMembersWrite,
+	PermissionServiceAccountsRead,
+	PermissionServiceAccountsWrite,
+}
+
+// TenantPermissions lists the permissions that act within the organization
+// a token was issued for, users:create creates accounts of it. Roles held as
+// a member grant only these, every other permission comes from the global
+// roles of the user.
+var TenantPermissions = []string{
+	PermissionUsersCreate,
+	PermissionMembersRead,
+	PermissionMembersWrite,
+}
+
+// IsTenantPermission tells whether the permission acts within an organization.
+func IsTenantPermission(permission string) bool {
+	for _, p := range TenantPermissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Role is a named set of permissions assigned to users.
+type Role struct {
+	Name        string    `bson:"_id" json:"name"`
+	Description string    `bson:"description" json:"description"`
+	Permissions []string  `bson:"permissions" json:"permissions"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// RolePermissions merges the permissions granted by the roles.
+func RolePermissions(roles []*Role) []string {
+	set := make(map[string]struct{})
+	for _, role := range roles {
+		for _, permission := range role.Permissions {
+			set[permission] = struct{}{}
+		}
+	}
+
+	permissions := make([]string, 0, len(set))
+	for permission := range set {
+		permissions = append(permissions, permission)
+	}
+	sort.Strings(permissions)
+
+	return permissions
+}
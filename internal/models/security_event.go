@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	SecurityEventRedirectRejected  = "redirect_rejected"
+	// SecurityEventMFAAttemptsExceeded is a login with the right password
+	// but too many wrong second factor codes.
+	SecurityEventMFAAttemptsExceeded = "mfa_attempts_exceeded"
+	// SecurityEventWebAuthnCloneWarning is an assertion whose signature
+	// counter went back, the authenticator may have been cloned.
+	SecurityEventWebAuthnCloneWarning = "webauthn_clone_warning"
+	// SecurityEventLoginLocked is an account or a client address locked out
+	// after too many failed logins.
+	SecurityEventLoginLocked = "login_locked"
+	// SecurityEventPasswordReset is a password set with a reset link.
+	SecurityEventPasswordReset = "password_reset"
+)
+
+// SecurityEvent is an auditable incident such as a replayed refresh token.
+type SecurityEvent struct {
+	Type     string
+	UserID   string
+	Time     time.Time
+	Metadata map[string]string
+}
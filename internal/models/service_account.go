@@ -0,0 +1,73 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// ServiceAccountKeyPrefix starts every service account API key, so they are
+// told apart from JWTs and personal access tokens.
+const ServiceAccountKeyPrefix = "sak_"
+
+const (
+	OwnerUser         = "user"
+	OwnerOrganization = "organization"
+)
+
+// ServiceAccount is a principal for automation that has no password. It is
+// owned by a user or by an organization, tokens of an organization's account
+// are issued for that organization. The account holds its own roles and
+// signs in with an API key or with a JWT assertion signed by one of its keys.
+type ServiceAccount struct {
+	ID          primitive.ObjectID     `bson:"_id" json:"id"`
+	Name        string                 `bson:"name" json:"name"`
+	Description string                 `bson:"description,omitempty" json:"description,omitempty"`
+	OwnerType   string                 `bson:"owner_type" json:"ownerType"`
+	OwnerID     string                 `bson:"owner_id" json:"ownerId"`
+	Roles       []string               `bson:"roles" json:"roles"`
+	Disabled    bool                   `bson:"disabled" json:"disabled"`
+	DisabledAt  time.Time              `bson:"disabled_at,omitempty" json:"disabledAt,omitempty"`
+	Keys        []ServiceAccountKey    `bson:"keys,omitempty" json:"keys"`
+	APIKeys     []ServiceAccountAPIKey `bson:"api_keys,omitempty" json:"apiKeys"`
+	CreatedAt   time.Time              `bson:"created_at" json:"createdAt"`
+}
+
+// Tenant is the organization tokens of the account are issued for, empty
+// for accounts owned by a user.
+func (a *ServiceAccount) Tenant() string {
+	if a.OwnerType == OwnerOrganization {
+		return a.OwnerID
+	}
+
+	return ""
+}
+
+func (a *ServiceAccount) Key(id string) (*ServiceAccountKey, bool) {
+	for i := range a.Keys {
+		if a.Keys[i].ID == id {
+			return &a.Keys[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// ServiceAccountKey is a public key the account signs RFC 7523 assertions
+// with, ID is the kid the assertion header names it by.
+type ServiceAccountKey struct {
+	ID        string    `bson:"id" json:"id"`
+	Algorithm string    `bson:"algorithm" json:"algorithm"`
+	PublicKey string    `bson:"public_key" json:"publicKey"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// ServiceAccountAPIKey is a bearer secret of the account. The key is shown
+// once and never stored, Hash is its SHA-256 hash and Prefix its first
+// characters.
+type ServiceAccountAPIKey struct {
+	ID        string    `bson:"id" json:"id"`
+	Name      string    `bson:"name" json:"name"`
+	Prefix    string    `bson:"prefix" json:"prefix"`
+	Hash      string    `bson:"hash" json:"-"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Session is one login of a user. It lives as long as its refresh token
+// family and ends when it is revoked or the family expires.
+type Session struct {
+	ID        string `bson:"_id" json:"id"`
+	UserID    string `bson:"user_id" json:"userId"`
+	FamilyID  string `bson:"family_id" json:"-"`
+	IP        string `bson:"ip" json:"ip"`
+	UserAgent string `bson:"user_agent" json:"userAgent"`
+	// ClientID is the OAuth client the session was granted to, empty for direct logins.
+	ClientID   string     `bson:"client_id,omitempty" json:"clientId,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"createdAt"`
+	LastSeenAt time.Time  `bson:"last_seen_at" json:"lastSeenAt"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"expiresAt"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty" json:"-"`
+	// Current marks the session of the request listing the sessions.
+	Current bool `bson:"-" json:"current"`
+}
+
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
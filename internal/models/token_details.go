@@ -3,8 +3,16 @@ package models
 import "time"
 
 type TokenDetails struct {
-	AccessToken  string    `json:"accessToken"`
-	RefreshToken string    `json:"refreshToken"`
-	AtExpires    time.Time `json:"-"`
-	RtExpires    time.Time `json:"-"`
+	AccessToken    string    `json:"accessToken"`
+	RefreshToken   string    `json:"refreshToken"`
+	AtExpires      time.Time `json:"-"`
+	RtExpires      time.Time `json:"-"`
+	RefreshTokenID string    `json:"-"`
+	FamilyID       string    `json:"-"`
+	SessionID      string    `json:"-"`
+	// ClientID and Scope are set on tokens issued to OAuth clients.
+	ClientID string `json:"-"`
+	Scope    string `json:"-"`
+	// IDToken is set when an OAuth client was granted the openid scope.
+	IDToken string `json:"-"`
 }
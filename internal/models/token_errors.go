@@ -0,0 +1,13 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	TokenExpiredErr       = errors.New("token is expired")
+	InvalidTokenErr       = errors.New("token is invalid")
+	TokenRevokedErr       = fmt.Errorf("%w: token is revoked", InvalidTokenErr)
+	RefreshTokenReusedErr = fmt.Errorf("%w: refresh token was already used", InvalidTokenErr)
+)
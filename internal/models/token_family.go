@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TokenFamily links every refresh token issued from one login. Only the
+// latest token of the family can be used, presenting an older one revokes it.
+type TokenFamily struct {
+	ID             string     `bson:"_id" json:"id"`
+	UserID         string     `bson:"user_id" json:"userId"`
+	CurrentTokenID string     `bson:"current_token_id" json:"-"`
+	CreatedAt      time.Time  `bson:"created_at" json:"createdAt"`
+	RotatedAt      time.Time  `bson:"rotated_at" json:"rotatedAt"`
+	ExpiresAt      time.Time  `bson:"expires_at" json:"expiresAt"`
+	RevokedAt      *time.Time `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
+
+func (f *TokenFamily) Revoked() bool {
+	return f.RevokedAt != nil
+}
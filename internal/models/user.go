@@ -5,9 +5,33 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 type User struct {
 	ID           primitive.ObjectID `bson:"_id" json:"id" yaml:"id"`
 	Username     string             `bson:"username" json:"username" yaml:"username"`
-	Password     string             `bson:"password" json:"password" yaml:"password"`
+	Password     string             `bson:"password" json:"-" yaml:"password"`
 	Email        string             `bson:"email" json:"email" yaml:"email"`
 	FirstName    string             `bson:"first_name" json:"first_name" yaml:"first_name"`
 	LastName     string             `bson:"last_name" json:"last_name" yaml:"last_name"`
 	CreationDate uint64             `bson:"creation_date" json:"creationDate"`
+	Roles        []string           `bson:"roles,omitempty" json:"roles" yaml:"roles"`
+	// Tenant is the organization the account belongs to, empty for global
+	// accounts. On users read from a token it is the organization the token
+	// was issued for.
+	Tenant string `bson:"tenant,omitempty" json:"tenant,omitempty" yaml:"tenant"`
+	// Permissions are resolved from the roles when a token is issued.
+	Permissions []string `bson:"-" json:"permissions,omitempty" yaml:"-"`
+	// Type is set on users read from a token, PrincipalServiceAccount when
+	// the caller is a service account and not a human.
+	Type PrincipalType `bson:"-" json:"type,omitempty" yaml:"-"`
+}
+
+func (u *User) IsServiceAccount() bool {
+	return u.Type == PrincipalServiceAccount
+}
+
+func (u *User) HasPermission(permission string) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+const (
+	UserSortUsername     = "username"
+	UserSortEmail        = "email"
+	UserSortCreationDate = "creation_date"
+
+	DefaultUserPageLimit = 20
+	MaxUserPageLimit     = 100
+)
+
+// UserQuery selects one page of users. Zero values disable a filter, Cursor
+// is the NextCursor of the previous page and must be used with the same
+// filters and sorting.
+type UserQuery struct {
+	UsernamePrefix string
+	EmailPrefix    string
+	CreatedFrom    time.Time
+	CreatedTo      time.Time
+	SortBy         string
+	Desc           bool
+	Limit          int
+	Cursor         string
+}
+
+// UserPage is one page of users, NextCursor is empty on the last page.
+type UserPage struct {
+	Users      []*User `json:"users"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
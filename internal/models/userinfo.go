@@ -0,0 +1,25 @@
+package models
+
+// UserInfo holds the OpenID Connect standard claims of a user. Only the
+// claims of the granted scopes are set.
+type UserInfo struct {
+	Subject           string `json:"sub"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	GivenName         string `json:"given_name,omitempty"`
+	FamilyName        string `json:"family_name,omitempty"`
+	Email             string `json:"email,omitempty"`
+}
+
+func NewUserInfo(user *User, scope string) *UserInfo {
+	info := &UserInfo{Subject: user.ID.Hex()}
+	if ScopeContains(scope, ScopeProfile) {
+		info.PreferredUsername = user.Username
+		info.GivenName = user.FirstName
+		info.FamilyName = user.LastName
+	}
+	if ScopeContains(scope, ScopeEmail) {
+		info.Email = user.Email
+	}
+
+	return info
+}
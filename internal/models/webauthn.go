@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	// MFAMethodWebAuthn is an assertion of a registered security key or passkey.
+	MFAMethodWebAuthn = "webauthn"
+)
+
+type WebAuthnCeremony string
+
+const (
+	WebAuthnRegistration WebAuthnCeremony = "registration"
+	WebAuthnLogin        WebAuthnCeremony = "login"
+)
+
+// WebAuthnCredential is a public key credential registered by a user, ID is
+// the base64url encoded credential id.
+type WebAuthnCredential struct {
+	ID              string `bson:"_id" json:"id"`
+	UserID          string `bson:"user_id" json:"userId"`
+	Name            string `bson:"name,omitempty" json:"name,omitempty"`
+	PublicKey       []byte `bson:"public_key" json:"-"`
+	AttestationType string `bson:"attestation_type" json:"attestationType"`
+	AAGUID          []byte `bson:"aaguid,omitempty" json:"-"`
+	// SignCount is the signature counter of the last assertion, an
+	// authenticator reporting a lower one may have been cloned.
+	SignCount  uint32    `bson:"sign_count" json:"-"`
+	CreatedAt  time.Time `bson:"created_at" json:"createdAt"`
+	LastUsedAt time.Time `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+}
+
+// WebAuthnSession is a ceremony waiting for the response of the
+// authenticator. The challenge is never stored, ID is its SHA-256 hash.
+// UserID is empty for passwordless logins, where the credential names the user.
+type WebAuthnSession struct {
+	ID                   string           `bson:"_id"`
+	Ceremony             WebAuthnCeremony `bson:"ceremony"`
+	UserID               string           `bson:"user_id,omitempty"`
+	Tenant               string           `bson:"tenant,omitempty"`
+	AllowedCredentialIDs [][]byte         `bson:"allowed_credential_ids,omitempty"`
+	UserVerification     string           `bson:"user_verification"`
+	ExpiresAt            time.Time        `bson:"expires_at"`
+}
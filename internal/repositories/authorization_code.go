@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	AUTHORIZATION_CODE_COLLECTION = "authorization_codes"
+)
+
+var NotFoundAuthorizationCodeErr = errors.New("authorization code not found")
+
+// AuthorizationCodeRepo stores pending code grants, expired codes are removed by a TTL index.
+type AuthorizationCodeRepo struct {
+	db *mongo.Database
+}
+
+func NewAuthorizationCodeRepo(db *mongo.Database) *AuthorizationCodeRepo {
+	return &AuthorizationCodeRepo{
+		db: db,
+	}
+}
+
+func (r *AuthorizationCodeRepo) Create(ctx context.Context, code *models.AuthorizationCode) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(AUTHORIZATION_CODE_COLLECTION).InsertOne(ctx, code)
+
+	return err
+}
+
+// Consume deletes the code and returns it, so it can be redeemed only once.
+func (r *AuthorizationCodeRepo) Consume(ctx context.Context, id string) (*models.AuthorizationCode, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var code models.AuthorizationCode
+	err := r.db.Collection(AUTHORIZATION_CODE_COLLECTION).FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundAuthorizationCodeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &code, nil
+}
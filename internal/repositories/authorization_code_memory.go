@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sync"
+)
+
+// MemoryAuthorizationCodeRepo keeps pending code grants in process, for tests and single instance setups.
+type MemoryAuthorizationCodeRepo struct {
+	mu    sync.Mutex
+	codes map[string]models.AuthorizationCode
+}
+
+func NewMemoryAuthorizationCodeRepo() *MemoryAuthorizationCodeRepo {
+	return &MemoryAuthorizationCodeRepo{
+		codes: make(map[string]models.AuthorizationCode),
+	}
+}
+
+func (r *MemoryAuthorizationCodeRepo) Create(ctx context.Context, code *models.AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.codes[code.ID] = *code
+
+	return nil
+}
+
+func (r *MemoryAuthorizationCodeRepo) Consume(ctx context.Context, id string) (*models.AuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[id]
+	if !ok {
+		return nil, NotFoundAuthorizationCodeErr
+	}
+	delete(r.codes, id)
+
+	return &code, nil
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	CLIENT_COLLECTION = "clients"
+)
+
+var NotFoundClientErr = errors.New("client not found")
+
+type ClientRepo struct {
+	db *mongo.Database
+}
+
+func NewClientRepo(db *mongo.Database) *ClientRepo {
+	return &ClientRepo{
+		db: db,
+	}
+}
+
+func (r *ClientRepo) Get(ctx context.Context, id string) (*models.Client, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var client models.Client
+	err := r.db.Collection(CLIENT_COLLECTION).FindOne(ctx, bson.M{"_id": id}).Decode(&client)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundClientErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
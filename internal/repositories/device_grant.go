@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	DEVICE_GRANT_COLLECTION = "device_grants"
+)
+
+var (
+	NotFoundDeviceGrantErr  = errors.New("device grant not found")
+	DuplicateDeviceGrantErr = errors.New("device grant with this user code already exists")
+)
+
+// DeviceGrantRepo stores pending device authorizations, expired grants are removed by a TTL index.
+type DeviceGrantRepo struct {
+	db *mongo.Database
+}
+
+func NewDeviceGrantRepo(db *mongo.Database) *DeviceGrantRepo {
+	return &DeviceGrantRepo{
+		db: db,
+	}
+}
+
+func (r *DeviceGrantRepo) Create(ctx context.Context, grant *models.DeviceGrant) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(DEVICE_GRANT_COLLECTION).InsertOne(ctx, grant)
+	if mongo.IsDuplicateKeyError(err) {
+		return DuplicateDeviceGrantErr
+	}
+
+	return err
+}
+
+func (r *DeviceGrantRepo) GetByUserCode(ctx context.Context, userCode string) (*models.DeviceGrant, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var grant models.DeviceGrant
+	err := r.db.Collection(DEVICE_GRANT_COLLECTION).FindOne(ctx, bson.M{"user_code": userCode}).Decode(&grant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundDeviceGrantErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &grant, nil
+}
+
+// Decide records the decision of the user on a pending grant, grants that
+// have already been decided are not found.
+func (r *DeviceGrantRepo) Decide(ctx context.Context, id string, status models.DeviceGrantStatus, userID, tenant string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	set := bson.M{"status": status, "user_id": userID}
+	if tenant != "" {
+		set["tenant"] = tenant
+	}
+
+	res, err := r.db.Collection(DEVICE_GRANT_COLLECTION).UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.DeviceGrantPending},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return NotFoundDeviceGrantErr
+	}
+
+	return nil
+}
+
+// Poll records the poll time and returns the grant as it was before, so the
+// caller sees when the device polled last.
+func (r *DeviceGrantRepo) Poll(ctx context.Context, id string, at time.Time) (*models.DeviceGrant, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var grant models.DeviceGrant
+	err := r.db.Collection(DEVICE_GRANT_COLLECTION).FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_polled_at": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&grant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundDeviceGrantErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &grant, nil
+}
+
+// SlowDown adds seconds to the polling interval of the grant.
+func (r *DeviceGrantRepo) SlowDown(ctx context.Context, id string, seconds int64) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(DEVICE_GRANT_COLLECTION).UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"interval": seconds}})
+
+	return err
+}
+
+// Consume deletes the grant and returns it, so it can be redeemed only once.
+func (r *DeviceGrantRepo) Consume(ctx context.Context, id string) (*models.DeviceGrant, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var grant models.DeviceGrant
+	err := r.db.Collection(DEVICE_GRANT_COLLECTION).FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&grant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundDeviceGrantErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &grant, nil
+}
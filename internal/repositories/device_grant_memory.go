@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryDeviceGrantRepo keeps pending device authorizations in process, for tests and single instance setups.
+type MemoryDeviceGrantRepo struct {
+	mu     sync.Mutex
+	grants map[string]models.DeviceGrant
+}
+
+func NewMemoryDeviceGrantRepo() *MemoryDeviceGrantRepo {
+	return &MemoryDeviceGrantRepo{
+		grants: make(map[string]models.DeviceGrant),
+	}
+}
+
+func (r *MemoryDeviceGrantRepo) Create(ctx context.Context, grant *models.DeviceGrant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, g := range r.grants {
+		if g.UserCode == grant.UserCode {
+			return DuplicateDeviceGrantErr
+		}
+	}
+	r.grants[grant.ID] = *grant
+
+	return nil
+}
+
+func (r *MemoryDeviceGrantRepo) GetByUserCode(ctx context.Context, userCode string) (*models.DeviceGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, grant := range r.grants {
+		if grant.UserCode == userCode {
+			return &grant, nil
+		}
+	}
+
+	return nil, NotFoundDeviceGrantErr
+}
+
+func (r *MemoryDeviceGrantRepo) Decide(ctx context.Context, id string, status models.DeviceGrantStatus, userID, tenant string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	grant, ok := r.grants[id]
+	if !ok || grant.Status != models.DeviceGrantPending {
+		return NotFoundDeviceGrantErr
+	}
+	grant.Status, grant.UserID, grant.Tenant = status, userID, tenant
+	r.grants[id] = grant
+
+	return nil
+}
+
+func (r *MemoryDeviceGrantRepo) Poll(ctx context.Context, id string, at time.Time) (*models.DeviceGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	grant, ok := r.grants[id]
+	if !ok {
+		return nil, NotFoundDeviceGrantErr
+	}
+	polled := grant
+	polled.LastPolledAt = at
+	r.grants[id] = polled
+
+	return &grant, nil
+}
+
+func (r *MemoryDeviceGrantRepo) SlowDown(ctx context.Context, id string, seconds int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	grant, ok := r.grants[id]
+	if !ok {
+		return NotFoundDeviceGrantErr
+	}
+	grant.Interval += seconds
+	r.grants[id] = grant
+
+	return nil
+}
+
+func (r *MemoryDeviceGrantRepo) Consume(ctx context.Context, id string) (*models.DeviceGrant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	grant, ok := r.grants[id]
+	if !ok {
+		return nil, NotFoundDeviceGrantErr
+	}
+	delete(r.grants, id)
+
+	return &grant, nil
+}
@@ -8,6 +8,8 @@ import (
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type FileRepo struct {
@@ -58,9 +60,9 @@ func (fr *FileRepo) GetAll(ctx context.Context) ([]*models.User, error) {
 	return fr.users, nil
 }
 
-func (fr *FileRepo) GetByName(ctx context.Context, uname string) (*models.User, error) {
+func (fr *FileRepo) GetByName(ctx context.Context, tenant, uname string) (*models.User, error) {
 	for _, user := range fr.users {
-		if user.Username == uname {
+		if user.Tenant == tenant && user.Username == uname {
 			return user, nil
 		}
 	}
@@ -68,6 +70,62 @@ func (fr *FileRepo) GetByName(ctx context.Context, uname string) (*models.User,
 	return nil, NotFoundUserErr
 }
 
+func (fr *FileRepo) Find(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	query, err := normalizeUserQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var after *userCursor
+	if query.Cursor != "" {
+		after, err = decodeUserCursor(query.Cursor, query.SortBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// sign turns the ascending comparison into the requested order
+	sign := 1
+	if query.Desc {
+		sign = -1
+	}
+
+	users := make([]*models.User, 0, len(fr.users))
+	for _, user := range fr.users {
+		if !strings.HasPrefix(user.Username, query.UsernamePrefix) ||
+			!strings.HasPrefix(user.Email, query.EmailPrefix) {
+			continue
+		}
+		if !query.CreatedFrom.IsZero() && int64(user.CreationDate) < query.CreatedFrom.Unix() {
+			continue
+		}
+		if !query.CreatedTo.IsZero() && int64(user.CreationDate) > query.CreatedTo.Unix() {
+			continue
+		}
+		if after != nil && sign*after.compare(user) <= 0 {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return sign*newUserCursor(users[j], query.SortBy).compare(users[i]) < 0
+	})
+	if len(users) > query.Limit+1 {
+		users = users[:query.Limit+1]
+	}
+
+	return newUserPage(users, query), nil
+}
+
+func (fr *FileRepo) UpdateRoles(ctx context.Context, user *models.User) error {
+	panic("Not emplement")
+}
+
+func (fr *FileRepo) Delete(ctx context.Context, id string) error {
+	panic("Not emplement")
+}
+
 func (fr *FileRepo) Insert(ctx context.Context, user *models.User) error {
 	panic("Not emplement")
 }
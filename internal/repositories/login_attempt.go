@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	LOGIN_ATTEMPT_COLLECTION = "login_attempts"
+)
+
+var NotFoundLoginAttemptsErr = errors.New("login attempts not found")
+
+// LoginAttemptRepo stores the failed login counters of accounts and client
+// addresses, shared by all instances. Expired counters are removed by a TTL
+// index and ignored until then.
+type LoginAttemptRepo struct {
+	db *mongo.Database
+}
+
+func NewLoginAttemptRepo(db *mongo.Database) *LoginAttemptRepo {
+	return &LoginAttemptRepo{
+		db: db,
+	}
+}
+
+func (r *LoginAttemptRepo) Get(ctx context.Context, ids []string, now time.Time) ([]*models.LoginAttempts, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{
+		"_id":        bson.M{"$in": ids},
+		"expires_at": bson.M{"$gt": now},
+	}
+	cursor, err := r.db.Collection(LOGIN_ATTEMPT_COLLECTION).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make([]*models.LoginAttempts, 0, len(ids))
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// Fail counts a failed login and returns the counter with it counted. An
+// expired counter the TTL index has not removed yet starts over.
+func (r *LoginAttemptRepo) Fail(ctx context.Context, kind, value string, at, expiresAt time.Time) (*models.LoginAttempts, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	id := models.LoginAttemptsID(kind, value)
+	collection := r.db.Collection(LOGIN_ATTEMPT_COLLECTION)
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "expires_at": bson.M{"$lte": at}},
+		bson.M{
+			"$set":   bson.M{"failures": 0},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{
+			"last_failure_at": at,
+			"expires_at":      expiresAt,
+		},
+		"$setOnInsert": bson.M{
+			"kind":  kind,
+			"value": value,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var attempts models.LoginAttempts
+	err = collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&attempts)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent failure inserted the counter first
+		err = collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&attempts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempts, nil
+}
+
+// Lock locks the counter out until the time, it expires when the lockout ends.
+func (r *LoginAttemptRepo) Lock(ctx context.Context, id string, until time.Time) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	update := bson.M{
+		"$set": bson.M{
+			"locked_until": until,
+			"expires_at":   until,
+		},
+	}
+	res, err := r.db.Collection(LOGIN_ATTEMPT_COLLECTION).UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return NotFoundLoginAttemptsErr
+	}
+
+	return nil
+}
+
+// GetLocked returns the counters locked out at now, the latest lockout first.
+func (r *LoginAttemptRepo) GetLocked(ctx context.Context, now time.Time) ([]*models.LoginAttempts, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{
+		"locked_until": bson.M{"$gt": now},
+	}
+	cursor, err := r.db.Collection(LOGIN_ATTEMPT_COLLECTION).Find(ctx, filter, options.Find().SetSort(bson.M{"locked_until": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := make([]*models.LoginAttempts, 0)
+	if err := cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+func (r *LoginAttemptRepo) Delete(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(LOGIN_ATTEMPT_COLLECTION).DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundLoginAttemptsErr
+	}
+
+	return nil
+}
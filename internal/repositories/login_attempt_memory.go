@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryLoginAttemptRepo keeps failed login counters in process, for tests and single instance setups.
+type MemoryLoginAttemptRepo struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+}
+
+func NewMemoryLoginAttemptRepo() *MemoryLoginAttemptRepo {
+	return &MemoryLoginAttemptRepo{
+		attempts: make(map[string]models.LoginAttempts),
+	}
+}
+
+func (r *MemoryLoginAttemptRepo) Get(ctx context.Context, ids []string, now time.Time) ([]*models.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*models.LoginAttempts, 0, len(ids))
+	for _, id := range ids {
+		attempts, ok := r.attempts[id]
+		if ok && now.Before(attempts.ExpiresAt) {
+			result = append(result, &attempts)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *MemoryLoginAttemptRepo) Fail(ctx context.Context, kind, value string, at, expiresAt time.Time) (*models.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := models.LoginAttemptsID(kind, value)
+	attempts, ok := r.attempts[id]
+	if !ok || !at.Before(attempts.ExpiresAt) {
+		attempts = models.LoginAttempts{ID: id, Kind: kind, Value: value}
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	attempts.ExpiresAt = expiresAt
+	r.attempts[id] = attempts
+
+	return &attempts, nil
+}
+
+func (r *MemoryLoginAttemptRepo) Lock(ctx context.Context, id string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[id]
+	if !ok {
+		return NotFoundLoginAttemptsErr
+	}
+	attempts.LockedUntil = until
+	attempts.ExpiresAt = until
+	r.attempts[id] = attempts
+
+	return nil
+}
+
+func (r *MemoryLoginAttemptRepo) GetLocked(ctx context.Context, now time.Time) ([]*models.LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]*models.LoginAttempts, 0)
+	for _, attempts := range r.attempts {
+		attempts := attempts
+		if attempts.Locked(now) {
+			result = append(result, &attempts)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LockedUntil.After(result[j].LockedUntil)
+	})
+
+	return result, nil
+}
+
+func (r *MemoryLoginAttemptRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.attempts[id]; !ok {
+		return NotFoundLoginAttemptsErr
+	}
+	delete(r.attempts, id)
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	MFA_CHALLENGE_COLLECTION = "mfa_challenges"
+)
+
+var NotFoundMFAChallengeErr = errors.New("mfa challenge not found")
+
+// MFAChallengeRepo stores logins waiting for the second factor, expired challenges are removed by a TTL index.
+type MFAChallengeRepo struct {
+	db *mongo.Database
+}
+
+func NewMFAChallengeRepo(db *mongo.Database) *MFAChallengeRepo {
+	return &MFAChallengeRepo{
+		db: db,
+	}
+}
+
+func (r *MFAChallengeRepo) Create(ctx context.Context, challenge *models.MFAChallenge) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(MFA_CHALLENGE_COLLECTION).InsertOne(ctx, challenge)
+
+	return err
+}
+
+func (r *MFAChallengeRepo) Get(ctx context.Context, id string) (*models.MFAChallenge, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var challenge models.MFAChallenge
+	err := r.db.Collection(MFA_CHALLENGE_COLLECTION).FindOne(ctx, bson.M{"_id": id}).Decode(&challenge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundMFAChallengeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// Attempt counts an attempt to answer the challenge and returns the
+// challenge with the attempt counted.
+func (r *MFAChallengeRepo) Attempt(ctx context.Context, id string) (*models.MFAChallenge, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var challenge models.MFAChallenge
+	err := r.db.Collection(MFA_CHALLENGE_COLLECTION).FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&challenge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundMFAChallengeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// Consume deletes the challenge and returns it, so it can be answered only once.
+func (r *MFAChallengeRepo) Consume(ctx context.Context, id string) (*models.MFAChallenge, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var challenge models.MFAChallenge
+	err := r.db.Collection(MFA_CHALLENGE_COLLECTION).FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&challenge)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundMFAChallengeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
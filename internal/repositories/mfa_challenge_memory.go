@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sync"
+)
+
+// MemoryMFAChallengeRepo keeps logins waiting for the second factor in process, for tests and single instance setups.
+type MemoryMFAChallengeRepo struct {
+	mu         sync.Mutex
+	challenges map[string]models.MFAChallenge
+}
+
+func NewMemoryMFAChallengeRepo() *MemoryMFAChallengeRepo {
+	return &MemoryMFAChallengeRepo{
+		challenges: make(map[string]models.MFAChallenge),
+	}
+}
+
+func (r *MemoryMFAChallengeRepo) Create(ctx context.Context, challenge *models.MFAChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.challenges[challenge.ID] = *challenge
+
+	return nil
+}
+
+func (r *MemoryMFAChallengeRepo) Get(ctx context.Context, id string) (*models.MFAChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, NotFoundMFAChallengeErr
+	}
+
+	return &challenge, nil
+}
+
+func (r *MemoryMFAChallengeRepo) Attempt(ctx context.Context, id string) (*models.MFAChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, NotFoundMFAChallengeErr
+	}
+	challenge.Attempts++
+	r.challenges[id] = challenge
+
+	return &challenge, nil
+}
+
+func (r *MemoryMFAChallengeRepo) Consume(ctx context.Context, id string) (*models.MFAChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, NotFoundMFAChallengeErr
+	}
+	delete(r.challenges, id)
+
+	return &challenge, nil
+}
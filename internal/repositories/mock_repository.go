@@ -27,11 +27,11 @@ func (r *MockUserRepository) Get(ctx context.Context, id string) (*models.User,
 		return nil, args.Error(1)
 	}
 
-	return args.Get(0).(*models.User), args.Get(1).(error)
+	return args.Get(0).(*models.User), nil
 }
 
-func (r *MockUserRepository) GetByName(ctx context.Context, uname string) (*models.User, error) {
-	args := r.Called(uname)
+func (r *MockUserRepository) GetByName(ctx context.Context, tenant, uname string) (*models.User, error) {
+	args := r.Called(tenant, uname)
 
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -79,3 +79,47 @@ func (r *MockUserRepository) UpdatePassword(ctx context.Context, user *models.Us
 
 	return nil
 }
+
+func (r *MockUserRepository) Find(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	args := r.Called(query)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.UserPage), nil
+}
+
+func (r *MockUserRepository) UpdateRoles(ctx context.Context, user *models.User) error {
+	args := r.Called(user)
+
+	if args.Get(0) != nil {
+		return args.Get(0).(error)
+	}
+
+	return nil
+}
+
+func (r *MockUserRepository) Delete(ctx context.Context, id string) error {
+	args := r.Called(id)
+
+	if args.Get(0) != nil {
+		return args.Get(0).(error)
+	}
+
+	return nil
+}
+
+type MockClientRepository struct {
+	mock.Mock
+}
+
+func (r *MockClientRepository) Get(ctx context.Context, id string) (*models.Client, error) {
+	args := r.Called(id)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.Client), nil
+}
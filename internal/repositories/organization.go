@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ORGANIZATION_COLLECTION = "organizations"
+	MEMBERSHIP_COLLECTION   = "memberships"
+)
+
+var (
+	NotFoundOrganizationErr  = errors.New("organization not found")
+	DuplicateOrganizationErr = errors.New("organization already exists")
+	NotFoundMembershipErr    = errors.New("membership not found")
+)
+
+type OrganizationRepo struct {
+	db *mongo.Database
+}
+
+func NewOrganizationRepo(db *mongo.Database) *OrganizationRepo {
+	return &OrganizationRepo{
+		db: db,
+	}
+}
+
+func (r *OrganizationRepo) Get(ctx context.Context, id string) (*models.Organization, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var org models.Organization
+	err := r.db.Collection(ORGANIZATION_COLLECTION).FindOne(ctx, bson.M{"_id": id}).Decode(&org)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundOrganizationErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &org, nil
+}
+
+func (r *OrganizationRepo) Insert(ctx context.Context, org *models.Organization) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(ORGANIZATION_COLLECTION).InsertOne(ctx, org)
+	if mongo.IsDuplicateKeyError(err) {
+		return DuplicateOrganizationErr
+	}
+
+	return err
+}
+
+type MembershipRepo struct {
+	db *mongo.Database
+}
+
+func NewMembershipRepo(db *mongo.Database) *MembershipRepo {
+	return &MembershipRepo{
+		db: db,
+	}
+}
+
+func (r *MembershipRepo) Get(ctx context.Context, orgID, userID string) (*models.Membership, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter, err := membershipFilter(orgID, userID)
+	if err != nil {
+		return nil, NotFoundMembershipErr
+	}
+
+	var membership models.Membership
+	err = r.db.Collection(MEMBERSHIP_COLLECTION).FindOne(ctx, filter).Decode(&membership)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundMembershipErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &membership, nil
+}
+
+// GetByOrganization returns the members of the organization, oldest first.
+func (r *MembershipRepo) GetByOrganization(ctx context.Context, orgID string) ([]*models.Membership, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "user_id", Value: 1}})
+	cursor, err := r.db.Collection(MEMBERSHIP_COLLECTION).Find(ctx, bson.M{"org_id": orgID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := make([]*models.Membership, 0)
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+// Save creates the membership or replaces the roles of an existing one.
+func (r *MembershipRepo) Save(ctx context.Context, membership *models.Membership) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{"org_id": membership.OrganizationID, "user_id": membership.UserID}
+	update := bson.M{
+		"$set":         bson.M{"roles": membership.Roles},
+		"$setOnInsert": bson.M{"created_at": membership.CreatedAt},
+	}
+	_, err := r.db.Collection(MEMBERSHIP_COLLECTION).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+
+	return err
+}
+
+func (r *MembershipRepo) Delete(ctx context.Context, orgID, userID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter, err := membershipFilter(orgID, userID)
+	if err != nil {
+		return NotFoundMembershipErr
+	}
+
+	res, err := r.db.Collection(MEMBERSHIP_COLLECTION).DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundMembershipErr
+	}
+
+	return nil
+}
+
+func membershipFilter(orgID, userID string) (bson.M, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bson.M{"org_id": orgID, "user_id": id}, nil
+}
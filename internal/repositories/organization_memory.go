@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+)
+
+// MemoryOrganizationRepo keeps organizations in process, for tests and single instance setups.
+type MemoryOrganizationRepo struct {
+	mu   sync.Mutex
+	orgs map[string]models.Organization
+}
+
+func NewMemoryOrganizationRepo(orgs ...*models.Organization) *MemoryOrganizationRepo {
+	r := &MemoryOrganizationRepo{
+		orgs: make(map[string]models.Organization, len(orgs)),
+	}
+	for _, org := range orgs {
+		r.orgs[org.ID] = *org
+	}
+
+	return r
+}
+
+func (r *MemoryOrganizationRepo) Get(ctx context.Context, id string) (*models.Organization, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, ok := r.orgs[id]
+	if !ok {
+		return nil, NotFoundOrganizationErr
+	}
+
+	return &org, nil
+}
+
+func (r *MemoryOrganizationRepo) Insert(ctx context.Context, org *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.orgs[org.ID]; ok {
+		return DuplicateOrganizationErr
+	}
+	r.orgs[org.ID] = *org
+
+	return nil
+}
+
+// MemoryMembershipRepo keeps memberships in process, for tests and single instance setups.
+type MemoryMembershipRepo struct {
+	mu          sync.Mutex
+	memberships map[string]models.Membership
+}
+
+func NewMemoryMembershipRepo(memberships ...*models.Membership) *MemoryMembershipRepo {
+	r := &MemoryMembershipRepo{
+		memberships: make(map[string]models.Membership, len(memberships)),
+	}
+	for _, membership := range memberships {
+		r.memberships[membershipKey(membership.OrganizationID, membership.UserID.Hex())] = *membership
+	}
+
+	return r
+}
+
+func (r *MemoryMembershipRepo) Get(ctx context.Context, orgID, userID string) (*models.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	membership, ok := r.memberships[membershipKey(orgID, userID)]
+	if !ok {
+		return nil, NotFoundMembershipErr
+	}
+
+	return &membership, nil
+}
+
+func (r *MemoryMembershipRepo) GetByOrganization(ctx context.Context, orgID string) ([]*models.Membership, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	memberships := make([]*models.Membership, 0)
+	for _, membership := range r.memberships {
+		if membership.OrganizationID == orgID {
+			membership := membership
+			memberships = append(memberships, &membership)
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		if !memberships[i].CreatedAt.Equal(memberships[j].CreatedAt) {
+			return memberships[i].CreatedAt.Before(memberships[j].CreatedAt)
+		}
+		return memberships[i].UserID.Hex() < memberships[j].UserID.Hex()
+	})
+
+	return memberships, nil
+}
+
+func (r *MemoryMembershipRepo) Save(ctx context.Context, membership *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := membershipKey(membership.OrganizationID, membership.UserID.Hex())
+	stored := *membership
+	if existing, ok := r.memberships[key]; ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	r.memberships[key] = stored
+
+	return nil
+}
+
+func (r *MemoryMembershipRepo) Delete(ctx context.Context, orgID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := membershipKey(orgID, userID)
+	if _, ok := r.memberships[key]; !ok {
+		return NotFoundMembershipErr
+	}
+	delete(r.memberships, key)
+
+	return nil
+}
+
+func membershipKey(orgID, userID string) string {
+	return orgID + "/" + userID
+}
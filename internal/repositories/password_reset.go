@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	PASSWORD_RESET_COLLECTION = "password_resets"
+)
+
+var NotFoundPasswordResetErr = errors.New("password reset not found")
+
+// PasswordResetRepo stores pending password resets by the hash of their
+// token, expired resets are removed by a TTL index.
+type PasswordResetRepo struct {
+	db *mongo.Database
+}
+
+func NewPasswordResetRepo(db *mongo.Database) *PasswordResetRepo {
+	return &PasswordResetRepo{
+		db: db,
+	}
+}
+
+func (r *PasswordResetRepo) Create(ctx context.Context, reset *models.PasswordReset) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(PASSWORD_RESET_COLLECTION).InsertOne(ctx, reset)
+
+	return err
+}
+
+// Consume deletes the reset and returns it, so a token is used only once.
+func (r *PasswordResetRepo) Consume(ctx context.Context, id string) (*models.PasswordReset, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var reset models.PasswordReset
+	err := r.db.Collection(PASSWORD_RESET_COLLECTION).FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&reset)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundPasswordResetErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+// DeleteByUser drops every pending reset of the user.
+func (r *PasswordResetRepo) DeleteByUser(ctx context.Context, userID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(PASSWORD_RESET_COLLECTION).DeleteMany(ctx, bson.M{"user_id": userID})
+
+	return err
+}
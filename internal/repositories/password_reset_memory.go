@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sync"
+)
+
+// MemoryPasswordResetRepo keeps pending password resets in process, for tests and single instance setups.
+type MemoryPasswordResetRepo struct {
+	mu     sync.Mutex
+	resets map[string]models.PasswordReset
+}
+
+func NewMemoryPasswordResetRepo() *MemoryPasswordResetRepo {
+	return &MemoryPasswordResetRepo{
+		resets: make(map[string]models.PasswordReset),
+	}
+}
+
+func (r *MemoryPasswordResetRepo) Create(ctx context.Context, reset *models.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resets[reset.ID] = *reset
+
+	return nil
+}
+
+func (r *MemoryPasswordResetRepo) Consume(ctx context.Context, id string) (*models.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reset, ok := r.resets[id]
+	if !ok {
+		return nil, NotFoundPasswordResetErr
+	}
+	delete(r.resets, id)
+
+	return &reset, nil
+}
+
+func (r *MemoryPasswordResetRepo) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, reset := range r.resets {
+		if reset.UserID == userID {
+			delete(r.resets, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	PERSONAL_TOKEN_COLLECTION = "personal_tokens"
+)
+
+var NotFoundPersonalTokenErr = errors.New("personal access token not found")
+
+// PersonalTokenRepo stores personal access tokens by the hash of the token,
+// expired tokens are removed by a TTL index.
+type PersonalTokenRepo struct {
+	db *mongo.Database
+}
+
+func NewPersonalTokenRepo(db *mongo.Database) *PersonalTokenRepo {
+	return &PersonalTokenRepo{
+		db: db,
+	}
+}
+
+func (r *PersonalTokenRepo) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(PERSONAL_TOKEN_COLLECTION).InsertOne(ctx, token)
+
+	return err
+}
+
+func (r *PersonalTokenRepo) GetByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var token models.PersonalAccessToken
+	err := r.db.Collection(PERSONAL_TOKEN_COLLECTION).FindOne(ctx, bson.M{"hash": hash}).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundPersonalTokenErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *PersonalTokenRepo) GetByUser(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{
+		"user_id":    userID,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	cursor, err := r.db.Collection(PERSONAL_TOKEN_COLLECTION).Find(ctx, filter, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]*models.PersonalAccessToken, 0)
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (r *PersonalTokenRepo) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	update := bson.M{
+		"$set": bson.M{
+			"last_used_at": lastUsedAt,
+		},
+	}
+	_, err := r.db.Collection(PERSONAL_TOKEN_COLLECTION).UpdateOne(ctx, bson.M{"_id": id}, update)
+
+	return err
+}
+
+// Delete removes a token of the user, tokens of other users are not found.
+func (r *PersonalTokenRepo) Delete(ctx context.Context, userID, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(PERSONAL_TOKEN_COLLECTION).DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundPersonalTokenErr
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryPersonalTokenRepo keeps personal access tokens in process, for tests and single instance setups.
+type MemoryPersonalTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[string]models.PersonalAccessToken
+}
+
+func NewMemoryPersonalTokenRepo() *MemoryPersonalTokenRepo {
+	return &MemoryPersonalTokenRepo{
+		tokens: make(map[string]models.PersonalAccessToken),
+	}
+}
+
+func (r *MemoryPersonalTokenRepo) Create(ctx context.Context, token *models.PersonalAccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.ID] = *token
+
+	return nil
+}
+
+func (r *MemoryPersonalTokenRepo) GetByHash(ctx context.Context, hash string) (*models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.Hash == hash && time.Now().Before(token.ExpiresAt) {
+			return &token, nil
+		}
+	}
+
+	return nil, NotFoundPersonalTokenErr
+}
+
+func (r *MemoryPersonalTokenRepo) GetByUser(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	tokens := make([]*models.PersonalAccessToken, 0)
+	for _, token := range r.tokens {
+		if token.UserID == userID && now.Before(token.ExpiresAt) {
+			token := token
+			tokens = append(tokens, &token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+
+	return tokens, nil
+}
+
+func (r *MemoryPersonalTokenRepo) Touch(ctx context.Context, id string, lastUsedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil
+	}
+	token.LastUsedAt = lastUsedAt
+	r.tokens[id] = token
+
+	return nil
+}
+
+func (r *MemoryPersonalTokenRepo) Delete(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UserID != userID {
+		return NotFoundPersonalTokenErr
+	}
+	delete(r.tokens, id)
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	POLICY_COLLECTION = "policy_rules"
+)
+
+var NotFoundPolicyRuleErr = errors.New("policy rule not found")
+
+type PolicyRepo struct {
+	db *mongo.Database
+}
+
+func NewPolicyRepo(db *mongo.Database) *PolicyRepo {
+	return &PolicyRepo{
+		db: db,
+	}
+}
+
+// GetAll returns the rules ordered by id.
+func (r *PolicyRepo) GetAll(ctx context.Context) ([]*models.PolicyRule, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	cursor, err := r.db.Collection(POLICY_COLLECTION).Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*models.PolicyRule, 0)
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *PolicyRepo) Save(ctx context.Context, rule *models.PolicyRule) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(POLICY_COLLECTION).ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+func (r *PolicyRepo) Delete(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(POLICY_COLLECTION).DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundPolicyRuleErr
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+)
+
+// MemoryPolicyRepo keeps policy rules in process, for tests and single instance setups.
+type MemoryPolicyRepo struct {
+	mu    sync.Mutex
+	rules map[string]models.PolicyRule
+}
+
+func NewMemoryPolicyRepo(rules ...*models.PolicyRule) *MemoryPolicyRepo {
+	r := &MemoryPolicyRepo{
+		rules: make(map[string]models.PolicyRule, len(rules)),
+	}
+	for _, rule := range rules {
+		r.rules[rule.ID] = *rule
+	}
+
+	return r
+}
+
+func (r *MemoryPolicyRepo) GetAll(ctx context.Context) ([]*models.PolicyRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := make([]*models.PolicyRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rule := rule
+		rules = append(rules, &rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return rules, nil
+}
+
+func (r *MemoryPolicyRepo) Save(ctx context.Context, rule *models.PolicyRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rules[rule.ID] = *rule
+
+	return nil
+}
+
+func (r *MemoryPolicyRepo) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.rules[id]; !ok {
+		return NotFoundPolicyRuleErr
+	}
+	delete(r.rules, id)
+
+	return nil
+}
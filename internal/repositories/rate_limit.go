@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"strconv"
+	"time"
+)
+
+const (
+	RATE_LIMIT_COLLECTION = "rate_limits"
+)
+
+// RateLimitRepo counts requests per key and time window, shared by all
+// instances. Past windows are removed by a TTL index.
+type RateLimitRepo struct {
+	db *mongo.Database
+}
+
+func NewRateLimitRepo(db *mongo.Database) *RateLimitRepo {
+	return &RateLimitRepo{
+		db: db,
+	}
+}
+
+type rateLimitWindow struct {
+	ID        string    `bson:"_id"`
+	Count     int       `bson:"count"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// Hit counts a request of the key in the window and returns the requests of
+// the window with it counted.
+func (r *RateLimitRepo) Hit(ctx context.Context, key string, window, expiresAt time.Time) (int, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	update := bson.M{
+		"$inc":         bson.M{"count": 1},
+		"$setOnInsert": bson.M{"expires_at": expiresAt},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	collection := r.db.Collection(RATE_LIMIT_COLLECTION)
+
+	var counter rateLimitWindow
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": windowID(key, window)}, update, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// a concurrent request of the key opened the window first
+		err = collection.FindOneAndUpdate(ctx, bson.M{"_id": windowID(key, window)}, update, opts).Decode(&counter)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return counter.Count, nil
+}
+
+// Count returns the requests of the key in the window, zero for windows
+// without requests.
+func (r *RateLimitRepo) Count(ctx context.Context, key string, window time.Time) (int, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var counter rateLimitWindow
+	err := r.db.Collection(RATE_LIMIT_COLLECTION).FindOne(ctx, bson.M{"_id": windowID(key, window)}).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return counter.Count, nil
+}
+
+func windowID(key string, window time.Time) string {
+	return key + "@" + strconv.FormatInt(window.UnixMilli(), 10)
+}
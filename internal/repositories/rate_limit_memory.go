@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often past windows are dropped.
+const rateLimitSweepInterval = time.Minute
+
+// MemoryRateLimitRepo counts requests in process, for tests and single instance setups.
+type MemoryRateLimitRepo struct {
+	mu        sync.Mutex
+	windows   map[string]rateLimitWindow
+	nextSweep time.Time
+}
+
+func NewMemoryRateLimitRepo() *MemoryRateLimitRepo {
+	return &MemoryRateLimitRepo{
+		windows: make(map[string]rateLimitWindow),
+	}
+}
+
+func (r *MemoryRateLimitRepo) Hit(ctx context.Context, key string, window, expiresAt time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sweep(time.Now())
+
+	id := windowID(key, window)
+	counter, ok := r.windows[id]
+	if !ok {
+		counter = rateLimitWindow{ID: id, ExpiresAt: expiresAt}
+	}
+	counter.Count++
+	r.windows[id] = counter
+
+	return counter.Count, nil
+}
+
+func (r *MemoryRateLimitRepo) Count(ctx context.Context, key string, window time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.windows[windowID(key, window)].Count, nil
+}
+
+// sweep drops the expired windows at most once per interval, so memory
+// stays bounded by the clients of the last windows.
+func (r *MemoryRateLimitRepo) sweep(now time.Time) {
+	if now.Before(r.nextSweep) {
+		return
+	}
+	for id, counter := range r.windows {
+		if !now.Before(counter.ExpiresAt) {
+			delete(r.windows, id)
+		}
+	}
+	r.nextSweep = now.Add(rateLimitSweepInterval)
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	REVOKED_TOKEN_COLLECTION = "revoked_tokens"
+)
+
+// RevocationRepo stores revoked token ids, expired entries are removed by a TTL index.
+type RevocationRepo struct {
+	db *mongo.Database
+}
+
+func NewRevocationRepo(db *mongo.Database) *RevocationRepo {
+	return &RevocationRepo{
+		db: db,
+	}
+}
+
+func (r *RevocationRepo) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	doc := models.RevokedToken{
+		ID:        id,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	_, err := r.db.Collection(REVOKED_TOKEN_COLLECTION).ReplaceOne(ctx, bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+func (r *RevocationRepo) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	count, err := r.db.Collection(REVOKED_TOKEN_COLLECTION).CountDocuments(ctx, bson.M{
+		"_id":        bson.M{"$in": ids},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
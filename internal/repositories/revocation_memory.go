@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevocationRepo keeps revoked token ids in process, for tests and single instance setups.
+type MemoryRevocationRepo struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevocationRepo() *MemoryRevocationRepo {
+	return &MemoryRevocationRepo{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (r *MemoryRevocationRepo) Revoke(ctx context.Context, id string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for revokedID, exp := range r.revoked {
+		if now.After(exp) {
+			delete(r.revoked, revokedID)
+		}
+	}
+	r.revoked[id] = expiresAt
+
+	return nil
+}
+
+func (r *MemoryRevocationRepo) IsRevoked(ctx context.Context, ids ...string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if exp, ok := r.revoked[id]; ok && now.Before(exp) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
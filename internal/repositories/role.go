@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	ROLE_COLLECTION = "roles"
+)
+
+var NotFoundRoleErr = errors.New("role not found")
+
+type RoleRepo struct {
+	db *mongo.Database
+}
+
+func NewRoleRepo(db *mongo.Database) *RoleRepo {
+	return &RoleRepo{
+		db: db,
+	}
+}
+
+func (r *RoleRepo) GetAll(ctx context.Context) ([]*models.Role, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return r.find(ctx, bson.M{})
+}
+
+func (r *RoleRepo) Get(ctx context.Context, name string) (*models.Role, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var role models.Role
+	err := r.db.Collection(ROLE_COLLECTION).FindOne(ctx, bson.M{"_id": name}).Decode(&role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundRoleErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepo) GetMany(ctx context.Context, names []string) ([]*models.Role, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if len(names) == 0 {
+		return []*models.Role{}, nil
+	}
+
+	return r.find(ctx, bson.M{"_id": bson.M{"$in": names}})
+}
+
+func (r *RoleRepo) Save(ctx context.Context, role *models.Role) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(ROLE_COLLECTION).ReplaceOne(ctx, bson.M{"_id": role.Name}, role, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+func (r *RoleRepo) Delete(ctx context.Context, name string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(ROLE_COLLECTION).DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundRoleErr
+	}
+
+	return nil
+}
+
+func (r *RoleRepo) find(ctx context.Context, filter bson.M) ([]*models.Role, error) {
+	cursor, err := r.db.Collection(ROLE_COLLECTION).Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*models.Role, 0)
+	if err := cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
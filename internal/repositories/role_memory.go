@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+)
+
+// MemoryRoleRepo keeps role definitions in process, for tests and single instance setups.
+type MemoryRoleRepo struct {
+	mu    sync.Mutex
+	roles map[string]models.Role
+}
+
+func NewMemoryRoleRepo(roles ...*models.Role) *MemoryRoleRepo {
+	r := &MemoryRoleRepo{
+		roles: make(map[string]models.Role, len(roles)),
+	}
+	for _, role := range roles {
+		r.roles[role.Name] = *role
+	}
+
+	return r
+}
+
+func (r *MemoryRoleRepo) GetAll(ctx context.Context) ([]*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	roles := make([]*models.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		role := role
+		roles = append(roles, &role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+
+	return roles, nil
+}
+
+func (r *MemoryRoleRepo) Get(ctx context.Context, name string) (*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, NotFoundRoleErr
+	}
+
+	return &role, nil
+}
+
+func (r *MemoryRoleRepo) GetMany(ctx context.Context, names []string) ([]*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	roles := make([]*models.Role, 0, len(names))
+	for _, name := range names {
+		if role, ok := r.roles[name]; ok {
+			roles = append(roles, &role)
+		}
+	}
+
+	return roles, nil
+}
+
+func (r *MemoryRoleRepo) Save(ctx context.Context, role *models.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.roles[role.Name] = *role
+
+	return nil
+}
+
+func (r *MemoryRoleRepo) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[name]; !ok {
+		return NotFoundRoleErr
+	}
+	delete(r.roles, name)
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	SERVICE_ACCOUNT_COLLECTION = "service_accounts"
+)
+
+var (
+	NotFoundServiceAccountErr    = errors.New("service account not found")
+	NotFoundServiceAccountKeyErr = errors.New("service account key not found")
+)
+
+// ServiceAccountRepo stores service accounts with their public keys and the
+// hashes of their API keys.
+type ServiceAccountRepo struct {
+	db *mongo.Database
+}
+
+func NewServiceAccountRepo(db *mongo.Database) *ServiceAccountRepo {
+	return &ServiceAccountRepo{
+		db: db,
+	}
+}
+
+func (r *ServiceAccountRepo) Create(ctx context.Context, account *models.ServiceAccount) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(SERVICE_ACCOUNT_COLLECTION).InsertOne(ctx, account)
+
+	return err
+}
+
+func (r *ServiceAccountRepo) Get(ctx context.Context, id string) (*models.ServiceAccount, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, NotFoundServiceAccountErr
+	}
+
+	return r.findOne(ctx, bson.M{"_id": docID})
+}
+
+func (r *ServiceAccountRepo) GetByAPIKey(ctx context.Context, hash string) (*models.ServiceAccount, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return r.findOne(ctx, bson.M{"api_keys.hash": hash})
+}
+
+// GetAll lists the accounts of the owner by name, every account when
+// ownerID is empty.
+func (r *ServiceAccountRepo) GetAll(ctx context.Context, ownerID string) ([]*models.ServiceAccount, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{}
+	if ownerID != "" {
+		filter["owner_id"] = ownerID
+	}
+	cursor, err := r.db.Collection(SERVICE_ACCOUNT_COLLECTION).Find(ctx, filter, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]*models.ServiceAccount, 0)
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// SetDisabled disables the account at the given time or enables it again.
+func (r *ServiceAccountRepo) SetDisabled(ctx context.Context, id string, disabled bool, at time.Time) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	update := bson.M{"$set": bson.M{"disabled": true, "disabled_at": at}}
+	if !disabled {
+		update = bson.M{"$set": bson.M{"disabled": false}, "$unset": bson.M{"disabled_at": ""}}
+	}
+
+	return r.updateOne(ctx, id, bson.M{}, update, NotFoundServiceAccountErr)
+}
+
+func (r *ServiceAccountRepo) UpdateRoles(ctx context.Context, id string, roles []string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return r.updateOne(ctx, id, bson.M{}, bson.M{"$set": bson.M{"roles": roles}}, NotFoundServiceAccountErr)
+}
+
+func (r *ServiceAccountRepo) AddKey(ctx context.Context, id string, key *models.ServiceAccountKey) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return r.updateOne(ctx, id, bson.M{}, bson.M{"$push": bson.M{"keys": key}}, NotFoundServiceAccountErr)
+}
+
+func (r *ServiceAccountRepo) DeleteKey(ctx context.Context, id, keyID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	update := bson.M{"$pull": bson.M{"keys": bson.M{"id": keyID}}}
+
+	return r.updateOne(ctx, id, bson.M{"keys.id": keyID}, update, NotFoundServiceAccountKeyErr)
+}
+
+func (r *ServiceAccountRepo) AddAPIKey(ctx context.Context, id string, key *models.ServiceAccountAPIKey) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return r.updateOne(ctx, id, bson.M{}, bson.M{"$push": bson.M{"api_keys": key}}, NotFoundServiceAccountErr)
+}
+
+func (r *ServiceAccountRepo) DeleteAPIKey(ctx context.Context, id, keyID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	update := bson.M{"$pull": bson.M{"api_keys": bson.M{"id": keyID}}}
+
+	return r.updateOne(ctx, id, bson.M{"api_keys.id": keyID}, update, NotFoundServiceAccountKeyErr)
+}
+
+func (r *ServiceAccountRepo) findOne(ctx context.Context, filter bson.M) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	err := r.db.Collection(SERVICE_ACCOUNT_COLLECTION).FindOne(ctx, filter).Decode(&account)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundServiceAccountErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// updateOne updates the account matching the filter, notFound is returned
+// when there is none.
+func (r *ServiceAccountRepo) updateOne(ctx context.Context, id string, filter, update bson.M, notFound error) error {
+	docID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return NotFoundServiceAccountErr
+	}
+	filter["_id"] = docID
+
+	res, err := r.db.Collection(SERVICE_ACCOUNT_COLLECTION).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return notFound
+	}
+
+	return nil
+}
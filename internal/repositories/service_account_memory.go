@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryServiceAccountRepo keeps service accounts in process, for tests and single instance setups.
+type MemoryServiceAccountRepo struct {
+	mu       sync.Mutex
+	accounts map[string]*models.ServiceAccount
+}
+
+func NewMemoryServiceAccountRepo() *MemoryServiceAccountRepo {
+	return &MemoryServiceAccountRepo{
+		accounts: make(map[string]*models.ServiceAccount),
+	}
+}
+
+func (r *MemoryServiceAccountRepo) Create(ctx context.Context, account *models.ServiceAccount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.accounts[account.ID.Hex()] = cloneServiceAccount(account)
+
+	return nil
+}
+
+func (r *MemoryServiceAccountRepo) Get(ctx context.Context, id string) (*models.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, NotFoundServiceAccountErr
+	}
+
+	return cloneServiceAccount(account), nil
+}
+
+func (r *MemoryServiceAccountRepo) GetByAPIKey(ctx context.Context, hash string) (*models.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, account := range r.accounts {
+		for _, key := range account.APIKeys {
+			if key.Hash == hash {
+				return cloneServiceAccount(account), nil
+			}
+		}
+	}
+
+	return nil, NotFoundServiceAccountErr
+}
+
+func (r *MemoryServiceAccountRepo) GetAll(ctx context.Context, ownerID string) ([]*models.ServiceAccount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accounts := make([]*models.ServiceAccount, 0)
+	for _, account := range r.accounts {
+		if ownerID == "" || account.OwnerID == ownerID {
+			accounts = append(accounts, cloneServiceAccount(account))
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+
+	return accounts, nil
+}
+
+func (r *MemoryServiceAccountRepo) SetDisabled(ctx context.Context, id string, disabled bool, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return NotFoundServiceAccountErr
+	}
+	account.Disabled = disabled
+	account.DisabledAt = time.Time{}
+	if disabled {
+		account.DisabledAt = at
+	}
+
+	return nil
+}
+
+func (r *MemoryServiceAccountRepo) UpdateRoles(ctx context.Context, id string, roles []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return NotFoundServiceAccountErr
+	}
+	account.Roles = append([]string(nil), roles...)
+
+	return nil
+}
+
+func (r *MemoryServiceAccountRepo) AddKey(ctx context.Context, id string, key *models.ServiceAccountKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return NotFoundServiceAccountErr
+	}
+	account.Keys = append(account.Keys, *key)
+
+	return nil
+}
+
+func (r *MemoryServiceAccountRepo) DeleteKey(ctx context.Context, id, keyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return NotFoundServiceAccountErr
+	}
+	for i, key := range account.Keys {
+		if key.ID == keyID {
+			account.Keys = append(account.Keys[:i:i], account.Keys[i+1:]...)
+			return nil
+		}
+	}
+
+	return NotFoundServiceAccountKeyErr
+}
+
+func (r *MemoryServiceAccountRepo) AddAPIKey(ctx context.Context, id string, key *models.ServiceAccountAPIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return NotFoundServiceAccountErr
+	}
+	account.APIKeys = append(account.APIKeys, *key)
+
+	return nil
+}
+
+func (r *MemoryServiceAccountRepo) DeleteAPIKey(ctx context.Context, id, keyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	account, ok := r.accounts[id]
+	if !ok {
+		return NotFoundServiceAccountErr
+	}
+	for i, key := range account.APIKeys {
+		if key.ID == keyID {
+			account.APIKeys = append(account.APIKeys[:i:i], account.APIKeys[i+1:]...)
+			return nil
+		}
+	}
+
+	return NotFoundServiceAccountKeyErr
+}
+
+// cloneServiceAccount copies the slices too, callers must not share them with the store.
+func cloneServiceAccount(account *models.ServiceAccount) *models.ServiceAccount {
+	c := *account
+	c.Roles = append([]string(nil), account.Roles...)
+	c.Keys = append([]models.ServiceAccountKey(nil), account.Keys...)
+	c.APIKeys = append([]models.ServiceAccountAPIKey(nil), account.APIKeys...)
+
+	return &c
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	SESSION_COLLECTION = "sessions"
+)
+
+var NotFoundSessionErr = errors.New("session not found")
+
+// SessionRepo stores user sessions, expired sessions are removed by a TTL index.
+type SessionRepo struct {
+	db *mongo.Database
+}
+
+func NewSessionRepo(db *mongo.Database) *SessionRepo {
+	return &SessionRepo{
+		db: db,
+	}
+}
+
+func (r *SessionRepo) Create(ctx context.Context, session *models.Session) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(SESSION_COLLECTION).InsertOne(ctx, session)
+
+	return err
+}
+
+func (r *SessionRepo) Get(ctx context.Context, id string) (*models.Session, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var session models.Session
+	err := r.db.Collection(SESSION_COLLECTION).FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundSessionErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *SessionRepo) GetByUser(ctx context.Context, userID string) ([]*models.Session, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	cursor, err := r.db.Collection(SESSION_COLLECTION).Find(ctx, filter, options.Find().SetSort(bson.M{"last_seen_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*models.Session, 0)
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepo) Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	update := bson.M{
+		"$set": bson.M{
+			"last_seen_at": lastSeenAt,
+			"expires_at":   expiresAt,
+		},
+	}
+	_, err := r.db.Collection(SESSION_COLLECTION).UpdateOne(ctx, bson.M{"_id": id}, update)
+
+	return err
+}
+
+func (r *SessionRepo) Revoke(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{
+		"_id":        id,
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"revoked_at": time.Now(),
+		},
+	}
+	_, err := r.db.Collection(SESSION_COLLECTION).UpdateOne(ctx, filter, update)
+
+	return err
+}
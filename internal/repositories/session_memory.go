@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySessionRepo keeps sessions in process, for tests and single instance setups.
+type MemorySessionRepo struct {
+	mu       sync.Mutex
+	sessions map[string]models.Session
+}
+
+func NewMemorySessionRepo() *MemorySessionRepo {
+	return &MemorySessionRepo{
+		sessions: make(map[string]models.Session),
+	}
+}
+
+func (r *MemorySessionRepo) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[session.ID] = *session
+
+	return nil
+}
+
+func (r *MemorySessionRepo) Get(ctx context.Context, id string) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || time.Now().After(session.ExpiresAt) {
+		return nil, NotFoundSessionErr
+	}
+
+	return &session, nil
+}
+
+func (r *MemorySessionRepo) GetByUser(ctx context.Context, userID string) ([]*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]*models.Session, 0)
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.Active(now) {
+			session := session
+			sessions = append(sessions, &session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+func (r *MemorySessionRepo) Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil
+	}
+
+	session.LastSeenAt = lastSeenAt
+	session.ExpiresAt = expiresAt
+	r.sessions[id] = session
+
+	return nil
+}
+
+func (r *MemorySessionRepo) Revoke(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	r.sessions[id] = session
+
+	return nil
+}
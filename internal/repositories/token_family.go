@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const (
+	TOKEN_FAMILY_COLLECTION = "token_families"
+)
+
+var NotFoundTokenFamilyErr = errors.New("token family not found")
+
+type TokenFamilyRepo struct {
+	db *mongo.Database
+}
+
+func NewTokenFamilyRepo(db *mongo.Database) *TokenFamilyRepo {
+	return &TokenFamilyRepo{
+		db: db,
+	}
+}
+
+func (r *TokenFamilyRepo) Create(ctx context.Context, family *models.TokenFamily) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(TOKEN_FAMILY_COLLECTION).InsertOne(ctx, family)
+
+	return err
+}
+
+func (r *TokenFamilyRepo) Get(ctx context.Context, id string) (*models.TokenFamily, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var family models.TokenFamily
+	err := r.db.Collection(TOKEN_FAMILY_COLLECTION).FindOne(ctx, bson.M{"_id": id}).Decode(&family)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundTokenFamilyErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &family, nil
+}
+
+func (r *TokenFamilyRepo) Rotate(ctx context.Context, id, currentID, nextID string, expiresAt time.Time) (bool, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{
+		"_id":              id,
+		"current_token_id": currentID,
+		"revoked_at":       bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"current_token_id": nextID,
+			"rotated_at":       time.Now(),
+			"expires_at":       expiresAt,
+		},
+	}
+
+	res, err := r.db.Collection(TOKEN_FAMILY_COLLECTION).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *TokenFamilyRepo) Revoke(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	filter := bson.M{
+		"_id":        id,
+		"revoked_at": bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"revoked_at": time.Now(),
+		},
+	}
+
+	_, err := r.db.Collection(TOKEN_FAMILY_COLLECTION).UpdateOne(ctx, filter, update)
+
+	return err
+}
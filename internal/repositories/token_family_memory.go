@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryTokenFamilyRepo keeps token families in process, for tests and single instance setups.
+type MemoryTokenFamilyRepo struct {
+	mu       sync.Mutex
+	families map[string]models.TokenFamily
+}
+
+func NewMemoryTokenFamilyRepo() *MemoryTokenFamilyRepo {
+	return &MemoryTokenFamilyRepo{
+		families: make(map[string]models.TokenFamily),
+	}
+}
+
+func (r *MemoryTokenFamilyRepo) Create(ctx context.Context, family *models.TokenFamily) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.families[family.ID] = *family
+
+	return nil
+}
+
+func (r *MemoryTokenFamilyRepo) Get(ctx context.Context, id string) (*models.TokenFamily, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family, ok := r.families[id]
+	if !ok || time.Now().After(family.ExpiresAt) {
+		return nil, NotFoundTokenFamilyErr
+	}
+
+	return &family, nil
+}
+
+func (r *MemoryTokenFamilyRepo) Rotate(ctx context.Context, id, currentID, nextID string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family, ok := r.families[id]
+	if !ok || family.Revoked() || family.CurrentTokenID != currentID {
+		return false, nil
+	}
+
+	family.CurrentTokenID = nextID
+	family.RotatedAt = time.Now()
+	family.ExpiresAt = expiresAt
+	r.families[id] = family
+
+	return true, nil
+}
+
+func (r *MemoryTokenFamilyRepo) Revoke(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	family, ok := r.families[id]
+	if !ok || family.Revoked() {
+		return nil
+	}
+
+	now := time.Now()
+	family.RevokedAt = &now
+	r.families[id] = family
+
+	return nil
+}
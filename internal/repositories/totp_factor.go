@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	TOTP_FACTOR_COLLECTION = "totp_factors"
+)
+
+var (
+	NotFoundTOTPFactorErr  = errors.New("totp factor not found")
+	ConfirmedTOTPFactorErr = errors.New("totp factor is already confirmed")
+)
+
+// TOTPFactorRepo stores the authenticator apps of users, one per user.
+type TOTPFactorRepo struct {
+	db *mongo.Database
+}
+
+func NewTOTPFactorRepo(db *mongo.Database) *TOTPFactorRepo {
+	return &TOTPFactorRepo{
+		db: db,
+	}
+}
+
+func (r *TOTPFactorRepo) Get(ctx context.Context, userID string) (*models.TOTPFactor, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var factor models.TOTPFactor
+	err := r.db.Collection(TOTP_FACTOR_COLLECTION).FindOne(ctx, bson.M{"_id": userID}).Decode(&factor)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundTOTPFactorErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &factor, nil
+}
+
+// Save stores a new unconfirmed factor in place of an unconfirmed one. A
+// confirmed factor is never replaced, the upsert then collides with it.
+func (r *TOTPFactorRepo) Save(ctx context.Context, factor *models.TOTPFactor) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(TOTP_FACTOR_COLLECTION).ReplaceOne(ctx,
+		bson.M{"_id": factor.ID, "confirmed": false},
+		factor,
+		options.Replace().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ConfirmedTOTPFactorErr
+	}
+
+	return err
+}
+
+// Confirm turns the unconfirmed factor into a second factor with the hashed
+// recovery codes, step is the time step of the confirming code.
+func (r *TOTPFactorRepo) Confirm(ctx context.Context, userID string, recoveryCodes []string, step int64, at time.Time) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(TOTP_FACTOR_COLLECTION).UpdateOne(ctx,
+		bson.M{"_id": userID, "confirmed": false},
+		bson.M{"$set": bson.M{
+			"confirmed":      true,
+			"recovery_codes": recoveryCodes,
+			"last_step":      step,
+			"confirmed_at":   at,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return NotFoundTOTPFactorErr
+	}
+
+	return nil
+}
+
+// UseStep records step as the last accepted one, returning false when a
+// code of this or a later step has been accepted already.
+func (r *TOTPFactorRepo) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(TOTP_FACTOR_COLLECTION).UpdateOne(ctx,
+		bson.M{"_id": userID, "confirmed": true, "last_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"last_step": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+}
+
+// UseRecoveryCode removes the hashed recovery code, returning false when the
+// user has no such code.
+func (r *TOTPFactorRepo) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(TOTP_FACTOR_COLLECTION).UpdateOne(ctx,
+		bson.M{"_id": userID, "confirmed": true, "recovery_codes": hash},
+		bson.M{"$pull": bson.M{"recovery_codes": hash}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *TOTPFactorRepo) Delete(ctx context.Context, userID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(TOTP_FACTOR_COLLECTION).DeleteOne(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundTOTPFactorErr
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sync"
+	"time"
+)
+
+// MemoryTOTPFactorRepo keeps authenticator apps in process, for tests and single instance setups.
+type MemoryTOTPFactorRepo struct {
+	mu      sync.Mutex
+	factors map[string]models.TOTPFactor
+}
+
+func NewMemoryTOTPFactorRepo() *MemoryTOTPFactorRepo {
+	return &MemoryTOTPFactorRepo{
+		factors: make(map[string]models.TOTPFactor),
+	}
+}
+
+func (r *MemoryTOTPFactorRepo) Get(ctx context.Context, userID string) (*models.TOTPFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	factor, ok := r.factors[userID]
+	if !ok {
+		return nil, NotFoundTOTPFactorErr
+	}
+	factor.RecoveryCodes = append([]string{}, factor.RecoveryCodes...)
+
+	return &factor, nil
+}
+
+func (r *MemoryTOTPFactorRepo) Save(ctx context.Context, factor *models.TOTPFactor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.factors[factor.ID]; ok && existing.Confirmed {
+		return ConfirmedTOTPFactorErr
+	}
+	r.factors[factor.ID] = *factor
+
+	return nil
+}
+
+func (r *MemoryTOTPFactorRepo) Confirm(ctx context.Context, userID string, recoveryCodes []string, step int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	factor, ok := r.factors[userID]
+	if !ok || factor.Confirmed {
+		return NotFoundTOTPFactorErr
+	}
+	factor.Confirmed = true
+	factor.RecoveryCodes = append([]string{}, recoveryCodes...)
+	factor.LastStep = step
+	factor.ConfirmedAt = at
+	r.factors[userID] = factor
+
+	return nil
+}
+
+func (r *MemoryTOTPFactorRepo) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	factor, ok := r.factors[userID]
+	if !ok || !factor.Confirmed || factor.LastStep >= step {
+		return false, nil
+	}
+	factor.LastStep = step
+	r.factors[userID] = factor
+
+	return true, nil
+}
+
+func (r *MemoryTOTPFactorRepo) UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	factor, ok := r.factors[userID]
+	if !ok || !factor.Confirmed {
+		return false, nil
+	}
+	for i, code := range factor.RecoveryCodes {
+		if code == hash {
+			factor.RecoveryCodes = append(append([]string{}, factor.RecoveryCodes[:i]...), factor.RecoveryCodes[i+1:]...)
+			r.factors[userID] = factor
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *MemoryTOTPFactorRepo) Delete(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.factors[userID]; !ok {
+		return NotFoundTOTPFactorErr
+	}
+	delete(r.factors, userID)
+
+	return nil
+}
@@ -2,11 +2,14 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
 	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"regexp"
 	"time"
 )
 
@@ -18,6 +21,8 @@ const (
 	DB_COLLECTION = "users"
 )
 
+var DuplicateUserErr = errors.New("user with this username or email already exists")
+
 func NewDatabaseRepo(db *mongo.Database) *DatabaseRepo {
 	return &DatabaseRepo{
 		db: db,
@@ -60,18 +65,30 @@ func (r *DatabaseRepo) Get(ctx context.Context, id string) (*models.User, error)
 
 	var user models.User
 	err = query.Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundUserErr
+	}
 
 	return &user, err
 }
 
-func (r *DatabaseRepo) GetByName(ctx context.Context, uname string) (*models.User, error) {
+// GetByName looks the username up among the accounts of the tenant, an empty
+// tenant means the global accounts.
+func (r *DatabaseRepo) GetByName(ctx context.Context, tenant, uname string) (*models.User, error) {
 	ctx, span := utils.StartSpan(ctx)
 	defer span.End()
 
-	query := r.db.Collection(DB_COLLECTION).FindOne(ctx, bson.M{"username": uname})
+	filter := bson.M{"username": uname, "tenant": tenant}
+	if tenant == "" {
+		filter["tenant"] = bson.M{"$exists": false}
+	}
+	query := r.db.Collection(DB_COLLECTION).FindOne(ctx, filter)
 
 	var user models.User
 	err := query.Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundUserErr
+	}
 
 	return &user, err
 }
@@ -88,8 +105,15 @@ func (r *DatabaseRepo) Insert(ctx context.Context, user *models.User) error {
 		"last_name":     user.LastName,
 		"creation_date": time.Now().Unix(),
 	}
+	// global accounts have no tenant field so they share the null key of the unique indexes
+	if user.Tenant != "" {
+		dataReq["tenant"] = user.Tenant
+	}
 
 	_, err := r.db.Collection(DB_COLLECTION).InsertOne(ctx, dataReq)
+	if mongo.IsDuplicateKeyError(err) {
+		return DuplicateUserErr
+	}
 	if err != nil {
 		return err
 	}
@@ -101,18 +125,28 @@ func (r *DatabaseRepo) Update(ctx context.Context, user *models.User) error {
 	ctx, span := utils.StartSpan(ctx)
 	defer span.End()
 
+	// the password is changed by UpdatePassword only
 	dataReq := bson.M{
 		"$set": bson.M{
-			"password":   user.Password,
 			"email":      user.Email,
 			"first_name": user.FirstName,
 			"last_name":  user.LastName,
 		},
 	}
 
-	res := r.db.Collection(DB_COLLECTION).FindOneAndUpdate(ctx, bson.M{"username": user.Username}, dataReq)
+	// usernames are unique per tenant only, the id names one account
+	res, err := r.db.Collection(DB_COLLECTION).UpdateOne(ctx, bson.M{"_id": user.ID}, dataReq)
+	if mongo.IsDuplicateKeyError(err) {
+		return DuplicateUserErr
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return NotFoundUserErr
+	}
 
-	return res.Err()
+	return nil
 }
 
 func (r *DatabaseRepo) UpdatePassword(ctx context.Context, user *models.User) error {
@@ -130,3 +164,117 @@ func (r *DatabaseRepo) UpdatePassword(ctx context.Context, user *models.User) er
 
 	return err
 }
+
+func (r *DatabaseRepo) UpdateRoles(ctx context.Context, user *models.User) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	dataReq := bson.M{
+		"$set": bson.M{
+			"roles": user.Roles,
+		},
+	}
+
+	res, err := r.db.Collection(DB_COLLECTION).UpdateOne(ctx, bson.M{"_id": user.ID}, dataReq)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return NotFoundUserErr
+	}
+
+	return nil
+}
+
+// Find returns one page of users using the sort key and the id of the last
+// user of the previous page, so pages stay stable while users are added.
+func (r *DatabaseRepo) Find(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	query, err := normalizeUserQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{}
+	if query.UsernamePrefix != "" {
+		filter["username"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(query.UsernamePrefix)}
+	}
+	if query.EmailPrefix != "" {
+		filter["email"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(query.EmailPrefix)}
+	}
+
+	created := bson.M{}
+	if !query.CreatedFrom.IsZero() {
+		created["$gte"] = query.CreatedFrom.Unix()
+	}
+	if !query.CreatedTo.IsZero() {
+		created["$lte"] = query.CreatedTo.Unix()
+	}
+	if len(created) > 0 {
+		filter["creation_date"] = created
+	}
+
+	order, op := 1, "$gt"
+	if query.Desc {
+		order, op = -1, "$lt"
+	}
+
+	if query.Cursor != "" {
+		c, err := decodeUserCursor(query.Cursor, query.SortBy)
+		if err != nil {
+			return nil, err
+		}
+		id, err := primitive.ObjectIDFromHex(c.ID)
+		if err != nil {
+			return nil, InvalidCursorErr
+		}
+
+		var value interface{} = c.String
+		if query.SortBy == models.UserSortCreationDate {
+			value = int64(c.Number)
+		}
+
+		filter["$or"] = bson.A{
+			bson.M{query.SortBy: bson.M{op: value}},
+			bson.M{query.SortBy: value, "_id": bson.M{op: id}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: query.SortBy, Value: order}, {Key: "_id", Value: order}}).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := r.db.Collection(DB_COLLECTION).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*models.User, 0, query.Limit+1)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return newUserPage(users, query), nil
+}
+
+func (r *DatabaseRepo) Delete(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	docId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return NotFoundUserErr
+	}
+
+	res, err := r.db.Collection(DB_COLLECTION).DeleteOne(ctx, bson.M{"_id": docId})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundUserErr
+	}
+
+	return nil
+}
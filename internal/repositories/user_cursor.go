@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"strings"
+)
+
+var (
+	InvalidCursorErr = errors.New("invalid page cursor")
+	InvalidSortErr   = errors.New("invalid sort field")
+)
+
+// userCursor is the position after the last user of a page: its sort key
+// with the id as tie breaker.
+type userCursor struct {
+	Sort   string `json:"s"`
+	String string `json:"v,omitempty"`
+	Number uint64 `json:"n,omitempty"`
+	ID     string `json:"id"`
+}
+
+// normalizeUserQuery applies the defaults and rejects unknown sort fields.
+func normalizeUserQuery(query models.UserQuery) (models.UserQuery, error) {
+	switch query.SortBy {
+	case "":
+		query.SortBy = models.UserSortCreationDate
+	case models.UserSortUsername, models.UserSortEmail, models.UserSortCreationDate:
+	default:
+		return query, InvalidSortErr
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = models.DefaultUserPageLimit
+	}
+	if query.Limit > models.MaxUserPageLimit {
+		query.Limit = models.MaxUserPageLimit
+	}
+
+	return query, nil
+}
+
+func newUserCursor(user *models.User, sortBy string) *userCursor {
+	c := &userCursor{Sort: sortBy, ID: user.ID.Hex()}
+	switch sortBy {
+	case models.UserSortUsername:
+		c.String = user.Username
+	case models.UserSortEmail:
+		c.String = user.Email
+	case models.UserSortCreationDate:
+		c.Number = user.CreationDate
+	}
+
+	return c
+}
+
+func (c *userCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUserCursor(s, sortBy string) (*userCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, InvalidCursorErr
+	}
+
+	var c userCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sortBy || c.ID == "" {
+		return nil, InvalidCursorErr
+	}
+
+	return &c, nil
+}
+
+// compare orders the user against the cursor position in ascending order.
+func (c *userCursor) compare(user *models.User) int {
+	var r int
+	switch c.Sort {
+	case models.UserSortUsername:
+		r = strings.Compare(user.Username, c.String)
+	case models.UserSortEmail:
+		r = strings.Compare(user.Email, c.String)
+	case models.UserSortCreationDate:
+		switch {
+		case user.CreationDate < c.Number:
+			r = -1
+		case user.CreationDate > c.Number:
+			r = 1
+		}
+	}
+	if r != 0 {
+		return r
+	}
+
+	return strings.Compare(user.ID.Hex(), c.ID)
+}
+
+// newUserPage trims the extra user fetched to detect whether a next page exists.
+func newUserPage(users []*models.User, query models.UserQuery) *models.UserPage {
+	page := &models.UserPage{Users: users}
+	if len(users) > query.Limit {
+		page.Users = users[:query.Limit]
+		page.NextCursor = newUserCursor(page.Users[query.Limit-1], query.SortBy).encode()
+	}
+
+	return page
+}
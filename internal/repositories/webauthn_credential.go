@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	WEBAUTHN_CREDENTIAL_COLLECTION = "webauthn_credentials"
+)
+
+var (
+	NotFoundWebAuthnCredentialErr  = errors.New("webauthn credential not found")
+	DuplicateWebAuthnCredentialErr = errors.New("webauthn credential is already registered")
+)
+
+// WebAuthnCredentialRepo stores the public key credentials of users.
+type WebAuthnCredentialRepo struct {
+	db *mongo.Database
+}
+
+func NewWebAuthnCredentialRepo(db *mongo.Database) *WebAuthnCredentialRepo {
+	return &WebAuthnCredentialRepo{
+		db: db,
+	}
+}
+
+func (r *WebAuthnCredentialRepo) Create(ctx context.Context, credential *models.WebAuthnCredential) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(WEBAUTHN_CREDENTIAL_COLLECTION).InsertOne(ctx, credential)
+	if mongo.IsDuplicateKeyError(err) {
+		return DuplicateWebAuthnCredentialErr
+	}
+
+	return err
+}
+
+func (r *WebAuthnCredentialRepo) Get(ctx context.Context, id string) (*models.WebAuthnCredential, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var credential models.WebAuthnCredential
+	err := r.db.Collection(WEBAUTHN_CREDENTIAL_COLLECTION).FindOne(ctx, bson.M{"_id": id}).Decode(&credential)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundWebAuthnCredentialErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// GetByUser returns the credentials of the user, oldest first.
+func (r *WebAuthnCredentialRepo) GetByUser(ctx context.Context, userID string) ([]*models.WebAuthnCredential, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	cursor, err := r.db.Collection(WEBAUTHN_CREDENTIAL_COLLECTION).Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.M{"created_at": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]*models.WebAuthnCredential, 0)
+	if err := cursor.All(ctx, &credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateSignCount records the signature counter of an assertion.
+func (r *WebAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id string, signCount uint32, usedAt time.Time) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(WEBAUTHN_CREDENTIAL_COLLECTION).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"sign_count": signCount, "last_used_at": usedAt}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return NotFoundWebAuthnCredentialErr
+	}
+
+	return nil
+}
+
+// Delete removes a credential of the user, credentials of other users are not found.
+func (r *WebAuthnCredentialRepo) Delete(ctx context.Context, userID, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	res, err := r.db.Collection(WEBAUTHN_CREDENTIAL_COLLECTION).DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return NotFoundWebAuthnCredentialErr
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryWebAuthnCredentialRepo keeps public key credentials in process, for tests and single instance setups.
+type MemoryWebAuthnCredentialRepo struct {
+	mu          sync.Mutex
+	credentials map[string]models.WebAuthnCredential
+}
+
+func NewMemoryWebAuthnCredentialRepo() *MemoryWebAuthnCredentialRepo {
+	return &MemoryWebAuthnCredentialRepo{
+		credentials: make(map[string]models.WebAuthnCredential),
+	}
+}
+
+func (r *MemoryWebAuthnCredentialRepo) Create(ctx context.Context, credential *models.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.credentials[credential.ID]; ok {
+		return DuplicateWebAuthnCredentialErr
+	}
+	r.credentials[credential.ID] = *credential
+
+	return nil
+}
+
+func (r *MemoryWebAuthnCredentialRepo) Get(ctx context.Context, id string) (*models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok {
+		return nil, NotFoundWebAuthnCredentialErr
+	}
+
+	return &credential, nil
+}
+
+func (r *MemoryWebAuthnCredentialRepo) GetByUser(ctx context.Context, userID string) ([]*models.WebAuthnCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credentials := make([]*models.WebAuthnCredential, 0)
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credential := credential
+			credentials = append(credentials, &credential)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool {
+		return credentials[i].CreatedAt.Before(credentials[j].CreatedAt)
+	})
+
+	return credentials, nil
+}
+
+func (r *MemoryWebAuthnCredentialRepo) UpdateSignCount(ctx context.Context, id string, signCount uint32, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok {
+		return NotFoundWebAuthnCredentialErr
+	}
+	credential.SignCount = signCount
+	credential.LastUsedAt = usedAt
+	r.credentials[id] = credential
+
+	return nil
+}
+
+func (r *MemoryWebAuthnCredentialRepo) Delete(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok || credential.UserID != userID {
+		return NotFoundWebAuthnCredentialErr
+	}
+	delete(r.credentials, id)
+
+	return nil
+}
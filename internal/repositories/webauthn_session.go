@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	WEBAUTHN_SESSION_COLLECTION = "webauthn_sessions"
+)
+
+var NotFoundWebAuthnSessionErr = errors.New("webauthn session not found")
+
+// WebAuthnSessionRepo stores ceremonies waiting for the authenticator, expired sessions are removed by a TTL index.
+type WebAuthnSessionRepo struct {
+	db *mongo.Database
+}
+
+func NewWebAuthnSessionRepo(db *mongo.Database) *WebAuthnSessionRepo {
+	return &WebAuthnSessionRepo{
+		db: db,
+	}
+}
+
+func (r *WebAuthnSessionRepo) Create(ctx context.Context, session *models.WebAuthnSession) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	_, err := r.db.Collection(WEBAUTHN_SESSION_COLLECTION).InsertOne(ctx, session)
+
+	return err
+}
+
+// Consume deletes the session and returns it, so a challenge is answered only once.
+func (r *WebAuthnSessionRepo) Consume(ctx context.Context, id string) (*models.WebAuthnSession, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	var session models.WebAuthnSession
+	err := r.db.Collection(WEBAUTHN_SESSION_COLLECTION).FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, NotFoundWebAuthnSessionErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sync"
+)
+
+// MemoryWebAuthnSessionRepo keeps ceremonies waiting for the authenticator in process, for tests and single instance setups.
+type MemoryWebAuthnSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[string]models.WebAuthnSession
+}
+
+func NewMemoryWebAuthnSessionRepo() *MemoryWebAuthnSessionRepo {
+	return &MemoryWebAuthnSessionRepo{
+		sessions: make(map[string]models.WebAuthnSession),
+	}
+}
+
+func (r *MemoryWebAuthnSessionRepo) Create(ctx context.Context, session *models.WebAuthnSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions[session.ID] = *session
+
+	return nil
+}
+
+func (r *MemoryWebAuthnSessionRepo) Consume(ctx context.Context, id string) (*models.WebAuthnSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, NotFoundWebAuthnSessionErr
+	}
+	delete(r.sessions, id)
+
+	return &session, nil
+}
@@ -0,0 +1,105 @@
+package auth_service
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"time"
+)
+
+const (
+	accessTokenType  = "access"
+	refreshTokenType = "refresh"
+)
+
+var (
+	TokenExpiredErr = models.TokenExpiredErr
+	InvalidTokenErr = models.InvalidTokenErr
+	TokenRevokedErr = models.TokenRevokedErr
+)
+
+// Claims are the registered RFC 7519 claims plus the user profile. Only
+// access tokens carry the profile, refresh tokens identify the user by sub.
+type Claims struct {
+	jwt.StandardClaims
+	Type       string `json:"typ"`
+	Authorized bool   `json:"authorized,omitempty"`
+	UserID     string `json:"user_id,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Email      string `json:"email,omitempty"`
+	FirstName  string `json:"first_name,omitempty"`
+	LastName   string `json:"last_name,omitempty"`
+	// Roles and Permissions are set on access tokens only, role changes
+	// apply when the next access token is issued. Tokens of OAuth clients
+	// carry no roles and only the permissions named in Scope.
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// SessionID is the login session both tokens belong to.
+	SessionID string `json:"sid,omitempty"`
+	// Tenant is the organization the session was started for, set on both tokens.
+	Tenant string `json:"tenant,omitempty"`
+	// Family is the refresh token family, set on refresh tokens only.
+	Family string `json:"fam,omitempty"`
+	// Scope and ClientID are set on both tokens issued to OAuth clients.
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	// PrincipalType is service on client_credentials access tokens, whose
+	// subject is the client id, and service_account on tokens of service
+	// accounts, whose subject is the account id. It is empty on user tokens.
+	PrincipalType models.PrincipalType `json:"principal_type,omitempty"`
+}
+
+func (c *Claims) service() bool {
+	return c.PrincipalType == models.PrincipalService
+}
+
+func (c *Claims) serviceAccount() bool {
+	return c.PrincipalType == models.PrincipalServiceAccount
+}
+
+// Valid is a no-op: jwt-go does not support leeway, claims are checked by validate.
+func (c *Claims) Valid() error {
+	return nil
+}
+
+// validate checks the registered claims allowing the configured clock skew.
+func (c *Claims) validate(settings *JwtSettings, tokenType string, now time.Time) error {
+	leeway := int64(settings.Leeway / time.Second)
+	unix := now.Unix()
+
+	if c.ExpiresAt == 0 || unix > c.ExpiresAt+leeway {
+		return TokenExpiredErr
+	}
+	if c.NotBefore != 0 && unix < c.NotBefore-leeway {
+		return InvalidTokenErr
+	}
+	if c.IssuedAt != 0 && unix < c.IssuedAt-leeway {
+		return InvalidTokenErr
+	}
+	if settings.Issuer != "" && c.Issuer != settings.Issuer {
+		return InvalidTokenErr
+	}
+	if settings.Audience != "" && c.Audience != settings.Audience {
+		return InvalidTokenErr
+	}
+	if c.Type != tokenType || c.Subject == "" {
+		return InvalidTokenErr
+	}
+
+	return nil
+}
+
+// IDClaims are the claims of an OpenID Connect ID token. The audience is the
+// client the token was issued to.
+type IDClaims struct {
+	jwt.StandardClaims
+	Nonce             string `json:"nonce,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	GivenName         string `json:"given_name,omitempty"`
+	FamilyName        string `json:"family_name,omitempty"`
+	Email             string `json:"email,omitempty"`
+}
+
+// Valid is a no-op, ID tokens are verified by the relying party.
+func (c *IDClaims) Valid() error {
+	return nil
+}
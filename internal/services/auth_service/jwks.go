@@ -0,0 +1,62 @@
+package auth_service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"math/big"
+)
+
+// publicJWK converts a public key to its JWK form. Symmetric and unknown keys are not published.
+func publicJWK(key crypto.PublicKey, alg string) (models.JWK, bool) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return models.JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: alg,
+			N:   encodeBase64(k.N.Bytes()),
+			E:   encodeBase64(big.NewInt(int64(k.E)).Bytes()),
+		}, true
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return models.JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: alg,
+			Crv: curveName(k.Curve),
+			X:   encodeBase64(k.X.FillBytes(make([]byte, size))),
+			Y:   encodeBase64(k.Y.FillBytes(make([]byte, size))),
+		}, true
+	case ed25519.PublicKey:
+		return models.JWK{
+			Kty: "OKP",
+			Use: "sig",
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   encodeBase64(k),
+		}, true
+	}
+
+	return models.JWK{}, false
+}
+
+func curveName(curve elliptic.Curve) string {
+	switch curve {
+	case elliptic.P256():
+		return "P-256"
+	case elliptic.P384():
+		return "P-384"
+	case elliptic.P521():
+		return "P-521"
+	}
+	return curve.Params().Name
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,206 @@
+package auth_service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"sort"
+	"time"
+)
+
+type KeyState string
+
+const (
+	// KeyActive keys sign new tokens once activated and verify until they expire.
+	KeyActive KeyState = "active"
+	// KeyVerifyOnly keys no longer sign but still verify outstanding tokens.
+	KeyVerifyOnly KeyState = "verify-only"
+	// KeyRetired keys are neither used nor published.
+	KeyRetired KeyState = "retired"
+
+	kidHeader = "kid"
+)
+
+var (
+	NoSigningKeyErr = errors.New("no active signing key")
+	UnknownKeyErr   = errors.New("unknown signing key")
+)
+
+// Key is a signing key with its rotation schedule. Zero ActivatesAt means
+// active immediately, zero ExpiresAt means the key never expires.
+type Key struct {
+	ID          string
+	State       KeyState
+	Signer      Signer
+	ActivatesAt time.Time
+	ExpiresAt   time.Time
+}
+
+func (k *Key) activated(now time.Time) bool {
+	return k.ActivatesAt.IsZero() || !now.Before(k.ActivatesAt)
+}
+
+func (k *Key) expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+func (k *Key) canSign(now time.Time) bool {
+	return k.State == KeyActive && k.activated(now) && !k.expired(now)
+}
+
+func (k *Key) canVerify(now time.Time) bool {
+	return k.State != KeyRetired && !k.expired(now)
+}
+
+// KeyRing selects the key that signs new tokens and the key that verifies a
+// token by its kid header, so keys can be rotated without logging users out.
+type KeyRing struct {
+	keys []*Key
+	now  func() time.Time
+}
+
+func NewKeyRing(keys ...*Key) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, NoSigningKeyErr
+	}
+
+	ids := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("signing key id is required")
+		}
+		if _, ok := ids[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key id %q", key.ID)
+		}
+		ids[key.ID] = struct{}{}
+
+		switch key.State {
+		case KeyActive, KeyVerifyOnly, KeyRetired:
+		case "":
+			key.State = KeyActive
+		default:
+			return nil, fmt.Errorf("signing key %q has unknown state %q", key.ID, key.State)
+		}
+
+		if key.Signer == nil && key.State != KeyRetired {
+			return nil, fmt.Errorf("signing key %q has no signer", key.ID)
+		}
+	}
+
+	return &KeyRing{keys: keys, now: time.Now}, nil
+}
+
+// SigningKey returns the most recently activated active key.
+func (kr *KeyRing) SigningKey() (*Key, error) {
+	now := kr.now()
+
+	var current *Key
+	for _, key := range kr.keys {
+		if !key.canSign(now) {
+			continue
+		}
+		if current == nil || key.ActivatesAt.After(current.ActivatesAt) {
+			current = key
+		}
+	}
+
+	if current == nil {
+		return nil, NoSigningKeyErr
+	}
+
+	return current, nil
+}
+
+// VerificationKey returns the key named by kid. Tokens issued before kid
+// headers were introduced are checked against the current signing key.
+func (kr *KeyRing) VerificationKey(kid string) (*Key, error) {
+	if kid == "" {
+		return kr.SigningKey()
+	}
+
+	now := kr.now()
+	for _, key := range kr.keys {
+		if key.ID == kid && key.canVerify(now) {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", UnknownKeyErr, kid)
+}
+
+// JWKS publishes every non retired asymmetric key, including keys that are
+// not active yet, so consumers can cache them before the rotation happens.
+func (kr *KeyRing) JWKS() *models.JWKSet {
+	now := kr.now()
+
+	keys := make([]*Key, 0, len(kr.keys))
+	for _, key := range kr.keys {
+		if key.canVerify(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].ActivatesAt.After(keys[j].ActivatesAt)
+	})
+
+	set := &models.JWKSet{Keys: make([]models.JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, ok := publicJWK(key.Signer.PublicKey(), key.Signer.Method().Alg())
+		if !ok {
+			continue
+		}
+		jwk.Kid = key.ID
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// Algorithms lists the algorithms of the keys tokens can be signed with,
+// including keys that are not active yet.
+func (kr *KeyRing) Algorithms() []string {
+	now := kr.now()
+
+	algorithms := make([]string, 0, len(kr.keys))
+	for _, key := range kr.keys {
+		if key.State != KeyActive || key.expired(now) {
+			continue
+		}
+		alg := key.Signer.Method().Alg()
+		if !contains(algorithms, alg) {
+			algorithms = append(algorithms, alg)
+		}
+	}
+
+	return algorithms
+}
+
+func (kr *KeyRing) sign(claims jwt.Claims) (string, error) {
+	key, err := kr.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.Signer.Method(), claims)
+	token.Header[kidHeader] = key.ID
+
+	return token.SignedString(key.Signer.SignKey())
+}
+
+// keyFunc only accepts tokens signed with the selected key's algorithm, so an
+// RS256 public key can never be used as an HMAC secret.
+func (kr *KeyRing) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header[kidHeader].(string)
+
+	key, err := kr.VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Method.Alg() != key.Signer.Method().Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.Signer.VerifyKey(), nil
+}
@@ -0,0 +1,104 @@
+package auth_service
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"time"
+)
+
+type Option func(as *authService)
+
+// WithTokenFamilies sets the refresh token family store, in memory by default.
+func WithTokenFamilies(repo interfaces.TokenFamilyRepo) Option {
+	return func(as *authService) {
+		as.families = repo
+	}
+}
+
+// WithRevocations sets the revoked token store, in memory by default.
+func WithRevocations(repo interfaces.RevocationRepo) Option {
+	return func(as *authService) {
+		as.revocations = repo
+	}
+}
+
+// WithSessions sets the session store, in memory by default.
+func WithSessions(repo interfaces.SessionRepo) Option {
+	return func(as *authService) {
+		as.sessions = repo
+	}
+}
+
+// WithRoles sets the role definitions used to resolve permissions, in memory by default.
+func WithRoles(repo interfaces.RoleRepo) Option {
+	return func(as *authService) {
+		as.roles = repo
+	}
+}
+
+// WithMemberships sets the organization memberships used to resolve tenant roles, in memory by default.
+func WithMemberships(repo interfaces.MembershipRepo) Option {
+	return func(as *authService) {
+		as.memberships = repo
+	}
+}
+
+// WithSecurityEvents sets the sink for detected incidents, discarded by default.
+func WithSecurityEvents(events interfaces.SecurityEvents) Option {
+	return func(as *authService) {
+		as.events = events
+	}
+}
+
+// WithSecondFactor adds a second factor asked for at login, no user has one by default.
+func WithSecondFactor(secondFactor interfaces.SecondFactor) Option {
+	return func(as *authService) {
+		as.secondFactors = append(as.secondFactors, secondFactor)
+	}
+}
+
+// WithMFAChallenges sets the store of logins waiting for the second factor
+// and how long they wait, in memory for five minutes by default.
+func WithMFAChallenges(repo interfaces.MFAChallengeRepo, lifeTime time.Duration) Option {
+	return func(as *authService) {
+		as.challenges = repo
+		as.challengeLifeTime = lifeTime
+	}
+}
+
+// WithPersonalTokens sets the store of personal access tokens accepted in
+// place of access tokens, in memory by default.
+func WithPersonalTokens(repo interfaces.PersonalTokenRepo) Option {
+	return func(as *authService) {
+		as.personalTokens = repo
+	}
+}
+
+// WithServiceAccounts sets the store of service accounts, whose API keys and
+// assertions are accepted, in memory by default.
+func WithServiceAccounts(repo interfaces.ServiceAccountRepo) Option {
+	return func(as *authService) {
+		as.serviceAccounts = repo
+	}
+}
+
+// WithLoginThrottle sets what slows down and locks out password guessing,
+// logins are not throttled by default.
+func WithLoginThrottle(throttle interfaces.LoginThrottle) Option {
+	return func(as *authService) {
+		as.throttle = throttle
+	}
+}
+
+type nopSecurityEvents struct{}
+
+func (nopSecurityEvents) Emit(context.Context, *models.SecurityEvent) {}
+
+type nopLoginThrottle struct{}
+
+func (nopLoginThrottle) Check(context.Context, string, string) error { return nil }
+
+func (nopLoginThrottle) Failed(context.Context, string, string) error { return nil }
+
+func (nopLoginThrottle) Succeeded(context.Context, string) error { return nil }
@@ -5,90 +5,551 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
+	uuid "github.com/satori/go.uuid"
 	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
 	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"log"
+	"sort"
 	"time"
 )
 
 type JwtSettings struct {
-	SecretKey  string
+	SecretKey string
+	// AtLifeTime is the access token lifetime in minutes.
 	AtLifeTime int
+	// RtLifeTime is the refresh token lifetime in hours.
 	RtLifeTime int
+	Issuer     string
+	Audience   string
+	// Leeway is the allowed clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
+	// Keys holds the rotating signing keys. When empty a single key named
+	// "default" is built from Signer or, failing that, from SecretKey.
+	Keys   *KeyRing
+	Signer Signer
 }
 
+const defaultKeyID = "default"
+
 type authService struct {
 	jwtSettings *JwtSettings
 	repo        interfaces.UserRepo
+	families    interfaces.TokenFamilyRepo
+	revocations interfaces.RevocationRepo
+	sessions    interfaces.SessionRepo
+	roles       interfaces.RoleRepo
+	memberships interfaces.MembershipRepo
+	events      interfaces.SecurityEvents
+	// secondFactors and challenges implement the two step login of users
+	// with a second factor.
+	secondFactors     []interfaces.SecondFactor
+	challenges        interfaces.MFAChallengeRepo
+	challengeLifeTime time.Duration
+	personalTokens    interfaces.PersonalTokenRepo
+	serviceAccounts   interfaces.ServiceAccountRepo
+	throttle          interfaces.LoginThrottle
+	now               func() time.Time
 }
 
-var WrongUnameOrPassErr = errors.New("no user found with this username and password")
+var (
+	WrongUnameOrPassErr   = errors.New("no user found with this username and password")
+	NotMemberErr          = errors.New("user is not a member of the organization")
+	RefreshTokenReusedErr = models.RefreshTokenReusedErr
+	// InvalidMFAChallengeErr is returned for challenge tokens that are
+	// unknown, expired, already answered or answered wrong too often.
+	InvalidMFAChallengeErr = errors.New("mfa challenge is invalid or expired")
+	InvalidMFACodeErr      = models.InvalidMFACodeErr
+)
 
 const (
-	authorized = "authorized"
-	userId     = "user_id"
-	expired    = "expired"
-	email      = "email"
-	firstName  = "first_name"
-	lastName   = "last_name"
-	username   = "username"
+	// challengeBytes is the entropy of MFA challenge tokens.
+	challengeBytes = 32
+	// challengeAttempts bounds the codes tried against one challenge.
+	challengeAttempts        = 5
+	defaultChallengeLifeTime = 5 * time.Minute
 )
 
-func New(jwtSettings *JwtSettings, repo interfaces.UserRepo) *authService {
-	return &authService{repo: repo, jwtSettings: jwtSettings}
+func New(jwtSettings *JwtSettings, repo interfaces.UserRepo, opts ...Option) *authService {
+	if jwtSettings.Keys == nil {
+		signer := jwtSettings.Signer
+		if signer == nil {
+			signer = NewHMACSigner(jwtSettings.SecretKey)
+		}
+		jwtSettings.Keys, _ = NewKeyRing(&Key{ID: defaultKeyID, State: KeyActive, Signer: signer})
+	}
+
+	as := &authService{
+		repo:              repo,
+		jwtSettings:       jwtSettings,
+		families:          repositories.NewMemoryTokenFamilyRepo(),
+		revocations:       repositories.NewMemoryRevocationRepo(),
+		sessions:          repositories.NewMemorySessionRepo(),
+		roles:             repositories.NewMemoryRoleRepo(),
+		memberships:       repositories.NewMemoryMembershipRepo(),
+		events:            nopSecurityEvents{},
+		challenges:        repositories.NewMemoryMFAChallengeRepo(),
+		challengeLifeTime: defaultChallengeLifeTime,
+		personalTokens:    repositories.NewMemoryPersonalTokenRepo(),
+		serviceAccounts:   repositories.NewMemoryServiceAccountRepo(),
+		throttle:          nopLoginThrottle{},
+		now:               time.Now,
+	}
+	for _, opt := range opts {
+		opt(as)
+	}
+
+	return as
 }
 
-func (as *authService) Authorize(ctx context.Context, uname, pass string) (*models.TokenDetails, error) {
+// Authorize logs the user in to the tenant, empty for no organization. The
+// account is looked up among the accounts of the tenant first and among the
+// global ones second, either way it must be a member of the tenant. Users
+// with a second factor get a *models.MFARequiredError instead of tokens and
+// finish the login with AuthorizeMFA. While the account or the client
+// address backs off after failed logins the password is not checked and a
+// *models.LoginThrottledError is returned.
+func (as *authService) Authorize(ctx context.Context, tenant, uname, pass string) (*models.TokenDetails, error) {
 	ctx, span := utils.StartSpan(ctx)
 	defer span.End()
 
-	user, err := as.repo.GetByName(ctx, uname)
+	user, err := as.repo.GetByName(ctx, tenant, uname)
+	if err != nil && tenant != "" {
+		user, err = as.repo.GetByName(ctx, "", uname)
+	}
+
+	// failures are counted by account rather than by the username and
+	// tenant asked for, which would let every tenant guess at global users
+	var userID string
+	if err == nil {
+		userID = user.ID.Hex()
+	}
+	ip := utils.ClientInfo(ctx).IP
+	if err := as.throttle.Check(ctx, userID, ip); err != nil {
+		return nil, err
+	}
+
 	if err != nil {
 		log.Println(err)
+		as.loginFailed(ctx, userID, ip)
 		return nil, WrongUnameOrPassErr
 	}
 
 	err = utils.CheckPassword([]byte(pass), []byte(user.Password))
 	if err != nil {
 		log.Println(err)
+		as.loginFailed(ctx, userID, ip)
 		return nil, WrongUnameOrPassErr
 	}
 
-	token, err := createToken(user, as.jwtSettings)
+	var methods []string
+	for _, secondFactor := range as.secondFactors {
+		m, err := secondFactor.Methods(ctx, user.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("get second factor error: %w", err)
+		}
+		methods = append(methods, m...)
+	}
+	// the failed logins are kept until the second factor is answered too,
+	// else every new challenge would reset the count of wrong codes
+	if len(methods) > 0 {
+		return nil, as.challenge(ctx, user, tenant, methods)
+	}
+
+	return as.loggedIn(ctx, user, &models.Grant{Tenant: tenant})
+}
+
+// loginFailed counts a wrong username, password or second factor code, the
+// login fails either way.
+func (as *authService) loginFailed(ctx context.Context, userID, ip string) {
+	if err := as.throttle.Failed(ctx, userID, ip); err != nil {
+		log.Println(err)
+	}
+}
+
+// loggedIn starts the session of a finished login and forgets the failed
+// logins of the user.
+func (as *authService) loggedIn(ctx context.Context, user *models.User, grant *models.Grant) (*models.TokenDetails, error) {
+	td, err := as.startSession(ctx, user, grant)
+	if err != nil {
+		return nil, err
+	}
+	if err := as.throttle.Succeeded(ctx, user.ID.Hex()); err != nil {
+		log.Println(err)
+	}
+
+	return td, nil
+}
+
+// challenge records the login waiting for the second factor and returns
+// the error carrying its token.
+func (as *authService) challenge(ctx context.Context, user *models.User, tenant string, methods []string) error {
+	token, err := utils.RandomToken(challengeBytes)
 	if err != nil {
+		return fmt.Errorf("generate mfa challenge error: %w", err)
+	}
+
+	now := as.now()
+	challenge := &models.MFAChallenge{
+		ID:        utils.HashToken(token),
+		UserID:    user.ID.Hex(),
+		Tenant:    tenant,
+		Methods:   methods,
+		CreatedAt: now,
+		ExpiresAt: now.Add(as.challengeLifeTime),
+	}
+	if err := as.challenges.Create(ctx, challenge); err != nil {
+		return fmt.Errorf("create mfa challenge error: %w", err)
+	}
+
+	return &models.MFARequiredError{
+		Token:     token,
+		Methods:   methods,
+		ExpiresAt: challenge.ExpiresAt,
+	}
+}
+
+// PendingMFA returns the login of the challenge token while it waits for
+// the second factor, for second factors that need the user to answer.
+func (as *authService) PendingMFA(ctx context.Context, challengeToken string) (*models.MFAChallenge, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	challenge, err := as.challenges.Get(ctx, utils.HashToken(challengeToken))
+	if errors.Is(err, repositories.NotFoundMFAChallengeErr) {
+		return nil, InvalidMFAChallengeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get mfa challenge error: %w", err)
+	}
+	if !as.now().Before(challenge.ExpiresAt) || challenge.Attempts >= challengeAttempts {
+		return nil, InvalidMFAChallengeErr
+	}
+
+	return challenge, nil
+}
+
+// AuthorizeMFA finishes a login of Authorize with a code of the second
+// factor, any of the second factors may accept it. A challenge is answered
+// once and dropped after too many wrong codes. Wrong codes count as failed
+// logins of the user, while the account or the client address is locked out
+// a *models.LoginThrottledError is returned as Authorize does.
+func (as *authService) AuthorizeMFA(ctx context.Context, challengeToken, code string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	id := utils.HashToken(challengeToken)
+	challenge, err := as.challenges.Attempt(ctx, id)
+	if errors.Is(err, repositories.NotFoundMFAChallengeErr) {
+		return nil, InvalidMFAChallengeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get mfa challenge error: %w", err)
+	}
+	if !as.now().Before(challenge.ExpiresAt) {
+		return nil, InvalidMFAChallengeErr
+	}
+	if challenge.Attempts > challengeAttempts {
+		_, _ = as.challenges.Consume(ctx, id)
+		as.events.Emit(ctx, &models.SecurityEvent{
+			Type:   models.SecurityEventMFAAttemptsExceeded,
+			UserID: challenge.UserID,
+			Time:   as.now(),
+			Metadata: map[string]string{
+				"ip":         utils.ClientInfo(ctx).IP,
+				"user_agent": utils.ClientInfo(ctx).UserAgent,
+			},
+		})
+		return nil, InvalidMFAChallengeErr
+	}
+
+	ip := utils.ClientInfo(ctx).IP
+	if err := as.throttle.Check(ctx, challenge.UserID, ip); err != nil {
+		return nil, err
+	}
+
+	err = as.verifySecondFactor(ctx, challenge.UserID, code)
+	if errors.Is(err, InvalidMFACodeErr) {
+		as.loginFailed(ctx, challenge.UserID, ip)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// only one of concurrent answers gets the tokens
+	challenge, err = as.challenges.Consume(ctx, id)
+	if errors.Is(err, repositories.NotFoundMFAChallengeErr) {
+		return nil, InvalidMFAChallengeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("consume mfa challenge error: %w", err)
+	}
+
+	user, err := as.repo.Get(ctx, challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("get user error: %w", err)
+	}
+
+	return as.loggedIn(ctx, user, &models.Grant{Tenant: challenge.Tenant})
+}
+
+// AuthorizePasswordless logs the user in to the tenant once a passwordless
+// credential such as a passkey has proven the login. While the account or
+// the client address is locked out after failed logins a
+// *models.LoginThrottledError is returned as Authorize does.
+func (as *authService) AuthorizePasswordless(ctx context.Context, userID, tenant string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if err := as.throttle.Check(ctx, userID, utils.ClientInfo(ctx).IP); err != nil {
 		return nil, err
 	}
 
+	user, err := as.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user error: %w", err)
+	}
+
+	return as.loggedIn(ctx, user, &models.Grant{Tenant: tenant})
+}
+
+// IssueTokens starts a session of the user for an OAuth client once the
+// client has proven its grant.
+func (as *authService) IssueTokens(ctx context.Context, userID string, grant *models.Grant) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	user, err := as.repo.Get(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user error: %w", err)
+	}
+
+	return as.startSession(ctx, user, grant)
+}
+
+// IDToken issues an OpenID Connect ID token of the user for the client. It
+// expires with the access token and carries the claims of the granted scope.
+func (as *authService) IDToken(ctx context.Context, userID, clientID, scope, nonce string) (string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	user, err := as.repo.Get(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("get user error: %w", err)
+	}
+
+	now := as.now()
+	info := models.NewUserInfo(user, scope)
+	claims := &IDClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   info.Subject,
+			Issuer:    as.jwtSettings.Issuer,
+			Audience:  clientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Minute * time.Duration(as.jwtSettings.AtLifeTime)).Unix(),
+		},
+		Nonce:             nonce,
+		PreferredUsername: info.PreferredUsername,
+		GivenName:         info.GivenName,
+		FamilyName:        info.FamilyName,
+		Email:             info.Email,
+	}
+
+	token, err := as.jwtSettings.Keys.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("get id token error: %w", err)
+	}
+
 	return token, nil
 }
 
-func createToken(user *models.User, settings *JwtSettings) (td *models.TokenDetails, err error) {
+// IssueServiceToken issues an access token to a machine client that has
+// authenticated for the client_credentials grant. There is no refresh token,
+// the client authenticates again when the token expires.
+func (as *authService) IssueServiceToken(ctx context.Context, client *models.Client, scope string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	now := as.now()
+	lifeTime := time.Duration(client.TokenLifeTime) * time.Second
+	if lifeTime == 0 {
+		lifeTime = time.Minute * time.Duration(as.jwtSettings.AtLifeTime)
+	}
+
+	td := &models.TokenDetails{
+		AtExpires: now.Add(lifeTime),
+		ClientID:  client.ID,
+		Scope:     scope,
+	}
+
+	var err error
+	td.AccessToken, err = as.jwtSettings.Keys.sign(&Claims{
+		StandardClaims: as.standardClaims(client.ID, now, td.AtExpires),
+		Type:           accessTokenType,
+		PrincipalType:  models.PrincipalService,
+		ClientID:       client.ID,
+		Scope:          scope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get access token error: %w", err)
+	}
+
+	return td, nil
+}
+
+// startSession records a new login session and issues the first pair of its
+// refresh token family.
+func (as *authService) startSession(ctx context.Context, user *models.User, grant *models.Grant) (*models.TokenDetails, error) {
+	td, err := as.createToken(ctx, user, grant, primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	now := as.now()
+	err = as.families.Create(ctx, &models.TokenFamily{
+		ID:             td.FamilyID,
+		UserID:         user.ID.Hex(),
+		CurrentTokenID: td.RefreshTokenID,
+		CreatedAt:      now,
+		RotatedAt:      now,
+		ExpiresAt:      td.RtExpires,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create token family error: %w", err)
+	}
+
+	client := utils.ClientInfo(ctx)
+	err = as.sessions.Create(ctx, &models.Session{
+		ID:         td.SessionID,
+		UserID:     user.ID.Hex(),
+		FamilyID:   td.FamilyID,
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		ClientID:   grant.ClientID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  td.RtExpires,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create session error: %w", err)
+	}
+
+	return td, nil
+}
+
+func (as *authService) verifySecondFactor(ctx context.Context, userID, code string) error {
+	for _, secondFactor := range as.secondFactors {
+		err := secondFactor.Verify(ctx, userID, code)
+		if errors.Is(err, InvalidMFACodeErr) {
+			continue
+		}
+
+		return err
+	}
+
+	return InvalidMFACodeErr
+}
+
+// tenantRoles returns the global roles of the user together with the roles
+// it holds in the tenant, and the permissions they grant. Membership roles
+// only grant tenant permissions, so an organization cannot hand out access
+// beyond itself.
+func (as *authService) tenantRoles(ctx context.Context, user *models.User, tenant string) (names, permissions []string, err error) {
+	roles, err := as.roles.GetMany(ctx, user.Roles)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get roles error: %w", err)
+	}
+	if tenant == "" {
+		return user.Roles, models.RolePermissions(roles), nil
+	}
+
+	membership, err := as.memberships.Get(ctx, tenant, user.ID.Hex())
+	if errors.Is(err, repositories.NotFoundMembershipErr) {
+		return nil, nil, NotMemberErr
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("get membership error: %w", err)
+	}
+
+	memberRoles, err := as.roles.GetMany(ctx, membership.Roles)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get roles error: %w", err)
+	}
+
+	names = append([]string{}, user.Roles...)
+	for _, role := range membership.Roles {
+		if !contains(names, role) {
+			names = append(names, role)
+		}
+	}
+
+	permissions = models.RolePermissions(roles)
+	for _, permission := range models.RolePermissions(memberRoles) {
+		if models.IsTenantPermission(permission) && !contains(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	sort.Strings(permissions)
+
+	return names, permissions, nil
+}
+
+func (as *authService) createToken(ctx context.Context, user *models.User, grant *models.Grant, familyID, sessionID string) (td *models.TokenDetails, err error) {
+	names, permissions, err := as.tenantRoles(ctx, user, grant.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	// an OAuth client gets only the permissions the user granted it as
+	// scopes, roles are not passed on as role based rules would bypass them
+	if grant.ClientID != "" {
+		names, permissions = nil, scopedPermissions(permissions, grant.Scope)
+	}
+
+	now := as.now()
 	td = &models.TokenDetails{
-		AtExpires: time.Now().Add(time.Minute * time.Duration(settings.AtLifeTime)),
-		RtExpires: time.Now().Add(time.Hour * time.Duration(settings.RtLifeTime)),
+		AtExpires: now.Add(time.Minute * time.Duration(as.jwtSettings.AtLifeTime)),
+		RtExpires: now.Add(time.Hour * time.Duration(as.jwtSettings.RtLifeTime)),
+		FamilyID:  familyID,
+		SessionID: sessionID,
+		ClientID:  grant.ClientID,
+		Scope:     grant.Scope,
 	}
 
-	atClaims := jwt.MapClaims{}
-	atClaims[authorized] = true
-	atClaims[userId] = user.ID
-	atClaims[username] = user.Username
-	atClaims[firstName] = user.FirstName
-	atClaims[email] = user.Email
-	atClaims[lastName] = user.LastName
-	atClaims[expired] = time.Now().Add(time.Minute * 15).Unix()
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
-	td.AccessToken, err = at.SignedString([]byte(settings.SecretKey))
+	atClaims := &Claims{
+		StandardClaims: as.standardClaims(user.ID.Hex(), now, td.AtExpires),
+		Type:           accessTokenType,
+		SessionID:      sessionID,
+		Tenant:         grant.Tenant,
+		ClientID:       grant.ClientID,
+		Scope:          grant.Scope,
+		Authorized:     true,
+		UserID:         user.ID.Hex(),
+		Username:       user.Username,
+		Email:          user.Email,
+		FirstName:      user.FirstName,
+		LastName:       user.LastName,
+		Roles:          names,
+		Permissions:    permissions,
+	}
+	td.AccessToken, err = as.jwtSettings.Keys.sign(atClaims)
 	if err != nil {
 		return nil, fmt.Errorf("get access token error: %w", err)
 	}
 
-	rtClaims := jwt.MapClaims{}
-	rtClaims[userId] = user.ID
-	rtClaims[expired] = time.Now().Add(time.Hour * 24 * 7).Unix()
-	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, rtClaims)
-	td.RefreshToken, err = rt.SignedString([]byte(settings.SecretKey))
+	rtClaims := &Claims{
+		StandardClaims: as.standardClaims(user.ID.Hex(), now, td.RtExpires),
+		Type:           refreshTokenType,
+		SessionID:      sessionID,
+		Tenant:         grant.Tenant,
+		ClientID:       grant.ClientID,
+		Scope:          grant.Scope,
+		UserID:         user.ID.Hex(),
+		Family:         familyID,
+	}
+	td.RefreshTokenID = rtClaims.Id
+	td.RefreshToken, err = as.jwtSettings.Keys.sign(rtClaims)
 	if err != nil {
 		return nil, fmt.Errorf("get refresh token error: %w", err)
 	}
@@ -96,87 +557,384 @@ func createToken(user *models.User, settings *JwtSettings) (td *models.TokenDeta
 	return
 }
 
-func (as *authService) VerifyToken(ctx context.Context, tokens *models.TokenPair) (*models.TokenPair, error) {
+func (as *authService) standardClaims(subject string, now, expiresAt time.Time) jwt.StandardClaims {
+	return jwt.StandardClaims{
+		Id:        uuid.NewV4().String(),
+		Subject:   subject,
+		Issuer:    as.jwtSettings.Issuer,
+		Audience:  as.jwtSettings.Audience,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+}
+
+// VerifyToken returns the pair unchanged while both tokens are valid and
+// issues a new pair when the access token has expired but the refresh token
+// is still valid.
+func (as *authService) VerifyToken(ctx context.Context, tokens *models.TokenPair) (*models.TokenDetails, error) {
 	ctx, span := utils.StartSpan(ctx)
 	defer span.End()
 
-	at, err := parseToken(tokens.AccessToken, as.jwtSettings.SecretKey)
+	at, atErr := as.verifyClaims(ctx, tokens.AccessToken, accessTokenType)
+	if atErr != nil && !errors.Is(atErr, TokenExpiredErr) {
+		return nil, atErr
+	}
+
+	rt, err := as.verifyClaims(ctx, tokens.RefreshToken, refreshTokenType)
 	if err != nil {
+		// the access token is the one that matters to the caller
+		if atErr != nil {
+			return nil, atErr
+		}
 		return nil, err
 	}
 
-	if !at.Valid {
-		return as.newPairToken(ctx, tokens.AccessToken)
+	if atErr != nil {
+		return as.rotate(ctx, rt)
 	}
 
-	rt, err := parseToken(tokens.RefreshToken, as.jwtSettings.SecretKey)
+	return &models.TokenDetails{
+		AccessToken:    tokens.AccessToken,
+		RefreshToken:   tokens.RefreshToken,
+		AtExpires:      time.Unix(at.ExpiresAt, 0),
+		RtExpires:      time.Unix(rt.ExpiresAt, 0),
+		RefreshTokenID: rt.Id,
+		FamilyID:       rt.Family,
+		SessionID:      rt.SessionID,
+		ClientID:       rt.ClientID,
+		Scope:          rt.Scope,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new pair. Tokens issued to an
+// OAuth client are refused, the client refreshes them with RefreshClient.
+func (as *authService) Refresh(ctx context.Context, refreshToken string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	rt, err := as.verifyClaims(ctx, refreshToken, refreshTokenType)
 	if err != nil {
 		return nil, err
 	}
-
-	if !rt.Valid {
-		return as.newPairToken(ctx, tokens.RefreshToken)
+	if rt.ClientID != "" {
+		return nil, fmt.Errorf("%w: issued to an OAuth client", InvalidTokenErr)
 	}
 
-	return &models.TokenPair{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
-	}, nil
+	return as.rotate(ctx, rt)
 }
 
-func (as *authService) newPairToken(ctx context.Context, token string) (*models.TokenPair, error) {
-	user, _, err := as.ParseToken(ctx, token)
+// RefreshClient exchanges a refresh token issued to the OAuth client for a
+// new pair, the client must have authenticated already.
+func (as *authService) RefreshClient(ctx context.Context, clientID, refreshToken string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	rt, err := as.verifyClaims(ctx, refreshToken, refreshTokenType)
 	if err != nil {
 		return nil, err
 	}
+	if rt.ClientID == "" || rt.ClientID != clientID {
+		return nil, fmt.Errorf("%w: issued to another client", InvalidTokenErr)
+	}
+
+	return as.rotate(ctx, rt)
+}
 
-	td, err := createToken(user, as.jwtSettings)
+// rotate issues the next pair of the refresh token family. A refresh token
+// that is not the latest of its family has been used before, most likely by
+// an attacker holding a stolen copy, so the whole family is revoked.
+func (as *authService) rotate(ctx context.Context, rt *Claims) (*models.TokenDetails, error) {
+	family, err := as.families.Get(ctx, rt.Family)
+	if err != nil || family.Revoked() || family.UserID != rt.Subject {
+		return nil, InvalidTokenErr
+	}
+
+	user, err := as.repo.Get(ctx, rt.Subject)
+	if err != nil {
+		return nil, InvalidTokenErr
+	}
+
+	// members removed from the organization lose it with their next refresh
+	td, err := as.createToken(ctx, user, &models.Grant{Tenant: rt.Tenant, ClientID: rt.ClientID, Scope: rt.Scope}, family.ID, rt.SessionID)
+	if errors.Is(err, NotMemberErr) {
+		return nil, InvalidTokenErr
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.TokenPair{
-		AccessToken:  td.AccessToken,
-		RefreshToken: td.RefreshToken,
-	}, nil
+	rotated, err := as.families.Rotate(ctx, family.ID, rt.Id, td.RefreshTokenID, td.RtExpires)
+	if err != nil {
+		return nil, fmt.Errorf("rotate token family error: %w", err)
+	}
+
+	if !rotated {
+		if err := as.families.Revoke(ctx, family.ID); err != nil {
+			return nil, fmt.Errorf("revoke token family error: %w", err)
+		}
+
+		as.events.Emit(ctx, &models.SecurityEvent{
+			Type:   models.SecurityEventRefreshTokenReuse,
+			UserID: family.UserID,
+			Time:   as.now(),
+			Metadata: map[string]string{
+				"family_id": family.ID,
+				"token_id":  rt.Id,
+			},
+		})
+
+		return nil, RefreshTokenReusedErr
+	}
+
+	if rt.SessionID != "" {
+		if err := as.sessions.Touch(ctx, rt.SessionID, as.now(), td.RtExpires); err != nil {
+			return nil, fmt.Errorf("touch session error: %w", err)
+		}
+	}
+
+	return td, nil
 }
 
-func parseToken(token, secretKey string) (*jwt.Token, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// Logout revokes both tokens server side and ends the session with its
+// refresh token family, so it can no longer be used anywhere. Tokens that fail signature
+// verification are ignored as they are rejected anyway.
+func (as *authService) Logout(ctx context.Context, tokens *models.TokenPair) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if claims, _ := as.parseClaims(tokens.AccessToken, accessTokenType); claims != nil {
+		if err := as.revoke(ctx, claims); err != nil {
+			return err
 		}
-		return []byte(secretKey), nil
-	})
+	}
+
+	if claims, _ := as.parseClaims(tokens.RefreshToken, refreshTokenType); claims != nil {
+		if err := as.revoke(ctx, claims); err != nil {
+			return err
+		}
+
+		if claims.Family != "" {
+			if err := as.families.Revoke(ctx, claims.Family); err != nil {
+				return fmt.Errorf("revoke token family error: %w", err)
+			}
+		}
+
+		if claims.SessionID != "" {
+			if err := as.sessions.Revoke(ctx, claims.SessionID); err != nil {
+				return fmt.Errorf("revoke session error: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (as *authService) revoke(ctx context.Context, claims *Claims) error {
+	if claims.Id == "" {
+		return nil
+	}
+
+	err := as.revocations.Revoke(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0).Add(as.jwtSettings.Leeway))
+	if err != nil {
+		return fmt.Errorf("revoke token error: %w", err)
+	}
+
+	return nil
+}
+
+// verifyClaims parses the token and rejects it when the token itself or its
+// whole session has been revoked.
+func (as *authService) verifyClaims(ctx context.Context, tokenString, tokenType string) (*Claims, error) {
+	claims, err := as.parseClaims(tokenString, tokenType)
 	if err != nil {
 		return nil, err
 	}
-	return t, nil
+
+	ids := []string{claims.Id}
+	if claims.SessionID != "" {
+		ids = append(ids, claims.SessionID)
+	}
+
+	revoked, err := as.revocations.IsRevoked(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("check revocation error: %w", err)
+	}
+	if revoked {
+		return nil, TokenRevokedErr
+	}
+
+	return claims, nil
 }
-func (as *authService) ParseToken(ctx context.Context, tokenString string) (*models.User, bool, error) {
+
+// parseClaims checks the signature and the registered claims of a token of
+// the given type. Claims are returned along with a validation error when the
+// signature is valid, so callers can still identify the token.
+func (as *authService) parseClaims(tokenString, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	_, err := parser.ParseWithClaims(tokenString, claims, as.jwtSettings.Keys.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidTokenErr, err)
+	}
+
+	if err := claims.validate(as.jwtSettings, tokenType, as.now()); err != nil {
+		return claims, err
+	}
+
+	return claims, nil
+}
+
+// JWKS returns the public keys consumers can use to verify tokens offline.
+func (as *authService) JWKS(ctx context.Context) *models.JWKSet {
+	_, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return as.jwtSettings.Keys.JWKS()
+}
+
+// Introspect describes a token as RFC 7662 requires. The hint only selects
+// which token type is tried first. Tokens that are expired, revoked or
+// invalid are reported inactive, errors are returned for lookup failures only.
+func (as *authService) Introspect(ctx context.Context, token, tokenTypeHint string) (*models.Introspection, error) {
 	ctx, span := utils.StartSpan(ctx)
 	defer span.End()
 
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	types := []string{accessTokenType, refreshTokenType}
+	if tokenTypeHint == "refresh_token" {
+		types = []string{refreshTokenType, accessTokenType}
+	}
+
+	for _, tokenType := range types {
+		claims, err := as.verifyClaims(ctx, token, tokenType)
+		if errors.Is(err, TokenExpiredErr) || errors.Is(err, InvalidTokenErr) {
+			continue
 		}
-		return []byte(as.jwtSettings.SecretKey), nil
-	})
-	if err != nil || !token.Valid {
+		if err != nil {
+			return nil, err
+		}
+
+		return &models.Introspection{
+			Active:    true,
+			Subject:   claims.Subject,
+			Username:  claims.Username,
+			ExpiresAt: claims.ExpiresAt,
+			IssuedAt:  claims.IssuedAt,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			Tenant:    claims.Tenant,
+			TokenType: tokenType + "_token",
+		}, nil
+	}
+
+	return &models.Introspection{Active: false}, nil
+}
+
+// ParseToken returns the user of an access token, service tokens are
+// rejected as invalid so they are never taken for a user. Service accounts
+// are returned as users whose Type tells them apart.
+func (as *authService) ParseToken(ctx context.Context, tokenString string) (*models.User, bool, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	claims, err := as.verifyClaims(ctx, tokenString, accessTokenType)
+	if err != nil {
 		return nil, false, err
 	}
+	if claims.service() {
+		return nil, false, fmt.Errorf("%w: service token", InvalidTokenErr)
+	}
+	if claims.serviceAccount() {
+		if _, err := as.activeServiceAccount(ctx, claims.Subject); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return userFromClaims(claims), true, nil
+}
+
+// VerifyAccessToken identifies the caller of an access token presented
+// without its refresh token, a user, a service account or a machine client.
+// Expired user tokens are not rotated. Personal access tokens and service
+// account API keys are accepted as well.
+func (as *authService) VerifyAccessToken(ctx context.Context, tokenString string) (*models.Principal, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if isPersonalToken(tokenString) {
+		return as.verifyPersonalToken(ctx, tokenString)
+	}
+	if isServiceAccountKey(tokenString) {
+		return as.verifyServiceAccountKey(ctx, tokenString)
+	}
+
+	claims, err := as.verifyClaims(ctx, tokenString, accessTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.service() {
+		return &models.Principal{
+			Type: models.PrincipalService,
+			Service: &models.ServicePrincipal{
+				ClientID: claims.ClientID,
+				Scope:    claims.Scope,
+			},
+		}, nil
+	}
+
+	if claims.serviceAccount() {
+		if _, err := as.activeServiceAccount(ctx, claims.Subject); err != nil {
+			return nil, err
+		}
+		return &models.Principal{Type: models.PrincipalServiceAccount, User: userFromClaims(claims)}, nil
+	}
+
+	return &models.Principal{
+		Type:      models.PrincipalUser,
+		User:      userFromClaims(claims),
+		ClientID:  claims.ClientID,
+		SessionID: claims.SessionID,
+	}, nil
+}
+
+func userFromClaims(claims *Claims) *models.User {
+	id, _ := primitive.ObjectIDFromHex(claims.Subject)
+	principalType := models.PrincipalUser
+	if claims.serviceAccount() {
+		principalType = models.PrincipalServiceAccount
+	}
 
-	stringId := fmt.Sprintf("%v", claims[userId])
-	id, _ := primitive.ObjectIDFromHex(stringId)
-	user := &models.User{
-		ID:        id,
-		Username:  fmt.Sprintf("%v", claims[username]),
-		Email:     fmt.Sprintf("%v", claims[email]),
-		FirstName: fmt.Sprintf("%v", claims[firstName]),
-		LastName:  fmt.Sprintf("%v", claims[lastName]),
+	return &models.User{
+		ID:          id,
+		Username:    claims.Username,
+		Email:       claims.Email,
+		FirstName:   claims.FirstName,
+		LastName:    claims.LastName,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Tenant:      claims.Tenant,
+		Type:        principalType,
+	}
+}
+
+func scopedPermissions(permissions []string, scope string) []string {
+	var scoped []string
+	for _, permission := range permissions {
+		if models.ScopeContains(scope, permission) {
+			scoped = append(scoped, permission)
+		}
+	}
+
+	return scoped
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
 
-	return user, true, nil
+	return false
 }
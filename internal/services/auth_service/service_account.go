@@ -0,0 +1,234 @@
+package auth_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"strings"
+	"time"
+)
+
+const (
+	// assertionMaxLifeTime bounds how far in the future an assertion may
+	// expire, RFC 7523 3 asks servers to limit the window a stolen assertion
+	// can be replayed in.
+	assertionMaxLifeTime = time.Hour
+	// tokenEndpointPath is accepted as assertion audience next to the issuer.
+	tokenEndpointPath = "/oauth/token"
+)
+
+// assertionClaims are the claims of an RFC 7523 assertion, aud may be a
+// string or an array as RFC 7519 allows.
+type assertionClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// Valid is a no-op, assertions are checked by IssueServiceAccountToken.
+func (c *assertionClaims) Valid() error {
+	return nil
+}
+
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+
+	return nil
+}
+
+// IssueServiceAccountToken exchanges an RFC 7523 JWT bearer assertion for an
+// access token of a service account. The account signs the assertion itself
+// with one of its registered keys named by kid, so iss and sub are both the
+// account id. The audience is the issuer or its token endpoint. Assertions
+// carrying a jti are accepted once. There is no refresh token, the account
+// signs a new assertion when the token expires.
+func (as *authService) IssueServiceAccountToken(ctx context.Context, assertion string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+
+	// the account is looked up before the signature is checked, since the
+	// key depends on it, nothing else of the claims is trusted until then
+	claims := &assertionClaims{}
+	if _, _, err := parser.ParseUnverified(assertion, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidTokenErr, err)
+	}
+
+	account, err := as.activeServiceAccount(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = parser.ParseWithClaims(assertion, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header[kidHeader].(string)
+		key, ok := account.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return ParsePublicKey([]byte(key.PublicKey))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidTokenErr, err)
+	}
+
+	now := as.now()
+	if err := as.validateAssertion(claims, now); err != nil {
+		return nil, err
+	}
+
+	if claims.ID != "" {
+		replayID := "assertion:" + claims.Subject + ":" + claims.ID
+		used, err := as.revocations.IsRevoked(ctx, replayID)
+		if err != nil {
+			return nil, fmt.Errorf("check assertion replay error: %w", err)
+		}
+		if used {
+			return nil, fmt.Errorf("%w: assertion has been used before", InvalidTokenErr)
+		}
+
+		expiresAt := time.Unix(claims.ExpiresAt, 0).Add(as.jwtSettings.Leeway)
+		if err := as.revocations.Revoke(ctx, replayID, expiresAt); err != nil {
+			return nil, fmt.Errorf("record assertion error: %w", err)
+		}
+	}
+
+	roles, err := as.roles.GetMany(ctx, account.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("get roles error: %w", err)
+	}
+
+	td := &models.TokenDetails{
+		AtExpires: now.Add(time.Minute * time.Duration(as.jwtSettings.AtLifeTime)),
+	}
+	td.AccessToken, err = as.jwtSettings.Keys.sign(&Claims{
+		StandardClaims: as.standardClaims(account.ID.Hex(), now, td.AtExpires),
+		Type:           accessTokenType,
+		PrincipalType:  models.PrincipalServiceAccount,
+		Username:       account.Name,
+		Roles:          account.Roles,
+		Permissions:    models.RolePermissions(roles),
+		Tenant:         account.Tenant(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get access token error: %w", err)
+	}
+
+	return td, nil
+}
+
+// validateAssertion checks the registered claims of an assertion allowing
+// the configured clock skew.
+func (as *authService) validateAssertion(claims *assertionClaims, now time.Time) error {
+	leeway := int64(as.jwtSettings.Leeway / time.Second)
+	unix := now.Unix()
+
+	if claims.Issuer != claims.Subject {
+		return fmt.Errorf("%w: assertion must be issued by the service account", InvalidTokenErr)
+	}
+	if claims.ExpiresAt == 0 || unix > claims.ExpiresAt+leeway {
+		return fmt.Errorf("%w: assertion has expired", InvalidTokenErr)
+	}
+	if claims.ExpiresAt-unix > int64(assertionMaxLifeTime/time.Second)+leeway {
+		return fmt.Errorf("%w: assertion expires too far in the future", InvalidTokenErr)
+	}
+	if (claims.NotBefore != 0 && unix < claims.NotBefore-leeway) || (claims.IssuedAt != 0 && unix < claims.IssuedAt-leeway) {
+		return fmt.Errorf("%w: assertion is not valid yet", InvalidTokenErr)
+	}
+	if !as.acceptsAudience(claims.Audience) {
+		return fmt.Errorf("%w: assertion is not meant for this server", InvalidTokenErr)
+	}
+
+	return nil
+}
+
+// acceptsAudience accepts the issuer and its token endpoint, any audience
+// when no issuer is configured.
+func (as *authService) acceptsAudience(aud audience) bool {
+	issuer := strings.TrimSuffix(as.jwtSettings.Issuer, "/")
+	for _, a := range aud {
+		if issuer == "" || a == issuer || a == issuer+"/" || a == issuer+tokenEndpointPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyServiceAccountKey identifies the service account of an API key. The
+// roles are read when the key is used, so role changes apply at once.
+func (as *authService) verifyServiceAccountKey(ctx context.Context, key string) (*models.Principal, error) {
+	account, err := as.serviceAccounts.GetByAPIKey(ctx, utils.HashToken(key))
+	if errors.Is(err, repositories.NotFoundServiceAccountErr) {
+		return nil, fmt.Errorf("%w: unknown service account key", InvalidTokenErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get service account error: %w", err)
+	}
+	if account.Disabled {
+		return nil, fmt.Errorf("%w: service account is disabled", InvalidTokenErr)
+	}
+
+	roles, err := as.roles.GetMany(ctx, account.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("get roles error: %w", err)
+	}
+
+	return &models.Principal{
+		Type: models.PrincipalServiceAccount,
+		User: &models.User{
+			ID:          account.ID,
+			Username:    account.Name,
+			Roles:       account.Roles,
+			Permissions: models.RolePermissions(roles),
+			Tenant:      account.Tenant(),
+			Type:        models.PrincipalServiceAccount,
+		},
+	}, nil
+}
+
+// activeServiceAccount returns the account unless it is unknown or has been
+// disabled, tokens of a disabled account stop working at once.
+func (as *authService) activeServiceAccount(ctx context.Context, id string) (*models.ServiceAccount, error) {
+	account, err := as.serviceAccounts.Get(ctx, id)
+	if errors.Is(err, repositories.NotFoundServiceAccountErr) {
+		return nil, fmt.Errorf("%w: unknown service account", InvalidTokenErr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get service account error: %w", err)
+	}
+	if account.Disabled {
+		return nil, fmt.Errorf("%w: service account is disabled", InvalidTokenErr)
+	}
+
+	return account, nil
+}
+
+func isServiceAccountKey(tokenString string) bool {
+	return strings.HasPrefix(tokenString, models.ServiceAccountKeyPrefix)
+}
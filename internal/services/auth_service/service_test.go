@@ -2,14 +2,29 @@ package auth_service_test
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
 	"gitlab.com/g6834/team17/auth-service/internal/repositories"
 	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/lockout_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 var (
@@ -42,11 +57,11 @@ func TestUnitTestSuite(t *testing.T) {
 func (u *unitTestSuit) TestAuthorizeSuccess() {
 	r := new(repositories.MockUserRepository)
 
-	r.On("GetByName", userName).Return(&user)
+	r.On("GetByName", "", userName).Return(&user)
 
 	as := auth_service.New(&jwtSettings, r)
 
-	tokens, err := as.Authorize(context.Background(), userName, userPassword)
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
 
 	u.Nil(err, "error must be nil")
 	u.NotNil(tokens, "tokens must not be nil")
@@ -59,11 +74,11 @@ func (u *unitTestSuit) TestAuthorizeSuccess() {
 func (u *unitTestSuit) TestAuthorizeWrongUsername() {
 	r := new(repositories.MockUserRepository)
 
-	r.On("GetByName", userName).Return(nil, errors.New(""))
+	r.On("GetByName", "", userName).Return(nil, errors.New(""))
 
 	as := auth_service.New(&jwtSettings, r)
 
-	tokens, err := as.Authorize(context.Background(), userName, userPassword)
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
 
 	u.Nil(tokens, "tokens must be nil")
 	u.NotNil(err, "error must be not nil")
@@ -75,11 +90,11 @@ func (u *unitTestSuit) TestAuthorizeWrongUsername() {
 func (u *unitTestSuit) TestAuthorizeWrongPass() {
 	r := new(repositories.MockUserRepository)
 
-	r.On("GetByName", userName).Return(&user)
+	r.On("GetByName", "", userName).Return(&user)
 
 	as := auth_service.New(&jwtSettings, r)
 
-	tokens, err := as.Authorize(context.Background(), userName, userPassword+"x")
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword+"x")
 
 	u.Nil(tokens, "tokens must be nil")
 	u.NotNil(err, "error must be not nil")
@@ -88,14 +103,81 @@ func (u *unitTestSuit) TestAuthorizeWrongPass() {
 	r.AssertExpectations(u.T())
 }
 
+func (u *unitTestSuit) TestAuthorizeLockout() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("GetByName", "", "unknown").Return(nil, repositories.NotFoundUserErr)
+
+	attempts := repositories.NewMemoryLoginAttemptRepo()
+	lockout := lockout_service.New(attempts, &recordedEvents{}, lockout_service.Settings{
+		UserThreshold:   2,
+		IPThreshold:     3,
+		LockoutDuration: time.Hour,
+		Window:          time.Hour,
+	})
+	as := auth_service.New(&jwtSettings, r, auth_service.WithLoginThrottle(lockout))
+
+	ctx := context.WithValue(context.Background(), constants.CTX_CLIENT_INFO, models.ClientInfo{IP: "192.168.0.1"})
+	for i := 0; i < 2; i++ {
+		_, err := as.Authorize(ctx, "", userName, userPassword+"x")
+		u.ErrorIs(err, auth_service.WrongUnameOrPassErr)
+	}
+
+	// the right password is refused without being checked
+	_, err := as.Authorize(context.Background(), "", userName, userPassword)
+	var throttled *models.LoginThrottledError
+	u.Require().ErrorAs(err, &throttled)
+	u.True(throttled.Locked)
+
+	u.Require().NoError(lockout.Unlock(context.Background(), models.LoginAttemptsID(models.LoginAttemptUser, user.ID.Hex())))
+	_, err = as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	// unknown usernames count against the address
+	_, err = as.Authorize(ctx, "", "unknown", userPassword)
+	u.ErrorIs(err, auth_service.WrongUnameOrPassErr)
+	_, err = as.Authorize(ctx, "", userName, userPassword)
+	u.Require().ErrorAs(err, &throttled, "the address has failed three times")
+	_, err = as.Authorize(context.Background(), "", userName, userPassword)
+	u.NoError(err, "logins from other addresses go on")
+}
+
+func (u *unitTestSuit) TestAuthorizePasswordlessLockout() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	lockout := lockout_service.New(repositories.NewMemoryLoginAttemptRepo(), &recordedEvents{}, lockout_service.Settings{
+		UserThreshold:   2,
+		LockoutDuration: time.Hour,
+		Window:          time.Hour,
+	})
+	as := auth_service.New(&jwtSettings, r, auth_service.WithLoginThrottle(lockout))
+
+	for i := 0; i < 2; i++ {
+		_, err := as.Authorize(context.Background(), "", userName, userPassword+"x")
+		u.ErrorIs(err, auth_service.WrongUnameOrPassErr)
+	}
+
+	_, err := as.AuthorizePasswordless(context.Background(), user.ID.Hex(), "")
+	var throttled *models.LoginThrottledError
+	u.Require().ErrorAs(err, &throttled, "a passkey must not get around the lockout")
+	u.True(throttled.Locked)
+
+	u.Require().NoError(lockout.Unlock(context.Background(), models.LoginAttemptsID(models.LoginAttemptUser, user.ID.Hex())))
+	td, err := as.AuthorizePasswordless(context.Background(), user.ID.Hex(), "")
+	u.Require().NoError(err)
+	u.NotEmpty(td.AccessToken)
+}
+
 func (u *unitTestSuit) TestVerifyTokenSuccess() {
 	r := new(repositories.MockUserRepository)
 
-	r.On("GetByName", userName).Return(&user)
+	r.On("GetByName", "", userName).Return(&user)
 
 	as := auth_service.New(&jwtSettings, r)
 
-	tokens, err := as.Authorize(context.Background(), userName, userPassword)
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
 	u.Nil(err, "error must be nil")
 
 	pair, err := as.VerifyToken(context.Background(), &models.TokenPair{
@@ -114,11 +196,11 @@ func (u *unitTestSuit) TestVerifyTokenSuccess() {
 func (u *unitTestSuit) TestVerifyTokenWrongTokens() {
 	r := new(repositories.MockUserRepository)
 
-	r.On("GetByName", userName).Return(&user)
+	r.On("GetByName", "", userName).Return(&user)
 
 	as := auth_service.New(&jwtSettings, r)
 
-	tokens, err := as.Authorize(context.Background(), userName, userPassword)
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
 	u.Nil(err, "error must be nil")
 
 	t, err := as.VerifyToken(context.Background(), &models.TokenPair{
@@ -141,11 +223,11 @@ func (u *unitTestSuit) TestVerifyTokenWrongTokens() {
 func (u *unitTestSuit) TestParseTokenSuccess() {
 	r := new(repositories.MockUserRepository)
 
-	r.On("GetByName", userName).Return(&user)
+	r.On("GetByName", "", userName).Return(&user)
 
 	as := auth_service.New(&jwtSettings, r)
 
-	tokens, err := as.Authorize(context.Background(), userName, userPassword)
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
 	u.Nil(err, "error must be nil")
 
 	us, ok, err := as.ParseToken(context.Background(), tokens.AccessToken)
@@ -175,3 +257,1036 @@ func (u *unitTestSuit) TestParseTokenFailed() {
 
 	r.AssertExpectations(u.T())
 }
+
+func (u *unitTestSuit) writeKey(key crypto.Signer) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	u.Require().NoError(err)
+
+	path := filepath.Join(u.T().TempDir(), "key.pem")
+	err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	u.Require().NoError(err)
+
+	return path
+}
+
+func (u *unitTestSuit) TestAsymmetricSigners() {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	u.Require().NoError(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	u.Require().NoError(err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	u.Require().NoError(err)
+
+	cases := map[string]crypto.Signer{
+		"RS256": rsaKey,
+		"ES256": ecKey,
+		"EdDSA": edKey,
+	}
+
+	for alg, key := range cases {
+		signer, err := auth_service.NewSigner(alg, "", u.writeKey(key))
+		u.Require().NoError(err, alg)
+
+		r := new(repositories.MockUserRepository)
+		r.On("GetByName", "", userName).Return(&user)
+
+		as := auth_service.New(&auth_service.JwtSettings{AtLifeTime: 5, RtLifeTime: 5, Signer: signer}, r)
+
+		tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+		u.Require().NoError(err, alg)
+
+		us, ok, err := as.ParseToken(context.Background(), tokens.AccessToken)
+		u.NoError(err, alg)
+		u.True(ok, alg)
+		u.Equal(user.Username, us.Username, alg)
+
+		jwks := as.JWKS(context.Background())
+		u.Len(jwks.Keys, 1, alg)
+		u.Equal(alg, jwks.Keys[0].Alg)
+
+		hmac := auth_service.New(&jwtSettings, r)
+		_, ok, err = hmac.ParseToken(context.Background(), tokens.AccessToken)
+		u.Error(err, "token signed with %s must be rejected by HS256 service", alg)
+		u.False(ok)
+	}
+}
+
+func (u *unitTestSuit) TestSignerKeyMismatch() {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	u.Require().NoError(err)
+
+	_, err = auth_service.NewSigner("RS256", "", u.writeKey(ecKey))
+	u.ErrorIs(err, auth_service.KeyMismatchErr)
+
+	_, err = auth_service.NewSigner("ES384", "", u.writeKey(ecKey))
+	u.ErrorIs(err, auth_service.KeyMismatchErr)
+
+	_, err = auth_service.NewSigner("XX256", "", "")
+	u.ErrorIs(err, auth_service.UnsupportedAlgorithmErr)
+}
+
+func (u *unitTestSuit) TestJWKSEmptyForHMAC() {
+	as := auth_service.New(&jwtSettings, new(repositories.MockUserRepository))
+
+	jwks := as.JWKS(context.Background())
+
+	u.NotNil(jwks.Keys)
+	u.Len(jwks.Keys, 0, "shared secret must never be published")
+}
+
+func tokenKid(token string) string {
+	t, _, _ := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if t == nil {
+		return ""
+	}
+	kid, _ := t.Header["kid"].(string)
+	return kid
+}
+
+func (u *unitTestSuit) newKeyRingService(r *repositories.MockUserRepository, keys ...*auth_service.Key) interface {
+	Authorize(ctx context.Context, tenant, uname, pass string) (*models.TokenDetails, error)
+	ParseToken(ctx context.Context, tokenString string) (*models.User, bool, error)
+} {
+	ring, err := auth_service.NewKeyRing(keys...)
+	u.Require().NoError(err)
+
+	return auth_service.New(&auth_service.JwtSettings{AtLifeTime: 5, RtLifeTime: 5, Keys: ring}, r)
+}
+
+func (u *unitTestSuit) TestKeyRotation() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+
+	oldSigner := auth_service.NewHMACSigner("old secret")
+	newSigner := auth_service.NewHMACSigner("new secret")
+
+	// the new key is scheduled but not activated yet
+	before := u.newKeyRingService(r,
+		&auth_service.Key{ID: "old", State: auth_service.KeyActive, Signer: oldSigner, ActivatesAt: time.Now().Add(-time.Hour)},
+		&auth_service.Key{ID: "new", State: auth_service.KeyActive, Signer: newSigner, ActivatesAt: time.Now().Add(time.Hour)},
+	)
+	oldTokens, err := before.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+	u.Equal("old", tokenKid(oldTokens.AccessToken))
+
+	// after the rotation the old key only verifies
+	after := u.newKeyRingService(r,
+		&auth_service.Key{ID: "old", State: auth_service.KeyVerifyOnly, Signer: oldSigner},
+		&auth_service.Key{ID: "new", State: auth_service.KeyActive, Signer: newSigner, ActivatesAt: time.Now().Add(-time.Minute)},
+	)
+	newTokens, err := after.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+	u.Equal("new", tokenKid(newTokens.AccessToken))
+
+	_, ok, err := after.ParseToken(context.Background(), oldTokens.AccessToken)
+	u.NoError(err, "tokens signed with verify-only key must stay valid")
+	u.True(ok)
+
+	// once retired the old tokens are rejected
+	retired := u.newKeyRingService(r,
+		&auth_service.Key{ID: "old", State: auth_service.KeyRetired},
+		&auth_service.Key{ID: "new", State: auth_service.KeyActive, Signer: newSigner},
+	)
+	_, ok, err = retired.ParseToken(context.Background(), oldTokens.AccessToken)
+	u.Error(err, "retired key must not verify")
+	u.False(ok)
+
+	_, ok, err = retired.ParseToken(context.Background(), newTokens.AccessToken)
+	u.NoError(err)
+	u.True(ok)
+}
+
+func (u *unitTestSuit) TestKeyRingExpiredKey() {
+	r := new(repositories.MockUserRepository)
+
+	as := u.newKeyRingService(r,
+		&auth_service.Key{ID: "expired", State: auth_service.KeyActive, Signer: auth_service.NewHMACSigner("x"), ExpiresAt: time.Now().Add(-time.Minute)},
+	)
+
+	r.On("GetByName", "", userName).Return(&user)
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+
+	u.Nil(tokens)
+	u.ErrorIs(err, auth_service.NoSigningKeyErr)
+}
+
+func (u *unitTestSuit) TestKeyRingValidation() {
+	signer := auth_service.NewHMACSigner("x")
+
+	_, err := auth_service.NewKeyRing()
+	u.ErrorIs(err, auth_service.NoSigningKeyErr)
+
+	_, err = auth_service.NewKeyRing(&auth_service.Key{ID: "a", Signer: signer}, &auth_service.Key{ID: "a", Signer: signer})
+	u.Error(err, "duplicate key ids must be rejected")
+
+	_, err = auth_service.NewKeyRing(&auth_service.Key{ID: "a", State: "unknown", Signer: signer})
+	u.Error(err, "unknown state must be rejected")
+}
+
+func signClaims(claims *auth_service.Claims) string {
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	t.Header["kid"] = "default"
+	token, _ := t.SignedString([]byte(jwtSettings.SecretKey))
+	return token
+}
+
+func testClaims(tokenType string, issued time.Time, lifetime time.Duration) *auth_service.Claims {
+	return &auth_service.Claims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        "jti",
+			Subject:   user.ID.Hex(),
+			IssuedAt:  issued.Unix(),
+			NotBefore: issued.Unix(),
+			ExpiresAt: issued.Add(lifetime).Unix(),
+		},
+		Type:     tokenType,
+		Username: user.Username,
+	}
+}
+
+func (u *unitTestSuit) TestRegisteredClaims() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+
+	settings := jwtSettings
+	settings.Issuer = "auth-service"
+	settings.Audience = "team17"
+	as := auth_service.New(&settings, r)
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	claims := &auth_service.Claims{}
+	_, _, err = new(jwt.Parser).ParseUnverified(tokens.AccessToken, claims)
+	u.Require().NoError(err)
+
+	u.Equal("auth-service", claims.Issuer)
+	u.Equal("team17", claims.Audience)
+	u.Equal(user.ID.Hex(), claims.Subject)
+	u.NotEmpty(claims.Id)
+	u.NotZero(claims.IssuedAt)
+	u.NotZero(claims.NotBefore)
+	u.Equal(tokens.AtExpires.Unix(), claims.ExpiresAt, "exp must follow AtLifeTime")
+	u.WithinDuration(time.Now().Add(5*time.Minute), tokens.AtExpires, time.Second)
+	u.WithinDuration(time.Now().Add(5*time.Hour), tokens.RtExpires, time.Second)
+
+	other := jwtSettings
+	other.Audience = "someone-else"
+	_, ok, err := auth_service.New(&other, r).ParseToken(context.Background(), tokens.AccessToken)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "audience must be enforced")
+	u.False(ok)
+
+	_, ok, err = as.ParseToken(context.Background(), tokens.RefreshToken)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "refresh token must not be accepted as access token")
+	u.False(ok)
+}
+
+func (u *unitTestSuit) TestParseTokenExpired() {
+	as := auth_service.New(&jwtSettings, new(repositories.MockUserRepository))
+
+	expired := signClaims(testClaims("access", time.Now().Add(-time.Hour), time.Minute))
+	_, ok, err := as.ParseToken(context.Background(), expired)
+
+	u.ErrorIs(err, auth_service.TokenExpiredErr)
+	u.False(ok)
+}
+
+func (u *unitTestSuit) TestParseTokenLeeway() {
+	settings := jwtSettings
+	settings.Leeway = time.Minute
+	as := auth_service.New(&settings, new(repositories.MockUserRepository))
+
+	// expired 30 seconds ago, still within the leeway
+	token := signClaims(testClaims("access", time.Now().Add(-90*time.Second), time.Minute))
+	_, ok, err := as.ParseToken(context.Background(), token)
+	u.NoError(err)
+	u.True(ok)
+
+	// not valid for another 30 seconds, still within the leeway
+	token = signClaims(testClaims("access", time.Now().Add(30*time.Second), time.Minute))
+	_, ok, err = as.ParseToken(context.Background(), token)
+	u.NoError(err)
+	u.True(ok)
+
+	token = signClaims(testClaims("access", time.Now().Add(5*time.Minute), time.Minute))
+	_, ok, err = as.ParseToken(context.Background(), token)
+	u.ErrorIs(err, auth_service.InvalidTokenErr)
+	u.False(ok)
+}
+
+func (u *unitTestSuit) TestVerifyTokenRefreshesExpiredAccessToken() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	as := auth_service.New(&jwtSettings, r)
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	pair, err := as.VerifyToken(context.Background(), &models.TokenPair{
+		AccessToken:  signClaims(testClaims("access", time.Now().Add(-time.Hour), time.Minute)),
+		RefreshToken: tokens.RefreshToken,
+	})
+	u.Require().NoError(err)
+	u.NotEqual(tokens.RefreshToken, pair.RefreshToken, "refresh token must be rotated")
+
+	us, ok, err := as.ParseToken(context.Background(), pair.AccessToken)
+	u.NoError(err)
+	u.True(ok)
+	u.Equal(user.Username, us.Username)
+
+	_, err = as.VerifyToken(context.Background(), &models.TokenPair{
+		AccessToken:  signClaims(testClaims("access", time.Now().Add(-time.Hour), time.Minute)),
+		RefreshToken: signClaims(testClaims("refresh", time.Now().Add(-time.Hour), time.Minute)),
+	})
+	u.ErrorIs(err, auth_service.TokenExpiredErr, "expired refresh token must not issue a new pair")
+
+	r.AssertExpectations(u.T())
+}
+
+type recordedEvents struct {
+	events []*models.SecurityEvent
+}
+
+func (r *recordedEvents) Emit(_ context.Context, event *models.SecurityEvent) {
+	r.events = append(r.events, event)
+}
+
+func (u *unitTestSuit) TestRefreshTokenReuseRevokesFamily() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	events := &recordedEvents{}
+	families := repositories.NewMemoryTokenFamilyRepo()
+	as := auth_service.New(&jwtSettings, r,
+		auth_service.WithTokenFamilies(families),
+		auth_service.WithSecurityEvents(events),
+	)
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	expiredAt := signClaims(testClaims("access", time.Now().Add(-time.Hour), time.Minute))
+	rotated, err := as.VerifyToken(context.Background(), &models.TokenPair{
+		AccessToken:  expiredAt,
+		RefreshToken: tokens.RefreshToken,
+	})
+	u.Require().NoError(err)
+
+	// the original refresh token is replayed
+	_, err = as.VerifyToken(context.Background(), &models.TokenPair{
+		AccessToken:  expiredAt,
+		RefreshToken: tokens.RefreshToken,
+	})
+	u.ErrorIs(err, auth_service.RefreshTokenReusedErr)
+	u.Len(events.events, 1, "reuse must emit a security event")
+	u.Equal(models.SecurityEventRefreshTokenReuse, events.events[0].Type)
+	u.Equal(user.ID.Hex(), events.events[0].UserID)
+
+	// the legitimate holder is logged out as well
+	_, err = as.VerifyToken(context.Background(), &models.TokenPair{
+		AccessToken:  expiredAt,
+		RefreshToken: rotated.RefreshToken,
+	})
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "revoked family must not issue new pairs")
+}
+
+func (u *unitTestSuit) TestRefresh() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	as := auth_service.New(&jwtSettings, r)
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	rotated, err := as.Refresh(context.Background(), tokens.RefreshToken)
+	u.Require().NoError(err)
+	u.NotEqual(tokens.RefreshToken, rotated.RefreshToken)
+	u.Equal(tokens.FamilyID, rotated.FamilyID, "refresh must stay in the family")
+
+	_, ok, err := as.ParseToken(context.Background(), rotated.AccessToken)
+	u.NoError(err)
+	u.True(ok)
+
+	_, err = as.Refresh(context.Background(), tokens.RefreshToken)
+	u.ErrorIs(err, auth_service.RefreshTokenReusedErr)
+	u.ErrorIs(err, auth_service.InvalidTokenErr)
+}
+
+func (u *unitTestSuit) TestRefreshRejectsTokens() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+
+	as := auth_service.New(&jwtSettings, r)
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	_, err = as.Refresh(context.Background(), tokens.AccessToken)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "access tokens must not refresh")
+
+	expired := signClaims(testClaims("refresh", time.Now().Add(-time.Hour), time.Minute))
+	_, err = as.Refresh(context.Background(), expired)
+	u.ErrorIs(err, auth_service.TokenExpiredErr)
+}
+
+func (u *unitTestSuit) TestIntrospect() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+
+	as := auth_service.New(&jwtSettings, r)
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	info, err := as.Introspect(context.Background(), tokens.AccessToken, "")
+	u.Require().NoError(err)
+	u.True(info.Active)
+	u.Equal(user.ID.Hex(), info.Subject)
+	u.Equal(userName, info.Username)
+	u.Equal(tokens.AtExpires.Unix(), info.ExpiresAt)
+	u.NotZero(info.IssuedAt)
+	u.Equal("access_token", info.TokenType)
+
+	info, err = as.Introspect(context.Background(), tokens.RefreshToken, "access_token")
+	u.Require().NoError(err)
+	u.True(info.Active, "a wrong hint must not hide the token")
+	u.Equal("refresh_token", info.TokenType)
+
+	expired := signClaims(testClaims("access", time.Now().Add(-time.Hour), time.Minute))
+	for _, token := range []string{expired, "garbage"} {
+		info, err = as.Introspect(context.Background(), token, "")
+		u.NoError(err)
+		u.Equal(&models.Introspection{Active: false}, info)
+	}
+
+	err = as.Logout(context.Background(), &models.TokenPair{AccessToken: tokens.AccessToken})
+	u.Require().NoError(err)
+	info, err = as.Introspect(context.Background(), tokens.AccessToken, "")
+	u.NoError(err)
+	u.False(info.Active, "revoked tokens must be inactive")
+}
+
+func (u *unitTestSuit) TestLogoutRevokesTokens() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+
+	revocations := repositories.NewMemoryRevocationRepo()
+	as := auth_service.New(&jwtSettings, r, auth_service.WithRevocations(revocations))
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	pair := &models.TokenPair{AccessToken: tokens.AccessToken, RefreshToken: tokens.RefreshToken}
+	_, err = as.VerifyToken(context.Background(), pair)
+	u.Require().NoError(err)
+
+	err = as.Logout(context.Background(), pair)
+	u.Require().NoError(err)
+
+	_, ok, err := as.ParseToken(context.Background(), tokens.AccessToken)
+	u.ErrorIs(err, auth_service.TokenRevokedErr)
+	u.False(ok)
+
+	_, err = as.VerifyToken(context.Background(), pair)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "revoked tokens must not validate")
+
+	// other logins of the same user stay valid
+	other, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+	_, ok, err = as.ParseToken(context.Background(), other.AccessToken)
+	u.NoError(err)
+	u.True(ok)
+}
+
+func (u *unitTestSuit) TestAuthorizeCreatesSession() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	sessions := repositories.NewMemorySessionRepo()
+	revocations := repositories.NewMemoryRevocationRepo()
+	as := auth_service.New(&jwtSettings, r,
+		auth_service.WithSessions(sessions),
+		auth_service.WithRevocations(revocations),
+	)
+
+	ctx := context.WithValue(context.Background(), constants.CTX_CLIENT_INFO, models.ClientInfo{
+		IP:        "192.168.0.1",
+		UserAgent: "test-agent",
+	})
+	tokens, err := as.Authorize(ctx, "", userName, userPassword)
+	u.Require().NoError(err)
+	u.NotEmpty(tokens.SessionID)
+
+	list, err := sessions.GetByUser(context.Background(), user.ID.Hex())
+	u.Require().NoError(err)
+	u.Require().Len(list, 1)
+	u.Equal(tokens.SessionID, list[0].ID)
+	u.Equal(tokens.FamilyID, list[0].FamilyID)
+	u.Equal("192.168.0.1", list[0].IP)
+	u.Equal("test-agent", list[0].UserAgent)
+
+	rotated, err := as.Refresh(context.Background(), tokens.RefreshToken)
+	u.Require().NoError(err)
+	u.Equal(tokens.SessionID, rotated.SessionID, "refresh must stay in the session")
+
+	// revoking the session id rejects every token of the session
+	err = revocations.Revoke(context.Background(), tokens.SessionID, time.Now().Add(time.Hour))
+	u.Require().NoError(err)
+
+	_, _, err = as.ParseToken(context.Background(), rotated.AccessToken)
+	u.ErrorIs(err, auth_service.TokenRevokedErr)
+	_, err = as.Refresh(context.Background(), rotated.RefreshToken)
+	u.ErrorIs(err, auth_service.TokenRevokedErr)
+}
+
+func (u *unitTestSuit) TestTokenCarriesPermissions() {
+	admin := user
+	admin.Roles = []string{"admin", "auditor", "removed"}
+
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&admin)
+
+	roles := repositories.NewMemoryRoleRepo(
+		&models.Role{Name: "admin", Permissions: []string{models.PermissionUsersWrite, models.PermissionUsersRead}},
+		&models.Role{Name: "auditor", Permissions: []string{models.PermissionUsersRead}},
+	)
+	as := auth_service.New(&jwtSettings, r, auth_service.WithRoles(roles))
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+
+	parsed, ok, err := as.ParseToken(context.Background(), tokens.AccessToken)
+	u.Require().NoError(err)
+	u.True(ok)
+	u.Equal(admin.Roles, parsed.Roles)
+	u.Equal([]string{models.PermissionUsersRead, models.PermissionUsersWrite}, parsed.Permissions,
+		"permissions must be merged and unknown roles ignored")
+	u.True(parsed.HasPermission(models.PermissionUsersWrite))
+	u.False(parsed.HasPermission(models.PermissionUsersDelete))
+}
+
+func (u *unitTestSuit) TestClientTokenScopedPermissions() {
+	admin := user
+	admin.Roles = []string{"admin"}
+
+	r := new(repositories.MockUserRepository)
+	r.On("Get", admin.ID.Hex()).Return(&admin)
+
+	roles := repositories.NewMemoryRoleRepo(
+		&models.Role{Name: "admin", Permissions: []string{models.PermissionUsersWrite, models.PermissionUsersRead}},
+	)
+	as := auth_service.New(&jwtSettings, r, auth_service.WithRoles(roles))
+
+	tokens, err := as.IssueTokens(context.Background(), admin.ID.Hex(), &models.Grant{ClientID: "app", Scope: "openid profile users:read"})
+	u.Require().NoError(err)
+
+	parsed, _, err := as.ParseToken(context.Background(), tokens.AccessToken)
+	u.Require().NoError(err)
+	u.Empty(parsed.Roles, "roles would bypass the scope")
+	u.Equal([]string{models.PermissionUsersRead}, parsed.Permissions, "only permissions granted as scopes")
+
+	principal, err := as.VerifyAccessToken(context.Background(), tokens.AccessToken)
+	u.Require().NoError(err)
+	u.Equal("app", principal.ClientID, "account routes tell delegated tokens apart")
+
+	_, err = as.Refresh(context.Background(), tokens.RefreshToken)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "client tokens are refreshed by their client only")
+	_, err = as.RefreshClient(context.Background(), "other", tokens.RefreshToken)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "tokens of another client are refused")
+
+	rotated, err := as.RefreshClient(context.Background(), "app", tokens.RefreshToken)
+	u.Require().NoError(err)
+	parsed, _, err = as.ParseToken(context.Background(), rotated.AccessToken)
+	u.Require().NoError(err)
+	u.Equal([]string{models.PermissionUsersRead}, parsed.Permissions, "refresh must keep the limit")
+
+	tokens, err = as.IssueTokens(context.Background(), admin.ID.Hex(), &models.Grant{ClientID: "app", Scope: "openid profile"})
+	u.Require().NoError(err)
+	parsed, _, err = as.ParseToken(context.Background(), tokens.AccessToken)
+	u.Require().NoError(err)
+	u.Empty(parsed.Permissions)
+}
+
+func (u *unitTestSuit) TestAuthorizeTenant() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "acme", userName).Return(nil, repositories.NotFoundUserErr)
+	r.On("GetByName", "globex", userName).Return(nil, repositories.NotFoundUserErr)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	roles := repositories.NewMemoryRoleRepo(
+		&models.Role{Name: "org-admin", Permissions: []string{models.PermissionMembersWrite, models.PermissionUsersCreate}},
+		&models.Role{Name: "admin", Permissions: []string{models.PermissionUsersWrite}},
+	)
+	memberships := repositories.NewMemoryMembershipRepo(
+		&models.Membership{OrganizationID: "acme", UserID: user.ID, Roles: []string{"admin", "org-admin"}},
+	)
+	as := auth_service.New(&jwtSettings, r, auth_service.WithRoles(roles), auth_service.WithMemberships(memberships))
+
+	_, err := as.Authorize(context.Background(), "globex", userName, userPassword)
+	u.ErrorIs(err, auth_service.NotMemberErr)
+
+	tokens, err := as.Authorize(context.Background(), "acme", userName, userPassword)
+	u.Require().NoError(err)
+
+	parsed, _, err := as.ParseToken(context.Background(), tokens.AccessToken)
+	u.Require().NoError(err)
+	u.Equal("acme", parsed.Tenant)
+	u.Equal([]string{"admin", "org-admin"}, parsed.Roles)
+	u.True(parsed.HasPermission(models.PermissionMembersWrite))
+	u.True(parsed.HasPermission(models.PermissionUsersCreate), "org admins create accounts of the organization")
+	u.False(parsed.HasPermission(models.PermissionUsersWrite), "membership roles only grant tenant permissions")
+
+	rotated, err := as.Refresh(context.Background(), tokens.RefreshToken)
+	u.Require().NoError(err)
+	parsed, _, err = as.ParseToken(context.Background(), rotated.AccessToken)
+	u.Require().NoError(err)
+	u.Equal("acme", parsed.Tenant, "refresh must keep the tenant")
+
+	err = memberships.Delete(context.Background(), "acme", user.ID.Hex())
+	u.Require().NoError(err)
+
+	_, err = as.Refresh(context.Background(), rotated.RefreshToken)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "removed members must not refresh")
+}
+
+func (u *unitTestSuit) TestLogoutIgnoresInvalidTokens() {
+	as := auth_service.New(&jwtSettings, new(repositories.MockUserRepository))
+
+	err := as.Logout(context.Background(), &models.TokenPair{AccessToken: "garbage"})
+
+	u.NoError(err)
+}
+
+func (u *unitTestSuit) TestServiceToken() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	as := auth_service.New(&jwtSettings, r)
+	client := &models.Client{ID: "billing", TokenLifeTime: 60}
+
+	td, err := as.IssueServiceToken(context.Background(), client, "invoices:read")
+	u.Require().NoError(err)
+	u.Empty(td.RefreshToken, "machine clients authenticate again instead of refreshing")
+	u.WithinDuration(time.Now().Add(time.Minute), td.AtExpires, time.Second)
+
+	principal, err := as.VerifyAccessToken(context.Background(), td.AccessToken)
+	u.Require().NoError(err)
+	u.Equal(models.PrincipalService, principal.Type)
+	u.Nil(principal.User)
+	u.Equal(&models.ServicePrincipal{ClientID: "billing", Scope: "invoices:read"}, principal.Service)
+
+	_, _, err = as.ParseToken(context.Background(), td.AccessToken)
+	u.ErrorIs(err, models.InvalidTokenErr, "a service token must never be taken for a user")
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().NoError(err)
+	principal, err = as.VerifyAccessToken(context.Background(), tokens.AccessToken)
+	u.Require().NoError(err)
+	u.Equal(models.PrincipalUser, principal.Type)
+	u.Equal(userName, principal.User.Username)
+
+	_, err = as.VerifyAccessToken(context.Background(), tokens.RefreshToken)
+	u.ErrorIs(err, models.InvalidTokenErr)
+}
+
+// staticSecondFactor accepts the one code for every user, as TOTP unless
+// another method is given.
+type staticSecondFactor struct {
+	code   string
+	method string
+}
+
+func (f staticSecondFactor) Methods(context.Context, string) ([]string, error) {
+	if f.method != "" {
+		return []string{f.method}, nil
+	}
+
+	return []string{models.MFAMethodTOTP}, nil
+}
+
+func (f staticSecondFactor) Verify(_ context.Context, _, code string) error {
+	if code != f.code {
+		return models.InvalidMFACodeErr
+	}
+
+	return nil
+}
+
+func (u *unitTestSuit) TestAuthorizeMFA() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	as := auth_service.New(&jwtSettings, r, auth_service.WithSecondFactor(staticSecondFactor{code: "123456"}))
+
+	tokens, err := as.Authorize(context.Background(), "", userName, userPassword)
+	u.Nil(tokens, "no tokens before the second factor")
+	var mfaRequired *models.MFARequiredError
+	u.Require().ErrorAs(err, &mfaRequired)
+	u.NotEmpty(mfaRequired.Token)
+	u.Equal([]string{models.MFAMethodTOTP}, mfaRequired.Methods)
+	u.WithinDuration(time.Now().Add(5*time.Minute), mfaRequired.ExpiresAt, time.Second)
+
+	_, err = as.Authorize(context.Background(), "", userName, userPassword+"x")
+	u.ErrorIs(err, auth_service.WrongUnameOrPassErr, "password is checked first")
+
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "000000")
+	u.ErrorIs(err, auth_service.InvalidMFACodeErr)
+	_, err = as.AuthorizeMFA(context.Background(), "unknown", "123456")
+	u.ErrorIs(err, auth_service.InvalidMFAChallengeErr)
+
+	tokens, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "123456")
+	u.Require().NoError(err)
+	parsed, ok, err := as.ParseToken(context.Background(), tokens.AccessToken)
+	u.Require().NoError(err)
+	u.True(ok)
+	u.Equal(userName, parsed.Username)
+
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "123456")
+	u.ErrorIs(err, auth_service.InvalidMFAChallengeErr, "a challenge is answered once")
+}
+
+func (u *unitTestSuit) TestAuthorizeMFASecondFactors() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	as := auth_service.New(&jwtSettings, r,
+		auth_service.WithSecondFactor(staticSecondFactor{code: "123456"}),
+		auth_service.WithSecondFactor(staticSecondFactor{code: "assertion", method: models.MFAMethodWebAuthn}),
+	)
+
+	_, err := as.Authorize(context.Background(), "", userName, userPassword)
+	var mfaRequired *models.MFARequiredError
+	u.Require().ErrorAs(err, &mfaRequired)
+	u.Equal([]string{models.MFAMethodTOTP, models.MFAMethodWebAuthn}, mfaRequired.Methods)
+
+	challenge, err := as.PendingMFA(context.Background(), mfaRequired.Token)
+	u.Require().NoError(err)
+	u.Equal(user.ID.Hex(), challenge.UserID)
+	u.Zero(challenge.Attempts, "looking at the challenge is no attempt")
+	_, err = as.PendingMFA(context.Background(), "unknown")
+	u.ErrorIs(err, auth_service.InvalidMFAChallengeErr)
+
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "assertion")
+	u.Require().NoError(err, "any second factor may answer")
+
+	_, err = as.PendingMFA(context.Background(), mfaRequired.Token)
+	u.ErrorIs(err, auth_service.InvalidMFAChallengeErr, "answered challenges are not pending")
+}
+
+func (u *unitTestSuit) TestAuthorizeMFAAttempts() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+
+	events := &recordedEvents{}
+	as := auth_service.New(&jwtSettings, r,
+		auth_service.WithSecondFactor(staticSecondFactor{code: "123456"}),
+		auth_service.WithSecurityEvents(events),
+	)
+
+	_, err := as.Authorize(context.Background(), "", userName, userPassword)
+	var mfaRequired *models.MFARequiredError
+	u.Require().ErrorAs(err, &mfaRequired)
+
+	for i := 0; i < 5; i++ {
+		_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "000000")
+		u.ErrorIs(err, auth_service.InvalidMFACodeErr)
+	}
+
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "123456")
+	u.ErrorIs(err, auth_service.InvalidMFAChallengeErr, "challenge is dropped after too many wrong codes")
+	u.Require().Len(events.events, 1)
+	u.Equal(models.SecurityEventMFAAttemptsExceeded, events.events[0].Type)
+	u.Equal(user.ID.Hex(), events.events[0].UserID)
+}
+
+func (u *unitTestSuit) TestAuthorizeMFALockout() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	lockout := lockout_service.New(repositories.NewMemoryLoginAttemptRepo(), &recordedEvents{}, lockout_service.Settings{
+		UserThreshold:   3,
+		LockoutDuration: time.Hour,
+		Window:          time.Hour,
+	})
+	as := auth_service.New(&jwtSettings, r,
+		auth_service.WithSecondFactor(staticSecondFactor{code: "123456"}),
+		auth_service.WithLoginThrottle(lockout),
+	)
+
+	// a new challenge with the right password does not forget wrong codes
+	var mfaRequired *models.MFARequiredError
+	for i := 0; i < 3; i++ {
+		_, err := as.Authorize(context.Background(), "", userName, userPassword)
+		u.Require().ErrorAs(err, &mfaRequired)
+		_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "000000")
+		u.ErrorIs(err, auth_service.InvalidMFACodeErr)
+	}
+
+	_, err := as.Authorize(context.Background(), "", userName, userPassword)
+	var throttled *models.LoginThrottledError
+	u.Require().ErrorAs(err, &throttled, "wrong codes count against the account")
+	u.True(throttled.Locked)
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "123456")
+	u.Require().ErrorAs(err, &throttled, "pending challenges are locked out too")
+
+	u.Require().NoError(lockout.Unlock(context.Background(), models.LoginAttemptsID(models.LoginAttemptUser, user.ID.Hex())))
+	_, err = as.Authorize(context.Background(), "", userName, userPassword)
+	u.Require().ErrorAs(err, &mfaRequired)
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "000000")
+	u.ErrorIs(err, auth_service.InvalidMFACodeErr)
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "123456")
+	u.Require().NoError(err)
+
+	locked, err := lockout.Locked(context.Background())
+	u.Require().NoError(err)
+	u.Empty(locked)
+	for i := 0; i < 2; i++ {
+		_, err = as.Authorize(context.Background(), "", userName, userPassword+"x")
+		u.ErrorIs(err, auth_service.WrongUnameOrPassErr)
+	}
+	_, err = as.Authorize(context.Background(), "", userName, userPassword)
+	u.ErrorAs(err, &mfaRequired, "the finished login forgot the wrong code")
+}
+
+func (u *unitTestSuit) TestAuthorizeMFAExpired() {
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", userName).Return(&user)
+
+	as := auth_service.New(&jwtSettings, r,
+		auth_service.WithSecondFactor(staticSecondFactor{code: "123456"}),
+		auth_service.WithMFAChallenges(repositories.NewMemoryMFAChallengeRepo(), -time.Second),
+	)
+
+	_, err := as.Authorize(context.Background(), "", userName, userPassword)
+	var mfaRequired *models.MFARequiredError
+	u.Require().ErrorAs(err, &mfaRequired)
+
+	_, err = as.AuthorizeMFA(context.Background(), mfaRequired.Token, "123456")
+	u.ErrorIs(err, auth_service.InvalidMFAChallengeErr)
+}
+
+func (u *unitTestSuit) TestPersonalToken() {
+	admin := user
+	admin.Roles = []string{"admin"}
+
+	r := new(repositories.MockUserRepository)
+	r.On("Get", admin.ID.Hex()).Return(&admin)
+
+	roles := repositories.NewMemoryRoleRepo(
+		&models.Role{Name: "admin", Permissions: []string{models.PermissionUsersWrite, models.PermissionUsersRead}},
+	)
+	tokens := repositories.NewMemoryPersonalTokenRepo()
+	as := auth_service.New(&jwtSettings, r, auth_service.WithRoles(roles), auth_service.WithPersonalTokens(tokens))
+
+	secret := models.PersonalTokenPrefix + "3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+	u.Require().NoError(tokens.Create(context.Background(), &models.PersonalAccessToken{
+		ID:        "62b1b6c3f0e1a2b3c4d5e6f7",
+		UserID:    admin.ID.Hex(),
+		Hash:      utils.HashToken(secret),
+		Scopes:    []string{models.PermissionUsersRead, models.PermissionRolesWrite},
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	principal, err := as.VerifyAccessToken(context.Background(), secret)
+	u.Require().NoError(err)
+	u.Equal(models.PrincipalUser, principal.Type)
+	u.Equal("62b1b6c3f0e1a2b3c4d5e6f7", principal.PersonalTokenID)
+	u.Equal(admin.ID, principal.User.ID)
+	u.Equal([]string{models.PermissionUsersRead}, principal.User.Permissions,
+		"permissions must be those of the scopes the user holds")
+	u.Empty(principal.User.Roles, "roles would bypass the scopes")
+
+	u.Eventually(func() bool {
+		stored, err := tokens.GetByHash(context.Background(), utils.HashToken(secret))
+		return err == nil && !stored.LastUsedAt.IsZero()
+	}, time.Second, 10*time.Millisecond, "last use must be recorded")
+
+	_, err = as.VerifyAccessToken(context.Background(), secret+"x")
+	u.ErrorIs(err, auth_service.InvalidTokenErr)
+
+	_, _, err = as.ParseToken(context.Background(), secret)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "personal access tokens are no JWTs")
+
+	u.Require().NoError(tokens.Delete(context.Background(), admin.ID.Hex(), "62b1b6c3f0e1a2b3c4d5e6f7"))
+	_, err = as.VerifyAccessToken(context.Background(), secret)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "revoked token")
+}
+
+func (u *unitTestSuit) TestPersonalTokenTenant() {
+	r := new(repositories.MockUserRepository)
+	r.On("Get", user.ID.Hex()).Return(&user)
+
+	memberships := repositories.NewMemoryMembershipRepo(
+		&models.Membership{OrganizationID: "acme", UserID: user.ID, Roles: []string{"org-admin"}},
+	)
+	tokens := repositories.NewMemoryPersonalTokenRepo()
+	as := auth_service.New(&jwtSettings, r, auth_service.WithMemberships(memberships), auth_service.WithPersonalTokens(tokens))
+
+	secret := models.PersonalTokenPrefix + "tenant"
+	u.Require().NoError(tokens.Create(context.Background(), &models.PersonalAccessToken{
+		ID:        "62b1b6c3f0e1a2b3c4d5e6f8",
+		UserID:    user.ID.Hex(),
+		Tenant:    "acme",
+		Hash:      utils.HashToken(secret),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	principal, err := as.VerifyAccessToken(context.Background(), secret)
+	u.Require().NoError(err)
+	u.Equal("acme", principal.User.Tenant)
+
+	u.Require().NoError(memberships.Delete(context.Background(), "acme", user.ID.Hex()))
+	_, err = as.VerifyAccessToken(context.Background(), secret)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "removed members must not use their tokens")
+}
+
+func (u *unitTestSuit) newServiceAccount(accounts *repositories.MemoryServiceAccountRepo) *models.ServiceAccount {
+	account := &models.ServiceAccount{
+		ID:        primitive.NewObjectID(),
+		Name:      "billing-sync",
+		OwnerType: models.OwnerOrganization,
+		OwnerID:   "acme",
+		Roles:     []string{"admin"},
+	}
+	u.Require().NoError(accounts.Create(context.Background(), account))
+
+	return account
+}
+
+func (u *unitTestSuit) TestServiceAccountAPIKey() {
+	roles := repositories.NewMemoryRoleRepo(
+		&models.Role{Name: "admin", Permissions: []string{models.PermissionUsersRead}},
+	)
+	accounts := repositories.NewMemoryServiceAccountRepo()
+	as := auth_service.New(&jwtSettings, new(repositories.MockUserRepository), auth_service.WithRoles(roles), auth_service.WithServiceAccounts(accounts))
+	account := u.newServiceAccount(accounts)
+
+	secret := models.ServiceAccountKeyPrefix + "3q2-7wEjLz0AMbX4nQ0ZUmK2pVb6tYd8fGhJkLmNoPq"
+	u.Require().NoError(accounts.AddAPIKey(context.Background(), account.ID.Hex(), &models.ServiceAccountAPIKey{
+		ID:   "62b1b6c3f0e1a2b3c4d5e6fa",
+		Hash: utils.HashToken(secret),
+	}))
+
+	principal, err := as.VerifyAccessToken(context.Background(), secret)
+	u.Require().NoError(err)
+	u.Equal(models.PrincipalServiceAccount, principal.Type)
+	u.True(principal.User.IsServiceAccount(), "service accounts must be told apart from humans")
+	u.Equal(account.ID, principal.User.ID)
+	u.Equal("acme", principal.User.Tenant)
+	u.Equal([]string{models.PermissionUsersRead}, principal.User.Permissions)
+
+	_, err = as.VerifyAccessToken(context.Background(), secret+"x")
+	u.ErrorIs(err, auth_service.InvalidTokenErr)
+
+	u.Require().NoError(accounts.SetDisabled(context.Background(), account.ID.Hex(), true, time.Now()))
+	_, err = as.VerifyAccessToken(context.Background(), secret)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "disabled accounts must not use their keys")
+}
+
+func signAssertion(key crypto.Signer, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+	assertion, _ := token.SignedString(key)
+
+	return assertion
+}
+
+func (u *unitTestSuit) TestServiceAccountAssertion() {
+	settings := jwtSettings
+	settings.Issuer = "https://auth.example.com"
+	settings.Keys = nil
+
+	roles := repositories.NewMemoryRoleRepo(
+		&models.Role{Name: "admin", Permissions: []string{models.PermissionUsersRead}},
+	)
+	accounts := repositories.NewMemoryServiceAccountRepo()
+	as := auth_service.New(&settings, new(repositories.MockUserRepository), auth_service.WithRoles(roles), auth_service.WithServiceAccounts(accounts))
+	account := u.newServiceAccount(accounts)
+	id := account.ID.Hex()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	u.Require().NoError(err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	u.Require().NoError(err)
+	u.Require().NoError(accounts.AddKey(context.Background(), id, &models.ServiceAccountKey{
+		ID:        "k1",
+		Algorithm: "ES256",
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}))
+
+	claims := func(mutate func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss": id,
+			"sub": id,
+			"aud": []string{"https://auth.example.com/oauth/token"},
+			"exp": time.Now().Add(5 * time.Minute).Unix(),
+			"jti": primitive.NewObjectID().Hex(),
+		}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+
+	assertion := signAssertion(key, "k1", claims(nil))
+	td, err := as.IssueServiceAccountToken(context.Background(), assertion)
+	u.Require().NoError(err)
+	u.Empty(td.RefreshToken, "service accounts sign a new assertion instead of refreshing")
+
+	principal, err := as.VerifyAccessToken(context.Background(), td.AccessToken)
+	u.Require().NoError(err)
+	u.Equal(models.PrincipalServiceAccount, principal.Type)
+	u.True(principal.User.IsServiceAccount())
+	u.Equal(account.ID, principal.User.ID)
+	u.Equal("billing-sync", principal.User.Username)
+	u.Equal("acme", principal.User.Tenant)
+	u.Equal([]string{"admin"}, principal.User.Roles)
+	u.Equal([]string{models.PermissionUsersRead}, principal.User.Permissions)
+
+	parsed, _, err := as.ParseToken(context.Background(), td.AccessToken)
+	u.Require().NoError(err)
+	u.True(parsed.IsServiceAccount())
+
+	_, err = as.IssueServiceAccountToken(context.Background(), assertion)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "an assertion is accepted once")
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	u.Require().NoError(err)
+
+	rejected := map[string]string{
+		"unknown key":      signAssertion(key, "k2", claims(nil)),
+		"wrong key":        signAssertion(other, "k1", claims(nil)),
+		"other issuer":     signAssertion(key, "k1", claims(func(c jwt.MapClaims) { c["iss"] = "someone" })),
+		"other audience":   signAssertion(key, "k1", claims(func(c jwt.MapClaims) { c["aud"] = "https://other.example.com" })),
+		"expired":          signAssertion(key, "k1", claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })),
+		"too long lived":   signAssertion(key, "k1", claims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(2 * time.Hour).Unix() })),
+		"no expiry":        signAssertion(key, "k1", claims(func(c jwt.MapClaims) { delete(c, "exp") })),
+		"unknown account":  signAssertion(key, "k1", claims(func(c jwt.MapClaims) { c["iss"], c["sub"] = "x", "x" })),
+		"not a jwt at all": "assertion",
+	}
+	for name, assertion := range rejected {
+		_, err := as.IssueServiceAccountToken(context.Background(), assertion)
+		u.ErrorIs(err, auth_service.InvalidTokenErr, name)
+	}
+
+	_, err = as.IssueServiceAccountToken(context.Background(), signAssertion(key, "k1", claims(func(c jwt.MapClaims) {
+		c["aud"] = "https://auth.example.com"
+	})))
+	u.NoError(err, "the issuer is a valid audience")
+
+	u.Require().NoError(accounts.SetDisabled(context.Background(), id, true, time.Now()))
+	_, err = as.VerifyAccessToken(context.Background(), td.AccessToken)
+	u.ErrorIs(err, auth_service.InvalidTokenErr, "tokens of a disabled account stop working at once")
+	_, err = as.IssueServiceAccountToken(context.Background(), signAssertion(key, "k1", claims(nil)))
+	u.ErrorIs(err, auth_service.InvalidTokenErr)
+}
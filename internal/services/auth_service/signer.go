@@ -0,0 +1,225 @@
+package auth_service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"os"
+	"path/filepath"
+)
+
+// Signer signs and verifies tokens with a single key.
+type Signer interface {
+	Method() jwt.SigningMethod
+	SignKey() interface{}
+	VerifyKey() interface{}
+	// PublicKey returns nil for symmetric signers, which must never be published.
+	PublicKey() crypto.PublicKey
+}
+
+var (
+	UnsupportedAlgorithmErr = errors.New("unsupported signing algorithm")
+	KeyMismatchErr          = errors.New("key does not match signing algorithm")
+)
+
+type hmacSigner struct {
+	method jwt.SigningMethod
+	secret []byte
+}
+
+func (s *hmacSigner) Method() jwt.SigningMethod   { return s.method }
+func (s *hmacSigner) SignKey() interface{}        { return s.secret }
+func (s *hmacSigner) VerifyKey() interface{}      { return s.secret }
+func (s *hmacSigner) PublicKey() crypto.PublicKey { return nil }
+
+type asymmetricSigner struct {
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+}
+
+func (s *asymmetricSigner) Method() jwt.SigningMethod   { return s.method }
+func (s *asymmetricSigner) SignKey() interface{}        { return s.privateKey }
+func (s *asymmetricSigner) VerifyKey() interface{}      { return s.privateKey.Public() }
+func (s *asymmetricSigner) PublicKey() crypto.PublicKey { return s.privateKey.Public() }
+
+// NewHMACSigner returns the HS256 signer used when no private key is configured.
+func NewHMACSigner(secretKey string) Signer {
+	return &hmacSigner{method: jwt.SigningMethodHS256, secret: []byte(secretKey)}
+}
+
+// NewSigner builds a signer for the algorithm. HMAC algorithms use secretKey,
+// asymmetric ones load the private key from the PEM file at privateKeyPath.
+func NewSigner(algorithm, secretKey, privateKeyPath string) (Signer, error) {
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("%w: %s", UnsupportedAlgorithmErr, algorithm)
+	}
+
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if secretKey == "" {
+			return nil, errors.New("secret key is required for " + algorithm)
+		}
+		return &hmacSigner{method: method, secret: []byte(secretKey)}, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(privateKeyPath))
+	if err != nil {
+		return nil, fmt.Errorf("read private key: %w", err)
+	}
+
+	key, err := ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAsymmetricSigner(method, key)
+}
+
+// NewAsymmetricSigner checks that the key can be used with the method.
+func NewAsymmetricSigner(method jwt.SigningMethod, key crypto.Signer) (Signer, error) {
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PrivateKey); !ok {
+			return nil, fmt.Errorf("%w: %s", KeyMismatchErr, method.Alg())
+		}
+	case *jwt.SigningMethodECDSA:
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok || k.Curve.Params().BitSize != m.CurveBits {
+			return nil, fmt.Errorf("%w: %s", KeyMismatchErr, method.Alg())
+		}
+	case *SigningMethodEd25519:
+		if _, ok := key.(ed25519.PrivateKey); !ok {
+			return nil, fmt.Errorf("%w: %s", KeyMismatchErr, method.Alg())
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", UnsupportedAlgorithmErr, method.Alg())
+	}
+
+	return &asymmetricSigner{method: method, privateKey: key}, nil
+}
+
+// ParsePrivateKey decodes a PEM encoded PKCS#8, PKCS#1 or SEC 1 private key.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported private key block %q", block.Type)
+}
+
+// ParsePublicKey decodes a PEM encoded PKIX or PKCS#1 public key.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported public key block %q", block.Type)
+}
+
+// VerificationMethod returns the method of the algorithm once the public key
+// is checked to fit it. HMAC algorithms are rejected, a public key must never
+// be used as a shared secret.
+func VerificationMethod(algorithm string, key crypto.PublicKey) (jwt.SigningMethod, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("%w: %s", UnsupportedAlgorithmErr, algorithm)
+	}
+
+	switch m := method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: %s", KeyMismatchErr, algorithm)
+		}
+	case *jwt.SigningMethodECDSA:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || k.Curve.Params().BitSize != m.CurveBits {
+			return nil, fmt.Errorf("%w: %s", KeyMismatchErr, algorithm)
+		}
+	case *SigningMethodEd25519:
+		if _, ok := key.(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("%w: %s", KeyMismatchErr, algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", UnsupportedAlgorithmErr, algorithm)
+	}
+
+	return method, nil
+}
+
+// SigningMethodEd25519 implements the EdDSA algorithm which jwt-go lacks.
+type SigningMethodEd25519 struct{}
+
+var SigningMethodEdDSA = &SigningMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *SigningMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+
+	return nil
+}
+
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
@@ -0,0 +1,59 @@
+package client_service
+
+import (
+	"context"
+	"errors"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+)
+
+type clientService struct {
+	repo interfaces.ClientRepo
+}
+
+var InvalidClientErr = errors.New("invalid client credentials")
+
+// unknownClientHash is checked against the secrets of unknown clients so
+// they take as long to refuse as wrong secrets of known ones.
+const unknownClientHash = "$2a$04$5dPEqok074OBZWbQ3fzj8ekj3KtIoO0OBkZJlczFlyVmt80fnKlpa"
+
+func New(repo interfaces.ClientRepo) *clientService {
+	return &clientService{
+		repo: repo,
+	}
+}
+
+// Authenticate checks the client secret, public clients have none and are
+// identified by their id alone. Unknown clients and wrong secrets are
+// reported the same way and take as long so client ids cannot be probed.
+func (cs *clientService) Authenticate(ctx context.Context, clientID, secret string) (*models.Client, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	client, err := cs.repo.Get(ctx, clientID)
+	if errors.Is(err, repositories.NotFoundClientErr) {
+		_ = utils.CheckPassword([]byte(secret), []byte(unknownClientHash))
+		return nil, InvalidClientErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public {
+		if secret != "" {
+			return nil, InvalidClientErr
+		}
+		return client, nil
+	}
+	if secret == "" {
+		return nil, InvalidClientErr
+	}
+
+	if err := utils.CheckPassword([]byte(secret), []byte(client.SecretHash)); err != nil {
+		return nil, InvalidClientErr
+	}
+
+	return client, nil
+}
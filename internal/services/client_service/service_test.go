@@ -0,0 +1,84 @@
+package client_service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/client_service"
+	"testing"
+)
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+var (
+	clientID     = "resource-server"
+	clientSecret = "qwerty"
+	client       = models.Client{
+		ID:         clientID,
+		Name:       "Default resource server",
+		SecretHash: "$2a$04$7M.stb53jLjW8xxd9j3idO0nAmjFDnm.45hyxHjcndvnZfmiEaanO",
+	}
+)
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func (u *unitTestSuit) TestAuthenticateSuccess() {
+	r := new(repositories.MockClientRepository)
+	r.On("Get", clientID).Return(&client)
+
+	cs := client_service.New(r)
+
+	c, err := cs.Authenticate(context.Background(), clientID, clientSecret)
+
+	u.NoError(err)
+	u.Equal(clientID, c.ID)
+	r.AssertExpectations(u.T())
+}
+
+func (u *unitTestSuit) TestAuthenticateWrongSecret() {
+	r := new(repositories.MockClientRepository)
+	r.On("Get", clientID).Return(&client)
+
+	cs := client_service.New(r)
+
+	c, err := cs.Authenticate(context.Background(), clientID, "wrong")
+
+	u.ErrorIs(err, client_service.InvalidClientErr)
+	u.Nil(c)
+}
+
+func (u *unitTestSuit) TestAuthenticateUnknownClient() {
+	r := new(repositories.MockClientRepository)
+	r.On("Get", "unknown").Return(nil, repositories.NotFoundClientErr)
+
+	cs := client_service.New(r)
+
+	c, err := cs.Authenticate(context.Background(), "unknown", clientSecret)
+
+	u.ErrorIs(err, client_service.InvalidClientErr, "unknown clients must look like wrong secrets")
+	u.Nil(c)
+}
+
+func (u *unitTestSuit) TestAuthenticatePublicClient() {
+	public := models.Client{ID: "spa", Public: true}
+	r := new(repositories.MockClientRepository)
+	r.On("Get", public.ID).Return(&public)
+	r.On("Get", clientID).Return(&client)
+
+	cs := client_service.New(r)
+
+	c, err := cs.Authenticate(context.Background(), public.ID, "")
+	u.NoError(err)
+	u.Equal(public.ID, c.ID)
+
+	_, err = cs.Authenticate(context.Background(), public.ID, clientSecret)
+	u.ErrorIs(err, client_service.InvalidClientErr, "public clients have no secret")
+
+	_, err = cs.Authenticate(context.Background(), clientID, "")
+	u.ErrorIs(err, client_service.InvalidClientErr, "confidential clients need their secret")
+}
@@ -0,0 +1,198 @@
+package lockout_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"strconv"
+	"time"
+)
+
+var NotFoundLockoutErr = errors.New("no failed logins recorded")
+
+// Settings describes how failed logins are slowed down. After n failures
+// the next login waits BaseDelay doubled n-1 times, at most MaxDelay. At
+// UserThreshold failures of an account or IPThreshold failures from an
+// address, zero for never, logins are refused for LockoutDuration. Failures
+// are forgotten Window after the last one.
+type Settings struct {
+	UserThreshold   int
+	IPThreshold     int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+type lockoutService struct {
+	attempts interfaces.LoginAttemptRepo
+	events   interfaces.SecurityEvents
+	settings Settings
+	now      func() time.Time
+}
+
+// New needs a store shared by all instances, so that the counters hold
+// across replicas and restarts.
+func New(attempts interfaces.LoginAttemptRepo, events interfaces.SecurityEvents, settings Settings) *lockoutService {
+	return &lockoutService{
+		attempts: attempts,
+		events:   events,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// Check refuses the login while the account or the address is locked out
+// or has not waited out the delay of its last failure. The error carries
+// the longest wait of the two.
+func (s *lockoutService) Check(ctx context.Context, userID, ip string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	ids := make([]string, 0, 2)
+	for _, counter := range counters(userID, ip) {
+		ids = append(ids, models.LoginAttemptsID(counter.kind, counter.value))
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	now := s.now()
+	attempts, err := s.attempts.Get(ctx, ids, now)
+	if err != nil {
+		return fmt.Errorf("get login attempts error: %w", err)
+	}
+
+	throttled := &models.LoginThrottledError{}
+	for _, a := range attempts {
+		if a.Locked(now) {
+			if wait := a.LockedUntil.Sub(now); !throttled.Locked || wait > throttled.RetryAfter {
+				throttled.RetryAfter = wait
+			}
+			throttled.Locked = true
+			continue
+		}
+		if wait := a.LastFailureAt.Add(s.delay(a.Failures)).Sub(now); !throttled.Locked && wait > throttled.RetryAfter {
+			throttled.RetryAfter = wait
+		}
+	}
+	if throttled.RetryAfter > 0 {
+		return throttled
+	}
+
+	return nil
+}
+
+// Failed counts the failure for the account and the address and locks out
+// the ones reaching their threshold.
+func (s *lockoutService) Failed(ctx context.Context, userID, ip string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	now := s.now()
+	for _, counter := range counters(userID, ip) {
+		a, err := s.attempts.Fail(ctx, counter.kind, counter.value, now, now.Add(s.settings.Window))
+		if err != nil {
+			return fmt.Errorf("count failed login error: %w", err)
+		}
+
+		threshold := s.settings.UserThreshold
+		if counter.kind == models.LoginAttemptIP {
+			threshold = s.settings.IPThreshold
+		}
+		if threshold <= 0 || a.Failures < threshold || a.Locked(now) {
+			continue
+		}
+
+		if err := s.attempts.Lock(ctx, a.ID, now.Add(s.settings.LockoutDuration)); err != nil {
+			return fmt.Errorf("lock out error: %w", err)
+		}
+		s.events.Emit(ctx, &models.SecurityEvent{
+			Type:   models.SecurityEventLoginLocked,
+			UserID: userID,
+			Time:   now,
+			Metadata: map[string]string{
+				"kind":       counter.kind,
+				"failures":   strconv.Itoa(a.Failures),
+				"ip":         ip,
+				"user_agent": utils.ClientInfo(ctx).UserAgent,
+			},
+		})
+	}
+
+	return nil
+}
+
+// Succeeded forgets the failures of the account. The address keeps its
+// count, one good password does not clear guessing at other accounts.
+func (s *lockoutService) Succeeded(ctx context.Context, userID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	err := s.attempts.Delete(ctx, models.LoginAttemptsID(models.LoginAttemptUser, userID))
+	if err != nil && !errors.Is(err, repositories.NotFoundLoginAttemptsErr) {
+		return fmt.Errorf("reset login attempts error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *lockoutService) Locked(ctx context.Context) ([]*models.LoginAttempts, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return s.attempts.GetLocked(ctx, s.now())
+}
+
+// Unlock drops the counter, the account or address may log in at once.
+func (s *lockoutService) Unlock(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	err := s.attempts.Delete(ctx, id)
+	if errors.Is(err, repositories.NotFoundLoginAttemptsErr) {
+		return NotFoundLockoutErr
+	}
+
+	return err
+}
+
+// delay is the wait after the failures, doubling from BaseDelay up to MaxDelay.
+func (s *lockoutService) delay(failures int) time.Duration {
+	if failures <= 0 || s.settings.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := s.settings.BaseDelay
+	for i := 1; i < failures && delay < s.settings.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.settings.MaxDelay {
+		delay = s.settings.MaxDelay
+	}
+
+	return delay
+}
+
+type counter struct {
+	kind  string
+	value string
+}
+
+// counters are the counters a login counts against, unknown usernames and
+// unknown addresses have none.
+func counters(userID, ip string) []counter {
+	result := make([]counter, 0, 2)
+	if userID != "" {
+		result = append(result, counter{kind: models.LoginAttemptUser, value: userID})
+	}
+	if ip != "" {
+		result = append(result, counter{kind: models.LoginAttemptIP, value: ip})
+	}
+
+	return result
+}
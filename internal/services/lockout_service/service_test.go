@@ -0,0 +1,144 @@
+package lockout_service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/lockout_service"
+	"testing"
+	"time"
+)
+
+const (
+	userID = "62b1b6c3f0e1a2b3c4d5e6f7"
+	ip     = "192.168.0.1"
+)
+
+var settings = lockout_service.Settings{
+	UserThreshold:   3,
+	IPThreshold:     5,
+	BaseDelay:       time.Minute,
+	MaxDelay:        4 * time.Minute,
+	LockoutDuration: time.Hour,
+	Window:          2 * time.Hour,
+}
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+type recordedEvents struct {
+	events []*models.SecurityEvent
+}
+
+func (r *recordedEvents) Emit(_ context.Context, event *models.SecurityEvent) {
+	r.events = append(r.events, event)
+}
+
+func throttled(err error) *models.LoginThrottledError {
+	var throttled *models.LoginThrottledError
+	if errors.As(err, &throttled) {
+		return throttled
+	}
+
+	return nil
+}
+
+func (u *unitTestSuit) TestBackOff() {
+	attempts := repositories.NewMemoryLoginAttemptRepo()
+	s := lockout_service.New(attempts, &recordedEvents{}, settings)
+	ctx := context.Background()
+
+	u.Require().NoError(s.Check(ctx, userID, ip))
+	u.Require().NoError(s.Failed(ctx, userID, ip))
+
+	t := throttled(s.Check(ctx, userID, ip))
+	u.Require().NotNil(t, "the next login waits the base delay")
+	u.False(t.Locked)
+	u.InDelta(time.Minute.Seconds(), t.RetryAfter.Seconds(), 1)
+
+	u.Nil(throttled(s.Check(ctx, "", "10.0.0.1")), "other accounts and addresses are not delayed")
+
+	// a second failure a while ago doubles the delay
+	past := time.Now().Add(-90 * time.Second)
+	_, err := attempts.Fail(ctx, models.LoginAttemptUser, userID, past, past.Add(settings.Window))
+	u.Require().NoError(err)
+	t = throttled(s.Check(ctx, userID, ""))
+	u.Require().NotNil(t)
+	u.InDelta(30, t.RetryAfter.Seconds(), 1)
+
+	u.Require().NoError(s.Succeeded(ctx, userID))
+	u.NoError(s.Check(ctx, userID, ""), "a successful login forgets the failures of the account")
+	u.NotNil(throttled(s.Check(ctx, userID, ip)), "but not of the address")
+}
+
+func (u *unitTestSuit) TestLockout() {
+	attempts := repositories.NewMemoryLoginAttemptRepo()
+	events := &recordedEvents{}
+	s := lockout_service.New(attempts, events, settings)
+	ctx := context.Background()
+
+	for i := 0; i < settings.UserThreshold; i++ {
+		u.Require().NoError(s.Failed(ctx, userID, ""))
+	}
+
+	t := throttled(s.Check(ctx, userID, ip))
+	u.Require().NotNil(t)
+	u.True(t.Locked)
+	u.InDelta(time.Hour.Seconds(), t.RetryAfter.Seconds(), 1)
+
+	u.Require().Len(events.events, 1)
+	u.Equal(models.SecurityEventLoginLocked, events.events[0].Type)
+	u.Equal(userID, events.events[0].UserID)
+	u.Equal(models.LoginAttemptUser, events.events[0].Metadata["kind"])
+
+	locked, err := s.Locked(ctx)
+	u.Require().NoError(err)
+	u.Require().Len(locked, 1)
+	u.Equal(models.LoginAttemptsID(models.LoginAttemptUser, userID), locked[0].ID)
+	u.Equal(settings.UserThreshold, locked[0].Failures)
+
+	u.Require().NoError(s.Unlock(ctx, locked[0].ID))
+	u.NoError(s.Check(ctx, userID, ""))
+	u.ErrorIs(s.Unlock(ctx, locked[0].ID), lockout_service.NotFoundLockoutErr)
+}
+
+func (u *unitTestSuit) TestIPLockout() {
+	attempts := repositories.NewMemoryLoginAttemptRepo()
+	s := lockout_service.New(attempts, &recordedEvents{}, settings)
+	ctx := context.Background()
+
+	// guessing at unknown usernames counts against the address only
+	for i := 0; i < settings.IPThreshold; i++ {
+		u.Require().NoError(s.Failed(ctx, "", ip))
+	}
+
+	t := throttled(s.Check(ctx, userID, ip))
+	u.Require().NotNil(t)
+	u.True(t.Locked)
+	u.NoError(s.Check(ctx, userID, "10.0.0.1"), "the account is not locked")
+}
+
+func (u *unitTestSuit) TestExpiredFailures() {
+	attempts := repositories.NewMemoryLoginAttemptRepo()
+	s := lockout_service.New(attempts, &recordedEvents{}, settings)
+	ctx := context.Background()
+
+	past := time.Now().Add(-3 * time.Hour)
+	for i := 0; i < settings.UserThreshold-1; i++ {
+		_, err := attempts.Fail(ctx, models.LoginAttemptUser, userID, past, past.Add(settings.Window))
+		u.Require().NoError(err)
+	}
+	u.NoError(s.Check(ctx, userID, ""), "failures are forgotten after the window")
+
+	u.Require().NoError(s.Failed(ctx, userID, ""))
+	t := throttled(s.Check(ctx, userID, ""))
+	u.Require().NotNil(t)
+	u.False(t.Locked, "the count starts over")
+}
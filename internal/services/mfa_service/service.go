@@ -0,0 +1,287 @@
+package mfa_service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"image/png"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidCodeErr     = models.InvalidMFACodeErr
+	AlreadyEnrolledErr = errors.New("totp is already enabled")
+	NotEnrolledErr     = errors.New("totp is not enabled")
+)
+
+const (
+	// period, digits and skew are the RFC 6238 defaults every authenticator app supports.
+	period = 30
+	digits = otp.DigitsSix
+	skew   = 1
+	// qrCodeSize is the width and height of the QR code in pixels.
+	qrCodeSize = 256
+	// recoveryCodeBytes gives 16 base32 characters per recovery code.
+	recoveryCodeBytes = 10
+)
+
+// Settings holds the name shown in authenticator apps and the number of
+// recovery codes handed out on enrollment.
+type Settings struct {
+	Issuer        string
+	RecoveryCodes int
+}
+
+type mfaService struct {
+	factors  interfaces.TOTPFactorRepo
+	settings Settings
+	now      func() time.Time
+}
+
+func New(factors interfaces.TOTPFactorRepo, settings Settings) *mfaService {
+	return &mfaService{
+		factors:  factors,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// Enroll generates a new secret for the user. It replaces an enrollment
+// that was never confirmed, a confirmed one has to be disabled first.
+func (s *mfaService) Enroll(ctx context.Context, user *models.User) (*models.TOTPEnrollment, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.settings.Issuer,
+		AccountName: user.Username,
+		Period:      period,
+		Digits:      digits,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generate totp secret error: %w", err)
+	}
+
+	err = s.factors.Save(ctx, &models.TOTPFactor{
+		ID:        user.ID.Hex(),
+		Secret:    key.Secret(),
+		CreatedAt: s.now(),
+	})
+	if errors.Is(err, repositories.ConfirmedTOTPFactorErr) {
+		return nil, AlreadyEnrolledErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("save totp factor error: %w", err)
+	}
+
+	img, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("render qr code error: %w", err)
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		return nil, fmt.Errorf("encode qr code error: %w", err)
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: key.Secret(),
+		URI:    key.URL(),
+		QRCode: qr.Bytes(),
+	}, nil
+}
+
+// Confirm enables the enrolled authenticator app once the user proves it
+// works with a first code, and returns the recovery codes. They are shown
+// this once, only their hashes are kept.
+func (s *mfaService) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	factor, err := s.factors.Get(ctx, userID)
+	if errors.Is(err, repositories.NotFoundTOTPFactorErr) {
+		return nil, NotEnrolledErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.Confirmed {
+		return nil, AlreadyEnrolledErr
+	}
+
+	step, ok := s.validate(factor.Secret, code)
+	if !ok {
+		return nil, InvalidCodeErr
+	}
+
+	codes := make([]string, s.settings.RecoveryCodes)
+	hashes := make([]string, s.settings.RecoveryCodes)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("generate recovery code error: %w", err)
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	err = s.factors.Confirm(ctx, userID, hashes, step, s.now())
+	if errors.Is(err, repositories.NotFoundTOTPFactorErr) {
+		return nil, AlreadyEnrolledErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("confirm totp factor error: %w", err)
+	}
+
+	return codes, nil
+}
+
+// Disable removes the authenticator app and its recovery codes, the user
+// proves it still holds them with a code of either.
+func (s *mfaService) Disable(ctx context.Context, userID, code string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	factor, err := s.factors.Get(ctx, userID)
+	if errors.Is(err, repositories.NotFoundTOTPFactorErr) {
+		return NotEnrolledErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// an enrollment that was never confirmed is no second factor yet
+	if factor.Confirmed {
+		if err := s.Verify(ctx, userID, code); err != nil {
+			return err
+		}
+	}
+
+	err = s.factors.Delete(ctx, userID)
+	if err != nil && !errors.Is(err, repositories.NotFoundTOTPFactorErr) {
+		return fmt.Errorf("delete totp factor error: %w", err)
+	}
+
+	return nil
+}
+
+// Methods returns the second factor methods of the user, none until the
+// authenticator app has been confirmed.
+func (s *mfaService) Methods(ctx context.Context, userID string) ([]string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	factor, err := s.factors.Get(ctx, userID)
+	if errors.Is(err, repositories.NotFoundTOTPFactorErr) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !factor.Confirmed {
+		return nil, nil
+	}
+
+	methods := []string{models.MFAMethodTOTP}
+	if len(factor.RecoveryCodes) > 0 {
+		methods = append(methods, models.MFAMethodRecoveryCode)
+	}
+
+	return methods, nil
+}
+
+// Verify accepts a current code of the authenticator app or an unused
+// recovery code. Either is accepted only once.
+func (s *mfaService) Verify(ctx context.Context, userID, code string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	factor, err := s.factors.Get(ctx, userID)
+	if errors.Is(err, repositories.NotFoundTOTPFactorErr) {
+		return InvalidCodeErr
+	}
+	if err != nil {
+		return err
+	}
+	if !factor.Confirmed {
+		return InvalidCodeErr
+	}
+
+	used := false
+	if step, ok := s.validate(factor.Secret, code); ok {
+		used, err = s.factors.UseStep(ctx, userID, step)
+	} else {
+		used, err = s.factors.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	}
+	if err != nil {
+		return err
+	}
+	if !used {
+		return InvalidCodeErr
+	}
+
+	return nil
+}
+
+// validate checks the code against the current time step and its
+// neighbours, allowing for clock drift, and returns the matching step.
+func (s *mfaService) validate(secret, code string) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != digits.Length() {
+		return 0, false
+	}
+
+	now := s.now()
+	current := now.Unix() / period
+	for offset := int64(-skew); offset <= skew; offset++ {
+		expected, err := totp.GenerateCodeCustom(secret, now.Add(time.Duration(offset*period)*time.Second), totp.ValidateOpts{
+			Period:    period,
+			Digits:    digits,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + offset, true
+		}
+	}
+
+	return 0, false
+}
+
+// newRecoveryCode returns a random code formatted as XXXX-XXXX-XXXX-XXXX.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	raw := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+
+	groups := make([]string, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		groups = append(groups, raw[i:i+4])
+	}
+
+	return strings.Join(groups, "-"), nil
+}
+
+// hashRecoveryCode accepts the code typed in any case, with or without the
+// dashes or spaces.
+func hashRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+
+	return utils.HashToken(code)
+}
@@ -0,0 +1,135 @@
+package mfa_service_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/mfa_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+var user = &models.User{
+	ID:       primitive.NewObjectID(),
+	Username: "test123",
+}
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func newService() interface {
+	Enroll(ctx context.Context, user *models.User) (*models.TOTPEnrollment, error)
+	Confirm(ctx context.Context, userID, code string) ([]string, error)
+	Disable(ctx context.Context, userID, code string) error
+	Methods(ctx context.Context, userID string) ([]string, error)
+	Verify(ctx context.Context, userID, code string) error
+} {
+	return mfa_service.New(repositories.NewMemoryTOTPFactorRepo(), mfa_service.Settings{
+		Issuer:        "auth-service",
+		RecoveryCodes: 3,
+	})
+}
+
+func code(u *unitTestSuit, secret string, at time.Time) string {
+	c, err := totp.GenerateCode(secret, at)
+	u.Require().NoError(err)
+
+	return c
+}
+
+func (u *unitTestSuit) TestEnroll() {
+	s := newService()
+	ctx := context.Background()
+
+	enrollment, err := s.Enroll(ctx, user)
+	u.Require().NoError(err)
+	u.True(strings.HasPrefix(enrollment.URI, "otpauth://totp/auth-service:test123?"), enrollment.URI)
+	u.Contains(enrollment.URI, "secret="+enrollment.Secret)
+
+	img, err := png.Decode(bytes.NewReader(enrollment.QRCode))
+	u.Require().NoError(err, "qr code must be a png")
+	u.Equal(256, img.Bounds().Dx())
+
+	methods, err := s.Methods(ctx, user.ID.Hex())
+	u.NoError(err)
+	u.Empty(methods, "unconfirmed factor is no second factor")
+	u.ErrorIs(s.Verify(ctx, user.ID.Hex(), code(u, enrollment.Secret, time.Now())), mfa_service.InvalidCodeErr)
+
+	// enrolling again replaces the unconfirmed secret
+	again, err := s.Enroll(ctx, user)
+	u.Require().NoError(err)
+	u.NotEqual(enrollment.Secret, again.Secret)
+
+	_, err = s.Confirm(ctx, user.ID.Hex(), code(u, enrollment.Secret, time.Now()))
+	u.ErrorIs(err, mfa_service.InvalidCodeErr, "old secret must be replaced")
+}
+
+func (u *unitTestSuit) TestConfirmAndVerify() {
+	s := newService()
+	ctx := context.Background()
+
+	enrollment, err := s.Enroll(ctx, user)
+	u.Require().NoError(err)
+
+	confirmCode := code(u, enrollment.Secret, time.Now())
+	codes, err := s.Confirm(ctx, user.ID.Hex(), confirmCode)
+	u.Require().NoError(err)
+	u.Len(codes, 3)
+	u.Regexp(`^[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}-[A-Z2-7]{4}$`, codes[0])
+
+	_, err = s.Enroll(ctx, user)
+	u.ErrorIs(err, mfa_service.AlreadyEnrolledErr)
+
+	methods, err := s.Methods(ctx, user.ID.Hex())
+	u.NoError(err)
+	u.Equal([]string{models.MFAMethodTOTP, models.MFAMethodRecoveryCode}, methods)
+
+	u.ErrorIs(s.Verify(ctx, user.ID.Hex(), confirmCode), mfa_service.InvalidCodeErr, "a code is accepted once")
+	u.ErrorIs(s.Verify(ctx, user.ID.Hex(), "000000"), mfa_service.InvalidCodeErr)
+
+	next := code(u, enrollment.Secret, time.Now().Add(30*time.Second))
+	u.NoError(s.Verify(ctx, user.ID.Hex(), next), "next step is within the skew")
+	u.ErrorIs(s.Verify(ctx, user.ID.Hex(), next), mfa_service.InvalidCodeErr)
+
+	u.NoError(s.Verify(ctx, user.ID.Hex(), strings.ToLower(strings.ReplaceAll(codes[0], "-", ""))), "recovery code in any format")
+	u.ErrorIs(s.Verify(ctx, user.ID.Hex(), codes[0]), mfa_service.InvalidCodeErr, "recovery code is used once")
+	u.NoError(s.Verify(ctx, user.ID.Hex(), codes[1]))
+	u.NoError(s.Verify(ctx, user.ID.Hex(), codes[2]))
+
+	methods, err = s.Methods(ctx, user.ID.Hex())
+	u.NoError(err)
+	u.Equal([]string{models.MFAMethodTOTP}, methods, "no recovery codes left")
+}
+
+func (u *unitTestSuit) TestDisable() {
+	s := newService()
+	ctx := context.Background()
+
+	u.ErrorIs(s.Disable(ctx, user.ID.Hex(), "123456"), mfa_service.NotEnrolledErr)
+
+	enrollment, err := s.Enroll(ctx, user)
+	u.Require().NoError(err)
+	codes, err := s.Confirm(ctx, user.ID.Hex(), code(u, enrollment.Secret, time.Now()))
+	u.Require().NoError(err)
+
+	u.ErrorIs(s.Disable(ctx, user.ID.Hex(), "000000"), mfa_service.InvalidCodeErr)
+	u.NoError(s.Disable(ctx, user.ID.Hex(), codes[0]))
+
+	methods, err := s.Methods(ctx, user.ID.Hex())
+	u.NoError(err)
+	u.Empty(methods)
+
+	_, err = s.Enroll(ctx, user)
+	u.NoError(err, "can enroll again once disabled")
+}
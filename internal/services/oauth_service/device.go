@@ -0,0 +1,254 @@
+package oauth_service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// InvalidUserCodeErr is returned for user codes that are unknown, expired or
+// already decided.
+var InvalidUserCodeErr = errors.New("user code is invalid or expired")
+
+const (
+	// userCodeAlphabet has no vowels and no characters easily confused, RFC 8628 6.1
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+	// slowDownStep is added to the interval of a device polling too fast, RFC 8628 3.5
+	slowDownStep = 5
+	// userCodeAttempts bounds the retries on user code collisions.
+	userCodeAttempts = 3
+)
+
+// DeviceAuthorization starts an RFC 8628 device flow for the client.
+func (s *oauthService) DeviceAuthorization(ctx context.Context, client *models.Client, scope string) (*models.DeviceAuthorization, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if !client.HasGrantType(models.GrantTypeDeviceCode) {
+		return nil, models.NewOAuthError(models.OAuthUnauthorizedClient, "the client may not use the device_code grant")
+	}
+
+	granted, err := grantedScope(client, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceCode, err := utils.RandomToken(codeBytes)
+	if err != nil {
+		return nil, fmt.Errorf("generate device code error: %w", err)
+	}
+
+	now := s.now()
+	grant := &models.DeviceGrant{
+		ID:        utils.HashToken(deviceCode),
+		ClientID:  client.ID,
+		Scope:     granted,
+		Status:    models.DeviceGrantPending,
+		Interval:  int64(s.settings.DeviceInterval / time.Second),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.settings.DeviceCodeLifeTime),
+	}
+
+	for attempt := 0; ; attempt++ {
+		grant.UserCode, err = newUserCode()
+		if err != nil {
+			return nil, fmt.Errorf("generate user code error: %w", err)
+		}
+
+		err = s.devices.Create(ctx, grant)
+		if errors.Is(err, repositories.DuplicateDeviceGrantErr) && attempt < userCodeAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("create device grant error: %w", err)
+		}
+		break
+	}
+
+	complete, err := url.Parse(s.settings.VerificationURI)
+	if err != nil {
+		return nil, fmt.Errorf("verification uri error: %w", err)
+	}
+	query := complete.Query()
+	query.Set("user_code", grant.UserCode)
+	complete.RawQuery = query.Encode()
+
+	return &models.DeviceAuthorization{
+		DeviceCode:              deviceCode,
+		UserCode:                grant.UserCode,
+		VerificationURI:         s.settings.VerificationURI,
+		VerificationURIComplete: complete.String(),
+		ExpiresIn:               int64(s.settings.DeviceCodeLifeTime / time.Second),
+		Interval:                grant.Interval,
+	}, nil
+}
+
+// DeviceToken answers a poll of the device for its token pair. Until the
+// user has decided it reports authorization_pending, or slow_down when the
+// device polls more often than the interval allows.
+func (s *oauthService) DeviceToken(ctx context.Context, client *models.Client, deviceCode string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if deviceCode == "" {
+		return nil, models.NewOAuthError(models.OAuthInvalidRequest, "device_code is required")
+	}
+
+	now := s.now()
+	id := utils.HashToken(deviceCode)
+	grant, err := s.devices.Poll(ctx, id, now)
+	if errors.Is(err, repositories.NotFoundDeviceGrantErr) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "unknown device code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if grant.ClientID != client.ID {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "unknown device code")
+	}
+	if !now.Before(grant.ExpiresAt) {
+		return nil, models.NewOAuthError(models.OAuthExpiredToken, "")
+	}
+	if !grant.LastPolledAt.IsZero() && now.Sub(grant.LastPolledAt) < time.Duration(grant.Interval)*time.Second {
+		if err := s.devices.SlowDown(ctx, id, slowDownStep); err != nil {
+			return nil, err
+		}
+		return nil, models.NewOAuthError(models.OAuthSlowDown, "")
+	}
+
+	switch grant.Status {
+	case models.DeviceGrantPending:
+		return nil, models.NewOAuthError(models.OAuthAuthorizationPending, "")
+	case models.DeviceGrantDenied:
+		_, _ = s.devices.Consume(ctx, id)
+		return nil, models.NewOAuthError(models.OAuthAccessDenied, "")
+	}
+
+	// only one of concurrent polls gets the tokens
+	grant, err = s.devices.Consume(ctx, id)
+	if errors.Is(err, repositories.NotFoundDeviceGrantErr) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "unknown device code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	td, err := s.authService.IssueTokens(ctx, grant.UserID, &models.Grant{
+		Tenant:   grant.Tenant,
+		ClientID: grant.ClientID,
+		Scope:    grant.Scope,
+	})
+	if errors.Is(err, auth_service.NotMemberErr) || errors.Is(err, repositories.NotFoundUserErr) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if models.ScopeContains(td.Scope, models.ScopeOpenID) {
+		td.IDToken, err = s.authService.IDToken(ctx, grant.UserID, client.ID, td.Scope, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return td, nil
+}
+
+// DeviceGrant returns the pending grant of a user code for the user to review.
+func (s *oauthService) DeviceGrant(ctx context.Context, userCode string) (*models.DeviceGrant, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	code, ok := normalizeUserCode(userCode)
+	if !ok {
+		return nil, InvalidUserCodeErr
+	}
+
+	grant, err := s.devices.GetByUserCode(ctx, code)
+	if errors.Is(err, repositories.NotFoundDeviceGrantErr) {
+		return nil, InvalidUserCodeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if grant.Status != models.DeviceGrantPending || !s.now().Before(grant.ExpiresAt) {
+		return nil, InvalidUserCodeErr
+	}
+
+	return grant, nil
+}
+
+// DecideDevice records whether the signed in user approves the device
+// request with the user code.
+func (s *oauthService) DecideDevice(ctx context.Context, userCode string, user *models.User, approve bool) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	grant, err := s.DeviceGrant(ctx, userCode)
+	if err != nil {
+		return err
+	}
+
+	status := models.DeviceGrantDenied
+	if approve {
+		status = models.DeviceGrantApproved
+	}
+
+	err = s.devices.Decide(ctx, grant.ID, status, user.ID.Hex(), user.Tenant)
+	if errors.Is(err, repositories.NotFoundDeviceGrantErr) {
+		return InvalidUserCodeErr
+	}
+
+	return err
+}
+
+// newUserCode returns a random code formatted as XXXX-XXXX.
+func newUserCode() (string, error) {
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+
+	var b strings.Builder
+	for i := 0; i < userCodeLength; i++ {
+		if i == userCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+
+	return b.String(), nil
+}
+
+// normalizeUserCode accepts the code typed in any case, with or without the
+// dash or spaces, RFC 8628 6.1.
+func normalizeUserCode(userCode string) (string, bool) {
+	var letters []byte
+	for _, c := range strings.ToUpper(userCode) {
+		if c == '-' || c == ' ' {
+			continue
+		}
+		if !strings.ContainsRune(userCodeAlphabet, c) {
+			return "", false
+		}
+		letters = append(letters, byte(c))
+	}
+	if len(letters) != userCodeLength {
+		return "", false
+	}
+
+	return string(letters[:userCodeLength/2]) + "-" + string(letters[userCodeLength/2:]), true
+}
@@ -0,0 +1,360 @@
+package oauth_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"strings"
+	"time"
+)
+
+// Settings holds the lifetimes of pending grants and the device flow parameters.
+type Settings struct {
+	CodeLifeTime       time.Duration
+	DeviceCodeLifeTime time.Duration
+	// DeviceInterval is the minimum time between two polls of a device.
+	DeviceInterval time.Duration
+	// VerificationURI is the page where users enter the user code of a device.
+	VerificationURI string
+}
+
+type oauthService struct {
+	clients     interfaces.ClientRepo
+	codes       interfaces.AuthorizationCodeRepo
+	devices     interfaces.DeviceGrantRepo
+	users       interfaces.UserRepo
+	authService interfaces.AuthService
+	settings    Settings
+	now         func() time.Time
+}
+
+// UnknownClientErr and InvalidRedirectURIErr are authorization request
+// errors that must not be sent to the redirect URI, it cannot be trusted.
+var (
+	UnknownClientErr      = errors.New("unknown client")
+	InvalidRedirectURIErr = errors.New("redirect uri is not registered for the client")
+)
+
+// InsufficientScopeErr is returned by UserInfo for access tokens issued
+// without the openid scope.
+var InsufficientScopeErr = errors.New("token was not granted the openid scope")
+
+// codeBytes is the entropy of authorization codes.
+const codeBytes = 32
+
+func New(clients interfaces.ClientRepo, codes interfaces.AuthorizationCodeRepo, devices interfaces.DeviceGrantRepo, users interfaces.UserRepo, authService interfaces.AuthService, settings Settings) *oauthService {
+	return &oauthService{
+		clients:     clients,
+		codes:       codes,
+		devices:     devices,
+		users:       users,
+		authService: authService,
+		settings:    settings,
+		now:         time.Now,
+	}
+}
+
+// ValidateAuthorization checks an authorization request and fills in the
+// default redirect URI and scope of the client. Errors other than
+// UnknownClientErr and InvalidRedirectURIErr are *models.OAuthError to be
+// reported to the redirect URI.
+func (s *oauthService) ValidateAuthorization(ctx context.Context, req *models.AuthorizationRequest) (*models.Client, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	client, err := s.clients.Get(ctx, req.ClientID)
+	if errors.Is(err, repositories.NotFoundClientErr) {
+		return nil, UnknownClientErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		req.RedirectURI = client.RedirectURIs[0]
+	}
+	if !client.HasRedirectURI(req.RedirectURI) {
+		return nil, InvalidRedirectURIErr
+	}
+
+	if !client.HasGrantType(models.GrantTypeAuthorization) {
+		return nil, models.NewOAuthError(models.OAuthUnauthorizedClient, "the client may not use the authorization_code grant")
+	}
+	if req.ResponseType != models.ResponseTypeCode {
+		return nil, models.NewOAuthError(models.OAuthUnsupportedResponseType, "only the code response type is supported")
+	}
+	if req.CodeChallenge == "" {
+		return nil, models.NewOAuthError(models.OAuthInvalidRequest, "code_challenge is required")
+	}
+	if req.CodeChallengeMethod != models.CodeChallengeS256 {
+		return nil, models.NewOAuthError(models.OAuthInvalidRequest, "code_challenge_method must be S256")
+	}
+
+	scope, err := grantedScope(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+	req.Scope = scope
+
+	return client, nil
+}
+
+// Authorize issues an authorization code for the signed in user.
+func (s *oauthService) Authorize(ctx context.Context, req *models.AuthorizationRequest, user *models.User) (string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	client, err := s.ValidateAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := utils.RandomToken(codeBytes)
+	if err != nil {
+		return "", fmt.Errorf("generate code error: %w", err)
+	}
+
+	now := s.now()
+	err = s.codes.Create(ctx, &models.AuthorizationCode{
+		ID:                  utils.HashToken(code),
+		ClientID:            client.ID,
+		UserID:              user.ID.Hex(),
+		Tenant:              user.Tenant,
+		RedirectURI:         req.RedirectURI,
+		Scope:               req.Scope,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+		CreatedAt:           now,
+		ExpiresAt:           now.Add(s.settings.CodeLifeTime),
+	})
+	if err != nil {
+		return "", fmt.Errorf("create authorization code error: %w", err)
+	}
+
+	return code, nil
+}
+
+// Exchange redeems an authorization code of the client for a token pair,
+// with an ID token when the openid scope was granted.
+func (s *oauthService) Exchange(ctx context.Context, client *models.Client, code, redirectURI, verifier string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if code == "" {
+		return nil, models.NewOAuthError(models.OAuthInvalidRequest, "code is required")
+	}
+	// RFC 7636 4.1
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return nil, models.NewOAuthError(models.OAuthInvalidRequest, "code_verifier must have 43 to 128 characters")
+	}
+
+	grant, err := s.codes.Consume(ctx, utils.HashToken(code))
+	if errors.Is(err, repositories.NotFoundAuthorizationCodeErr) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "unknown or used code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if grant.ClientID != client.ID || !s.now().Before(grant.ExpiresAt) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "unknown or used code")
+	}
+	if grant.RedirectURI != redirectURI {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !verifyChallenge(grant, verifier) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "code_verifier does not match the code_challenge")
+	}
+
+	td, err := s.authService.IssueTokens(ctx, grant.UserID, &models.Grant{
+		Tenant:   grant.Tenant,
+		ClientID: grant.ClientID,
+		Scope:    grant.Scope,
+	})
+	if errors.Is(err, auth_service.NotMemberErr) || errors.Is(err, repositories.NotFoundUserErr) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if models.ScopeContains(td.Scope, models.ScopeOpenID) {
+		td.IDToken, err = s.authService.IDToken(ctx, grant.UserID, client.ID, td.Scope, grant.Nonce)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return td, nil
+}
+
+// Refresh exchanges a refresh token issued to the client for a new pair.
+func (s *oauthService) Refresh(ctx context.Context, client *models.Client, refreshToken string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if !client.HasGrantType(models.GrantTypeRefreshToken) {
+		return nil, models.NewOAuthError(models.OAuthUnauthorizedClient, "the client may not use the refresh_token grant")
+	}
+	if refreshToken == "" {
+		return nil, models.NewOAuthError(models.OAuthInvalidRequest, "refresh_token is required")
+	}
+
+	// checked before rotating, a token of another client must stay usable by its owner
+	introspection, err := s.authService.Introspect(ctx, refreshToken, models.GrantTypeRefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if !introspection.Active || introspection.TokenType != models.GrantTypeRefreshToken || introspection.ClientID != client.ID {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, "invalid refresh token")
+	}
+
+	td, err := s.authService.RefreshClient(ctx, client.ID, refreshToken)
+	if errors.Is(err, models.InvalidTokenErr) || errors.Is(err, models.TokenExpiredErr) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if models.ScopeContains(td.Scope, models.ScopeOpenID) {
+		td.IDToken, err = s.authService.IDToken(ctx, introspection.Subject, client.ID, td.Scope, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return td, nil
+}
+
+// ClientCredentials issues an access token to a confidential client acting
+// on its own behalf. The openid scope needs a user and is never granted.
+func (s *oauthService) ClientCredentials(ctx context.Context, client *models.Client, scope string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if client.Public || !client.HasGrantType(models.GrantTypeClientCredentials) {
+		return nil, models.NewOAuthError(models.OAuthUnauthorizedClient, "the client may not use the client_credentials grant")
+	}
+	if models.ScopeContains(scope, models.ScopeOpenID) {
+		return nil, models.NewOAuthError(models.OAuthInvalidScope, "scope openid is not allowed for the client_credentials grant")
+	}
+
+	granted, err := grantedScope(client, scope)
+	if err != nil {
+		return nil, err
+	}
+	// the default scope is every scope of the client, openid included
+	granted = strings.Join(remove(strings.Fields(granted), models.ScopeOpenID), " ")
+
+	return s.authService.IssueServiceToken(ctx, client, granted)
+}
+
+// JWTBearer issues an access token to the service account that signed the
+// RFC 7523 assertion. The assertion authenticates the account, no client
+// authentication is needed.
+func (s *oauthService) JWTBearer(ctx context.Context, assertion string) (*models.TokenDetails, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if assertion == "" {
+		return nil, models.NewOAuthError(models.OAuthInvalidRequest, "assertion is required")
+	}
+
+	td, err := s.authService.IssueServiceAccountToken(ctx, assertion)
+	if errors.Is(err, models.InvalidTokenErr) || errors.Is(err, models.TokenExpiredErr) {
+		return nil, models.NewOAuthError(models.OAuthInvalidGrant, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return td, nil
+}
+
+// UserInfo returns the claims of the access token owner allowed by the
+// scope of the token. Tokens that are not active give InvalidTokenErr.
+func (s *oauthService) UserInfo(ctx context.Context, accessToken string) (*models.UserInfo, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	introspection, err := s.authService.Introspect(ctx, accessToken, "access_token")
+	if err != nil {
+		return nil, err
+	}
+	if !introspection.Active || introspection.TokenType != "access_token" {
+		return nil, models.InvalidTokenErr
+	}
+	if !models.ScopeContains(introspection.Scope, models.ScopeOpenID) {
+		return nil, InsufficientScopeErr
+	}
+
+	// read from the database, the profile may have changed since the token was issued
+	user, err := s.users.Get(ctx, introspection.Subject)
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		return nil, models.InvalidTokenErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewUserInfo(user, introspection.Scope), nil
+}
+
+// grantedScope checks the requested scopes against the client, an empty
+// request is granted every scope of the client.
+func grantedScope(client *models.Client, requested string) (string, error) {
+	scopes := strings.Fields(requested)
+	if len(scopes) == 0 {
+		return strings.Join(client.Scopes, " "), nil
+	}
+
+	granted := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !client.HasScope(scope) {
+			return "", models.NewOAuthError(models.OAuthInvalidScope, "scope "+scope+" is not allowed for the client")
+		}
+		if !contains(granted, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	return strings.Join(granted, " "), nil
+}
+
+func verifyChallenge(code *models.AuthorizationCode, verifier string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(code.CodeChallenge)) == 1
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func remove(values []string, value string) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,362 @@
+package oauth_service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/oauth_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+	"time"
+)
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+var (
+	user = models.User{
+		ID:        primitive.NewObjectID(),
+		Username:  "test123",
+		Email:     "test123@ya.ru",
+		FirstName: "test",
+		LastName:  "123",
+	}
+	spa = models.Client{
+		ID:           "spa",
+		Public:       true,
+		RedirectURIs: []string{"https://app.example.com/callback"},
+		Scopes:       []string{"openid", "profile"},
+	}
+	other = models.Client{
+		ID:           "other",
+		Public:       true,
+		RedirectURIs: []string{"https://other.example.com/callback"},
+	}
+	billing = models.Client{
+		ID:         "billing",
+		GrantTypes: []string{models.GrantTypeClientCredentials},
+		// registered to check that the authorization_code grant is refused
+		RedirectURIs: []string{"https://billing.example.com/callback"},
+		Scopes:       []string{"openid", "invoices:read", "invoices:write"},
+	}
+	cli = models.Client{
+		ID:         "cli",
+		Public:     true,
+		GrantTypes: []string{models.GrantTypeDeviceCode},
+		Scopes:     []string{"openid", "profile"},
+	}
+	verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge = s256(verifier)
+)
+
+func s256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func newService() interfaces.OAuthService {
+	return newServiceWith(oauth_service.Settings{
+		CodeLifeTime:       time.Minute,
+		DeviceCodeLifeTime: time.Minute,
+		VerificationURI:    "https://auth.example.com/oauth/device",
+	})
+}
+
+func newServiceWith(settings oauth_service.Settings) interfaces.OAuthService {
+	clients := new(repositories.MockClientRepository)
+	clients.On("Get", spa.ID).Return(&spa)
+	clients.On("Get", other.ID).Return(&other)
+	clients.On("Get", billing.ID).Return(&billing)
+	clients.On("Get", cli.ID).Return(&cli)
+	clients.On("Get", "unknown").Return(nil, repositories.NotFoundClientErr)
+
+	users := new(repositories.MockUserRepository)
+	users.On("Get", user.ID.Hex()).Return(&user)
+
+	as := auth_service.New(&auth_service.JwtSettings{SecretKey: "secret", AtLifeTime: 5, RtLifeTime: 5}, users)
+
+	return oauth_service.New(clients, repositories.NewMemoryAuthorizationCodeRepo(), repositories.NewMemoryDeviceGrantRepo(), users, as, settings)
+}
+
+func request() *models.AuthorizationRequest {
+	return &models.AuthorizationRequest{
+		ResponseType:        models.ResponseTypeCode,
+		ClientID:            spa.ID,
+		Scope:               "openid",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: models.CodeChallengeS256,
+	}
+}
+
+func (u *unitTestSuit) TestAuthorizationCode() {
+	s := newService()
+	req := request()
+
+	code, err := s.Authorize(context.Background(), req, &user)
+	u.Require().NoError(err)
+	u.Equal(spa.RedirectURIs[0], req.RedirectURI, "the only registered redirect uri is the default")
+
+	td, err := s.Exchange(context.Background(), &spa, code, req.RedirectURI, verifier)
+	u.Require().NoError(err)
+	u.Equal(spa.ID, td.ClientID)
+	u.Equal("openid", td.Scope)
+
+	_, err = s.Exchange(context.Background(), &spa, code, req.RedirectURI, verifier)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidGrant, ""), "codes are single use")
+
+	rotated, err := s.Refresh(context.Background(), &spa, td.RefreshToken)
+	u.Require().NoError(err)
+	u.Equal("openid", rotated.Scope, "refresh must keep the scope")
+
+	_, err = s.Refresh(context.Background(), &other, rotated.RefreshToken)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidGrant, ""), "refresh tokens are bound to the client")
+	_, err = s.Refresh(context.Background(), &billing, rotated.RefreshToken)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthUnauthorizedClient, ""), "the client must be registered for the refresh_token grant")
+}
+
+func (u *unitTestSuit) TestOpenIDConnect() {
+	s := newService()
+	req := request()
+	req.Scope = "openid profile"
+	req.Nonce = "n-0S6_WzA2Mj"
+
+	code, err := s.Authorize(context.Background(), req, &user)
+	u.Require().NoError(err)
+	td, err := s.Exchange(context.Background(), &spa, code, req.RedirectURI, verifier)
+	u.Require().NoError(err)
+	u.Require().NotEmpty(td.IDToken)
+
+	claims := &auth_service.IDClaims{}
+	_, err = jwt.ParseWithClaims(td.IDToken, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("secret"), nil
+	})
+	u.Require().NoError(err)
+	u.Equal(user.ID.Hex(), claims.Subject)
+	u.Equal(spa.ID, claims.Audience, "the client is the audience")
+	u.Equal(req.Nonce, claims.Nonce)
+	u.Equal(user.Username, claims.PreferredUsername)
+	u.Equal(user.FirstName, claims.GivenName)
+	u.Equal(user.LastName, claims.FamilyName)
+	u.Empty(claims.Email, "the email scope was not granted")
+
+	info, err := s.UserInfo(context.Background(), td.AccessToken)
+	u.Require().NoError(err)
+	u.Equal(&models.UserInfo{
+		Subject:           user.ID.Hex(),
+		PreferredUsername: user.Username,
+		GivenName:         user.FirstName,
+		FamilyName:        user.LastName,
+	}, info)
+
+	rotated, err := s.Refresh(context.Background(), &spa, td.RefreshToken)
+	u.Require().NoError(err)
+	u.NotEmpty(rotated.IDToken, "refresh must issue a new id token")
+
+	_, err = s.UserInfo(context.Background(), td.RefreshToken)
+	u.ErrorIs(err, models.InvalidTokenErr, "refresh tokens are not accepted")
+	_, err = s.UserInfo(context.Background(), "garbage")
+	u.ErrorIs(err, models.InvalidTokenErr)
+}
+
+func (u *unitTestSuit) TestWithoutOpenIDScope() {
+	s := newService()
+	req := request()
+	req.Scope = "profile"
+
+	code, err := s.Authorize(context.Background(), req, &user)
+	u.Require().NoError(err)
+	td, err := s.Exchange(context.Background(), &spa, code, req.RedirectURI, verifier)
+	u.Require().NoError(err)
+	u.Empty(td.IDToken)
+
+	_, err = s.UserInfo(context.Background(), td.AccessToken)
+	u.ErrorIs(err, oauth_service.InsufficientScopeErr)
+}
+
+func (u *unitTestSuit) TestClientCredentials() {
+	s := newService()
+
+	td, err := s.ClientCredentials(context.Background(), &billing, "")
+	u.Require().NoError(err)
+	u.Equal("invoices:read invoices:write", td.Scope, "openid needs a user")
+	u.Empty(td.RefreshToken)
+	u.Empty(td.IDToken)
+
+	td, err = s.ClientCredentials(context.Background(), &billing, "invoices:read")
+	u.Require().NoError(err)
+	u.Equal("invoices:read", td.Scope)
+
+	_, err = s.ClientCredentials(context.Background(), &billing, "openid")
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidScope, ""))
+	_, err = s.ClientCredentials(context.Background(), &billing, "admin")
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidScope, ""))
+
+	_, err = s.ClientCredentials(context.Background(), &spa, "")
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthUnauthorizedClient, ""), "public clients have no identity")
+
+	req := request()
+	req.ClientID = billing.ID
+	req.RedirectURI = ""
+	_, err = s.ValidateAuthorization(context.Background(), req)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthUnauthorizedClient, ""), "grant types are enforced")
+}
+
+func (u *unitTestSuit) TestExchangeRejects() {
+	s := newService()
+	invalidGrant := models.NewOAuthError(models.OAuthInvalidGrant, "")
+
+	code, err := s.Authorize(context.Background(), request(), &user)
+	u.Require().NoError(err)
+	_, err = s.Exchange(context.Background(), &spa, code, spa.RedirectURIs[0], verifier[1:]+"x")
+	u.ErrorIs(err, invalidGrant, "wrong verifier")
+	_, err = s.Exchange(context.Background(), &spa, code, spa.RedirectURIs[0], verifier)
+	u.ErrorIs(err, invalidGrant, "a failed attempt must burn the code")
+
+	code, err = s.Authorize(context.Background(), request(), &user)
+	u.Require().NoError(err)
+	_, err = s.Exchange(context.Background(), &other, code, spa.RedirectURIs[0], verifier)
+	u.ErrorIs(err, invalidGrant, "code of another client")
+
+	code, err = s.Authorize(context.Background(), request(), &user)
+	u.Require().NoError(err)
+	_, err = s.Exchange(context.Background(), &spa, code, "https://evil.example.com/callback", verifier)
+	u.ErrorIs(err, invalidGrant, "redirect uri mismatch")
+
+	_, err = s.Exchange(context.Background(), &spa, code, spa.RedirectURIs[0], "short")
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidRequest, ""))
+}
+
+func (u *unitTestSuit) TestValidateAuthorization() {
+	s := newService()
+
+	req := request()
+	req.ClientID = "unknown"
+	_, err := s.ValidateAuthorization(context.Background(), req)
+	u.ErrorIs(err, oauth_service.UnknownClientErr)
+
+	req = request()
+	req.RedirectURI = "https://evil.example.com/callback"
+	_, err = s.ValidateAuthorization(context.Background(), req)
+	u.ErrorIs(err, oauth_service.InvalidRedirectURIErr)
+
+	req = request()
+	req.CodeChallenge = ""
+	_, err = s.ValidateAuthorization(context.Background(), req)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidRequest, ""), "pkce is mandatory")
+
+	req = request()
+	req.CodeChallengeMethod = "plain"
+	_, err = s.ValidateAuthorization(context.Background(), req)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidRequest, ""))
+
+	req = request()
+	req.Scope = "openid admin"
+	_, err = s.ValidateAuthorization(context.Background(), req)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidScope, ""))
+
+	req = request()
+	req.Scope = ""
+	_, err = s.ValidateAuthorization(context.Background(), req)
+	u.NoError(err)
+	u.Equal("openid profile", req.Scope)
+}
+
+func (u *unitTestSuit) TestDeviceFlow() {
+	s := newService()
+	ctx := context.Background()
+
+	authorization, err := s.DeviceAuthorization(ctx, &cli, "openid")
+	u.Require().NoError(err)
+	u.Regexp("^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$", authorization.UserCode)
+	u.Equal("https://auth.example.com/oauth/device?user_code="+authorization.UserCode, authorization.VerificationURIComplete)
+	u.EqualValues(60, authorization.ExpiresIn)
+
+	_, err = s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthAuthorizationPending, ""))
+	_, err = s.DeviceToken(ctx, &spa, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidGrant, ""), "device code of another client")
+
+	// typed by the user without the dash and in lower case
+	typed := strings.ToLower(strings.Replace(authorization.UserCode, "-", " ", 1))
+	grant, err := s.DeviceGrant(ctx, typed)
+	u.Require().NoError(err)
+	u.Equal(cli.ID, grant.ClientID)
+	u.Equal("openid", grant.Scope)
+
+	err = s.DecideDevice(ctx, typed, &user, true)
+	u.Require().NoError(err)
+	err = s.DecideDevice(ctx, typed, &user, false)
+	u.ErrorIs(err, oauth_service.InvalidUserCodeErr, "a decision is final")
+
+	td, err := s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.Require().NoError(err)
+	u.Equal(cli.ID, td.ClientID)
+	u.NotEmpty(td.IDToken)
+
+	_, err = s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidGrant, ""), "device codes are single use")
+}
+
+func (u *unitTestSuit) TestDeviceFlowDenied() {
+	s := newService()
+	ctx := context.Background()
+
+	authorization, err := s.DeviceAuthorization(ctx, &cli, "")
+	u.Require().NoError(err)
+
+	err = s.DecideDevice(ctx, authorization.UserCode, &user, false)
+	u.Require().NoError(err)
+
+	_, err = s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthAccessDenied, ""))
+	_, err = s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthInvalidGrant, ""))
+
+	_, err = s.DeviceGrant(ctx, "BCDF-GHJ1")
+	u.ErrorIs(err, oauth_service.InvalidUserCodeErr)
+	_, err = s.DeviceAuthorization(ctx, &spa, "")
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthUnauthorizedClient, ""), "grant types are enforced")
+}
+
+func (u *unitTestSuit) TestDeviceFlowSlowDown() {
+	s := newServiceWith(oauth_service.Settings{
+		DeviceCodeLifeTime: time.Minute,
+		DeviceInterval:     5 * time.Second,
+	})
+	ctx := context.Background()
+
+	authorization, err := s.DeviceAuthorization(ctx, &cli, "")
+	u.Require().NoError(err)
+	u.EqualValues(5, authorization.Interval)
+
+	_, err = s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthAuthorizationPending, ""))
+	_, err = s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthSlowDown, ""))
+}
+
+func (u *unitTestSuit) TestDeviceFlowExpired() {
+	s := newServiceWith(oauth_service.Settings{})
+	ctx := context.Background()
+
+	authorization, err := s.DeviceAuthorization(ctx, &cli, "")
+	u.Require().NoError(err)
+
+	_, err = s.DeviceToken(ctx, &cli, authorization.DeviceCode)
+	u.ErrorIs(err, models.NewOAuthError(models.OAuthExpiredToken, ""))
+	_, err = s.DeviceGrant(ctx, authorization.UserCode)
+	u.ErrorIs(err, oauth_service.InvalidUserCodeErr)
+}
@@ -0,0 +1,153 @@
+package organization_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"regexp"
+	"sort"
+	"time"
+)
+
+type organizationService struct {
+	orgs        interfaces.OrganizationRepo
+	memberships interfaces.MembershipRepo
+	users       interfaces.UserRepo
+	roles       interfaces.RoleRepo
+}
+
+var (
+	InvalidOrganizationIDErr = errors.New("organization id must be a lowercase slug")
+	UnknownRoleErr           = errors.New("unknown role")
+	// GlobalRoleErr rejects membership roles granting permissions beyond
+	// the organization, such as managing every user.
+	GlobalRoleErr = errors.New("role grants permissions outside the organization")
+)
+
+var slug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+func New(orgs interfaces.OrganizationRepo, memberships interfaces.MembershipRepo, users interfaces.UserRepo, roles interfaces.RoleRepo) *organizationService {
+	return &organizationService{
+		orgs:        orgs,
+		memberships: memberships,
+		users:       users,
+		roles:       roles,
+	}
+}
+
+// Create registers the organization, its id is the tenant claim of the tokens issued for it.
+func (ors *organizationService) Create(ctx context.Context, org *models.Organization) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if !slug.MatchString(org.ID) {
+		return InvalidOrganizationIDErr
+	}
+
+	org.CreatedAt = time.Now()
+
+	return ors.orgs.Insert(ctx, org)
+}
+
+func (ors *organizationService) Get(ctx context.Context, id string) (*models.Organization, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return ors.orgs.Get(ctx, id)
+}
+
+func (ors *organizationService) Members(ctx context.Context, orgID string) ([]*models.Membership, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if _, err := ors.orgs.Get(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	return ors.memberships.GetByOrganization(ctx, orgID)
+}
+
+// Invite makes the user a member of the organization with the given roles,
+// replacing the roles when it already is one. Only roles granting tenant
+// permissions can be held as a member. The username is looked up among the
+// accounts of the organization first and the global ones second.
+func (ors *organizationService) Invite(ctx context.Context, orgID, username string, roles []string) (*models.Membership, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if _, err := ors.orgs.Get(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	roles = unique(roles)
+	defined, err := ors.roles.GetMany(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(defined) != len(roles) {
+		found := make(map[string]struct{}, len(defined))
+		for _, role := range defined {
+			found[role.Name] = struct{}{}
+		}
+		for _, name := range roles {
+			if _, ok := found[name]; !ok {
+				return nil, fmt.Errorf("%w: %s", UnknownRoleErr, name)
+			}
+		}
+	}
+	for _, role := range defined {
+		for _, permission := range role.Permissions {
+			if !models.IsTenantPermission(permission) {
+				return nil, fmt.Errorf("%w: %s grants %s", GlobalRoleErr, role.Name, permission)
+			}
+		}
+	}
+
+	user, err := ors.users.GetByName(ctx, orgID, username)
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		user, err = ors.users.GetByName(ctx, "", username)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	membership := &models.Membership{
+		OrganizationID: orgID,
+		UserID:         user.ID,
+		Roles:          roles,
+		CreatedAt:      time.Now(),
+	}
+	if err := ors.memberships.Save(ctx, membership); err != nil {
+		return nil, err
+	}
+
+	return ors.memberships.Get(ctx, orgID, user.ID.Hex())
+}
+
+// Remove ends the membership. Sessions started for the organization stay
+// usable until their access token expires, the next refresh is rejected.
+func (ors *organizationService) Remove(ctx context.Context, orgID, userID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return ors.memberships.Delete(ctx, orgID, userID)
+}
+
+func unique(values []string) []string {
+	set := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, ok := set[value]; ok {
+			continue
+		}
+		set[value] = struct{}{}
+		result = append(result, value)
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,106 @@
+package organization_service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/organization_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+var (
+	user = models.User{
+		ID:       primitive.NewObjectID(),
+		Username: "test123",
+	}
+	acme = &models.Organization{
+		ID:   "acme",
+		Name: "Acme",
+	}
+	orgAdmin = &models.Role{
+		Name:        "org-admin",
+		Permissions: []string{models.PermissionMembersRead, models.PermissionMembersWrite, models.PermissionUsersCreate},
+	}
+)
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func (u *unitTestSuit) TestCreate() {
+	orgs := repositories.NewMemoryOrganizationRepo()
+	ors := organization_service.New(orgs, repositories.NewMemoryMembershipRepo(), new(repositories.MockUserRepository), repositories.NewMemoryRoleRepo())
+
+	err := ors.Create(context.Background(), &models.Organization{ID: "acme", Name: "Acme"})
+	u.Require().NoError(err)
+
+	org, err := orgs.Get(context.Background(), "acme")
+	u.Require().NoError(err)
+	u.False(org.CreatedAt.IsZero())
+
+	err = ors.Create(context.Background(), &models.Organization{ID: "acme"})
+	u.ErrorIs(err, repositories.DuplicateOrganizationErr)
+
+	err = ors.Create(context.Background(), &models.Organization{ID: "Acme Inc"})
+	u.ErrorIs(err, organization_service.InvalidOrganizationIDErr)
+}
+
+func (u *unitTestSuit) TestInvite() {
+	stored := user
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", acme.ID, stored.Username).Return(nil, repositories.NotFoundUserErr)
+	r.On("GetByName", "", stored.Username).Return(&stored)
+
+	memberships := repositories.NewMemoryMembershipRepo()
+	ors := organization_service.New(repositories.NewMemoryOrganizationRepo(acme), memberships, r, repositories.NewMemoryRoleRepo(orgAdmin))
+
+	membership, err := ors.Invite(context.Background(), acme.ID, stored.Username, []string{"org-admin", "org-admin"})
+	u.Require().NoError(err)
+	u.Equal(stored.ID, membership.UserID)
+	u.Equal([]string{"org-admin"}, membership.Roles)
+
+	members, err := ors.Members(context.Background(), acme.ID)
+	u.NoError(err)
+	u.Len(members, 1)
+
+	// inviting again replaces the roles
+	membership, err = ors.Invite(context.Background(), acme.ID, stored.Username, nil)
+	u.Require().NoError(err)
+	u.Empty(membership.Roles)
+	r.AssertExpectations(u.T())
+}
+
+func (u *unitTestSuit) TestInviteUnknown() {
+	r := new(repositories.MockUserRepository)
+	ors := organization_service.New(repositories.NewMemoryOrganizationRepo(acme), repositories.NewMemoryMembershipRepo(), r, repositories.NewMemoryRoleRepo(orgAdmin))
+
+	_, err := ors.Invite(context.Background(), "globex", user.Username, nil)
+	u.ErrorIs(err, repositories.NotFoundOrganizationErr)
+
+	_, err = ors.Invite(context.Background(), acme.ID, user.Username, []string{"root"})
+	u.ErrorIs(err, organization_service.UnknownRoleErr)
+
+	admin := &models.Role{Name: "admin", Permissions: []string{models.PermissionMembersRead, models.PermissionUsersWrite}}
+	ors = organization_service.New(repositories.NewMemoryOrganizationRepo(acme), repositories.NewMemoryMembershipRepo(), r, repositories.NewMemoryRoleRepo(orgAdmin, admin))
+	_, err = ors.Invite(context.Background(), acme.ID, user.Username, []string{"org-admin", "admin"})
+	u.ErrorIs(err, organization_service.GlobalRoleErr, "members must not get global permissions")
+
+	r.AssertNotCalled(u.T(), "GetByName", acme.ID, user.Username)
+}
+
+func (u *unitTestSuit) TestRemove() {
+	memberships := repositories.NewMemoryMembershipRepo(&models.Membership{OrganizationID: acme.ID, UserID: user.ID})
+	ors := organization_service.New(repositories.NewMemoryOrganizationRepo(acme), memberships, new(repositories.MockUserRepository), repositories.NewMemoryRoleRepo())
+
+	err := ors.Remove(context.Background(), acme.ID, user.ID.Hex())
+	u.NoError(err)
+
+	err = ors.Remove(context.Background(), acme.ID, user.ID.Hex())
+	u.ErrorIs(err, repositories.NotFoundMembershipErr)
+}
@@ -0,0 +1,213 @@
+package password_reset_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+	"time"
+)
+
+// InvalidTokenErr is returned for reset tokens that are unknown, expired or
+// already used.
+var InvalidTokenErr = errors.New("password reset token is invalid or expired")
+
+const (
+	// tokenBytes is the entropy of reset tokens.
+	tokenBytes = 32
+	// deliveryTimeout bounds creating and sending a link after Forgot returned.
+	deliveryTimeout = 30 * time.Second
+	// defaultMaxPending bounds the links created and sent at once when
+	// Settings.MaxPending is not set.
+	defaultMaxPending = 16
+)
+
+// Settings describes the reset links. URL is the page a link opens, it gets
+// the token in the token query parameter. Links are valid for LifeTime. At
+// most MaxPending links are created and sent at once, requests beyond are
+// dropped.
+type Settings struct {
+	URL        string
+	LifeTime   time.Duration
+	MaxPending int
+}
+
+type passwordResetService struct {
+	resets   interfaces.PasswordResetRepo
+	users    interfaces.UserRepo
+	sessions interfaces.SessionService
+	notifier interfaces.Notifier
+	events   interfaces.SecurityEvents
+	logger   *zerolog.Logger
+	url      *url.URL
+	lifeTime time.Duration
+	// pending holds a slot for every link being created and sent
+	pending chan struct{}
+	now     func() time.Time
+}
+
+func New(resets interfaces.PasswordResetRepo, users interfaces.UserRepo, sessions interfaces.SessionService, notifier interfaces.Notifier, events interfaces.SecurityEvents, logger *zerolog.Logger, settings Settings) (*passwordResetService, error) {
+	link, err := url.Parse(settings.URL)
+	if err != nil || !link.IsAbs() {
+		return nil, fmt.Errorf("password reset url %q must be absolute", settings.URL)
+	}
+
+	maxPending := settings.MaxPending
+	if maxPending <= 0 {
+		maxPending = defaultMaxPending
+	}
+
+	return &passwordResetService{
+		resets:   resets,
+		users:    users,
+		sessions: sessions,
+		notifier: notifier,
+		events:   events,
+		logger:   logger,
+		url:      link,
+		lifeTime: settings.LifeTime,
+		pending:  make(chan struct{}, maxPending),
+		now:      time.Now,
+	}, nil
+}
+
+// Forgot sends a reset link to the account the login would find, among the
+// accounts of the tenant first and the global ones second. The link is
+// created and sent in the background and Forgot returns at once for every
+// username, so neither the answer nor its timing tells which accounts exist.
+// While MaxPending links are on their way further requests are dropped.
+// Failures are logged with the request id.
+func (s *passwordResetService) Forgot(ctx context.Context, tenant, username string) {
+	_, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	logger := s.logger.With().
+		Str("request-id", utils.RequestID(ctx)).
+		Str("trace.id", span.SpanContext().TraceID().String()).
+		Logger()
+
+	select {
+	case s.pending <- struct{}{}:
+	default:
+		logger.Warn().Msg("password reset dropped, too many pending")
+		return
+	}
+
+	// the request context ends with the response, the delivery keeps its trace only
+	background := trace.ContextWithSpanContext(context.Background(), span.SpanContext())
+	go func() {
+		defer func() { <-s.pending }()
+
+		ctx, cancel := context.WithTimeout(background, deliveryTimeout)
+		defer cancel()
+
+		if err := s.forgot(ctx, tenant, username); err != nil {
+			logger.Error().Err(err).Msg("password reset delivery failed")
+		}
+	}()
+}
+
+// forgot creates and sends the link, unknown usernames succeed without one.
+func (s *passwordResetService) forgot(ctx context.Context, tenant, username string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	user, err := s.users.GetByName(ctx, tenant, username)
+	if errors.Is(err, repositories.NotFoundUserErr) && tenant != "" {
+		user, err = s.users.GetByName(ctx, "", username)
+	}
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get user error: %w", err)
+	}
+
+	token, err := utils.RandomToken(tokenBytes)
+	if err != nil {
+		return fmt.Errorf("generate password reset token error: %w", err)
+	}
+
+	now := s.now()
+	reset := &models.PasswordReset{
+		ID:        utils.HashToken(token),
+		UserID:    user.ID.Hex(),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.lifeTime),
+	}
+	if err := s.resets.Create(ctx, reset); err != nil {
+		return fmt.Errorf("create password reset error: %w", err)
+	}
+
+	if err := s.notifier.SendPasswordReset(ctx, user, s.link(token), reset.ExpiresAt); err != nil {
+		return fmt.Errorf("send password reset error: %w", err)
+	}
+
+	return nil
+}
+
+// Reset sets the password with the token of a reset link. Every pending
+// reset and every session of the user ends, whoever knew the old password
+// is logged out.
+func (s *passwordResetService) Reset(ctx context.Context, token, password string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	reset, err := s.resets.Consume(ctx, utils.HashToken(token))
+	if errors.Is(err, repositories.NotFoundPasswordResetErr) {
+		return InvalidTokenErr
+	}
+	if err != nil {
+		return fmt.Errorf("consume password reset error: %w", err)
+	}
+	if !s.now().Before(reset.ExpiresAt) {
+		return InvalidTokenErr
+	}
+
+	user, err := s.users.Get(ctx, reset.UserID)
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		return InvalidTokenErr
+	}
+	if err != nil {
+		return fmt.Errorf("get user error: %w", err)
+	}
+
+	user.Password = utils.GetHash([]byte(password))
+	if err := s.users.UpdatePassword(ctx, user); err != nil {
+		return fmt.Errorf("update password error: %w", err)
+	}
+
+	if err := s.resets.DeleteByUser(ctx, reset.UserID); err != nil {
+		return fmt.Errorf("delete password resets error: %w", err)
+	}
+	if err := s.sessions.RevokeAll(ctx, reset.UserID, ""); err != nil {
+		return fmt.Errorf("revoke sessions error: %w", err)
+	}
+
+	s.events.Emit(ctx, &models.SecurityEvent{
+		Type:   models.SecurityEventPasswordReset,
+		UserID: reset.UserID,
+		Time:   s.now(),
+		Metadata: map[string]string{
+			"ip":         utils.ClientInfo(ctx).IP,
+			"user_agent": utils.ClientInfo(ctx).UserAgent,
+		},
+	})
+
+	return nil
+}
+
+func (s *passwordResetService) link(token string) string {
+	link := *s.url
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return link.String()
+}
@@ -0,0 +1,255 @@
+package password_reset_service_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/password_reset_service"
+	"gitlab.com/g6834/team17/auth-service/internal/services/session_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const userName = "test123"
+
+var settings = password_reset_service.Settings{
+	URL:      "https://app.example.com/reset?lang=en",
+	LifeTime: time.Hour,
+}
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+type sentLink struct {
+	user      *models.User
+	link      string
+	expiresAt time.Time
+}
+
+// recordedNotifier passes on the links sent in the background.
+type recordedNotifier struct {
+	sent chan sentLink
+}
+
+func newRecordedNotifier() *recordedNotifier {
+	return &recordedNotifier{sent: make(chan sentLink, 10)}
+}
+
+func (n *recordedNotifier) SendPasswordReset(_ context.Context, user *models.User, link string, expiresAt time.Time) error {
+	n.sent <- sentLink{user: user, link: link, expiresAt: expiresAt}
+	return nil
+}
+
+func (u *unitTestSuit) next(n *recordedNotifier) sentLink {
+	select {
+	case sent := <-n.sent:
+		return sent
+	case <-time.After(time.Second):
+		u.FailNow("no password reset link sent")
+		return sentLink{}
+	}
+}
+
+type recordedEvents struct {
+	events []*models.SecurityEvent
+}
+
+func (r *recordedEvents) Emit(_ context.Context, event *models.SecurityEvent) {
+	r.events = append(r.events, event)
+}
+
+type fixture struct {
+	user     *models.User
+	users    *repositories.MockUserRepository
+	resets   *repositories.MemoryPasswordResetRepo
+	sessions *repositories.MemorySessionRepo
+	notifier *recordedNotifier
+	events   *recordedEvents
+	logger   zerolog.Logger
+	settings password_reset_service.Settings
+}
+
+func newFixture() *fixture {
+	user := &models.User{
+		ID:       primitive.NewObjectID(),
+		Username: userName,
+		Email:    "user123@ya.ru",
+		Password: utils.GetHash([]byte("qwerty")),
+	}
+
+	users := new(repositories.MockUserRepository)
+	users.On("GetByName", "", userName).Return(user)
+	users.On("GetByName", "acme", userName).Return(nil, repositories.NotFoundUserErr)
+	users.On("GetByName", "", "unknown").Return(nil, repositories.NotFoundUserErr)
+	users.On("Get", user.ID.Hex()).Return(user)
+	users.On("UpdatePassword", mock.Anything).Return(nil)
+
+	return &fixture{
+		user:     user,
+		users:    users,
+		resets:   repositories.NewMemoryPasswordResetRepo(),
+		sessions: repositories.NewMemorySessionRepo(),
+		notifier: newRecordedNotifier(),
+		events:   &recordedEvents{},
+		logger:   zerolog.Nop(),
+		settings: settings,
+	}
+}
+
+func (f *fixture) service() (interface {
+	Forgot(ctx context.Context, tenant, username string)
+	Reset(ctx context.Context, token, password string) error
+}, error) {
+	sessions := session_service.New(f.sessions, repositories.NewMemoryTokenFamilyRepo(), repositories.NewMemoryRevocationRepo())
+
+	return password_reset_service.New(f.resets, f.users, sessions, f.notifier, f.events, &f.logger, f.settings)
+}
+
+func token(link string) string {
+	parsed, _ := url.Parse(link)
+
+	return parsed.Query().Get("token")
+}
+
+func (u *unitTestSuit) TestReset() {
+	f := newFixture()
+	s, err := f.service()
+	u.Require().NoError(err)
+	ctx := context.Background()
+
+	now := time.Now()
+	u.Require().NoError(f.sessions.Create(ctx, &models.Session{ID: "s1", UserID: f.user.ID.Hex(), CreatedAt: now, LastSeenAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+	// a tenant without the account falls back to the global accounts as the login does
+	s.Forgot(ctx, "acme", userName)
+	sent := u.next(f.notifier)
+	u.Equal(f.user.ID, sent.user.ID)
+	u.WithinDuration(now.Add(time.Hour), sent.expiresAt, time.Minute)
+
+	link, err := url.Parse(sent.link)
+	u.Require().NoError(err)
+	u.Equal("app.example.com", link.Host)
+	u.Equal("en", link.Query().Get("lang"), "the query of the url is kept")
+	u.NotEmpty(token(sent.link))
+
+	u.Require().NoError(s.Reset(ctx, token(sent.link), "qwerty123"))
+	f.users.AssertCalled(u.T(), "UpdatePassword", mock.MatchedBy(func(user *models.User) bool {
+		return utils.CheckPassword([]byte("qwerty123"), []byte(user.Password)) == nil
+	}))
+
+	active, err := f.sessions.GetByUser(ctx, f.user.ID.Hex())
+	u.Require().NoError(err)
+	u.Empty(active, "every session is logged out")
+
+	u.Require().Len(f.events.events, 1)
+	u.Equal(models.SecurityEventPasswordReset, f.events.events[0].Type)
+
+	u.ErrorIs(s.Reset(ctx, token(sent.link), "qwerty456"), password_reset_service.InvalidTokenErr, "a token works once")
+}
+
+func (u *unitTestSuit) TestResetDropsOtherLinks() {
+	f := newFixture()
+	s, err := f.service()
+	u.Require().NoError(err)
+	ctx := context.Background()
+
+	s.Forgot(ctx, "", userName)
+	first := u.next(f.notifier)
+	s.Forgot(ctx, "", userName)
+	second := u.next(f.notifier)
+
+	u.Require().NoError(s.Reset(ctx, token(second.link), "qwerty123"))
+	u.ErrorIs(s.Reset(ctx, token(first.link), "qwerty456"), password_reset_service.InvalidTokenErr)
+}
+
+func (u *unitTestSuit) TestForgotUnknownUser() {
+	f := newFixture()
+	looked := make(chan struct{})
+	f.users = new(repositories.MockUserRepository)
+	f.users.On("GetByName", "", "unknown").Return(nil, repositories.NotFoundUserErr).Run(func(mock.Arguments) {
+		close(looked)
+	})
+	s, err := f.service()
+	u.Require().NoError(err)
+
+	s.Forgot(context.Background(), "", "unknown")
+	select {
+	case <-looked:
+	case <-time.After(time.Second):
+		u.FailNow("the username was not looked up")
+	}
+	u.Never(func() bool { return len(f.notifier.sent) > 0 }, 50*time.Millisecond, 10*time.Millisecond, "unknown usernames get no link")
+}
+
+func (u *unitTestSuit) TestForgotReturnsAtOnce() {
+	f := newFixture()
+	f.notifier = &recordedNotifier{sent: make(chan sentLink)}
+	s, err := f.service()
+	u.Require().NoError(err)
+
+	// the notifier blocks until the link is taken, the answer must not wait for it
+	s.Forgot(context.Background(), "", userName)
+	u.NotEmpty(token(u.next(f.notifier).link))
+}
+
+func (u *unitTestSuit) TestForgotDropsBeyondMaxPending() {
+	f := newFixture()
+	f.notifier = &recordedNotifier{sent: make(chan sentLink)}
+	f.settings.MaxPending = 1
+	var logged bytes.Buffer
+	f.logger = zerolog.New(&logged)
+	s, err := f.service()
+	u.Require().NoError(err)
+
+	// the first link waits in the notifier and holds the only slot
+	s.Forgot(context.Background(), "", userName)
+	s.Forgot(context.WithValue(context.Background(), constants.CTX_REQUEST_ID, "req-2"), "", userName)
+	u.Contains(logged.String(), `"request-id":"req-2"`, "dropped requests are logged with their request id")
+
+	u.NotEmpty(token(u.next(f.notifier).link))
+	select {
+	case <-f.notifier.sent:
+		u.Fail("the dropped request got a link")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func (u *unitTestSuit) TestResetInvalidToken() {
+	f := newFixture()
+	s, err := f.service()
+	u.Require().NoError(err)
+	ctx := context.Background()
+
+	u.ErrorIs(s.Reset(ctx, "not-a-token", "qwerty123"), password_reset_service.InvalidTokenErr)
+
+	expired := "expired-token"
+	u.Require().NoError(f.resets.Create(ctx, &models.PasswordReset{
+		ID:        utils.HashToken(expired),
+		UserID:    f.user.ID.Hex(),
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	}))
+	u.ErrorIs(s.Reset(ctx, expired, "qwerty123"), password_reset_service.InvalidTokenErr)
+	f.users.AssertNotCalled(u.T(), "UpdatePassword", mock.Anything)
+}
+
+func (u *unitTestSuit) TestInvalidURL() {
+	logger := zerolog.Nop()
+	for _, link := range []string{"", "/reset", "://"} {
+		_, err := password_reset_service.New(repositories.NewMemoryPasswordResetRepo(), new(repositories.MockUserRepository), nil, newRecordedNotifier(), &recordedEvents{}, &logger, password_reset_service.Settings{URL: link})
+		u.Error(err, link)
+	}
+}
@@ -0,0 +1,110 @@
+package personal_token_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+var (
+	NotFoundPersonalTokenErr = errors.New("personal access token not found")
+	// ScopeNotHeldErr is returned for scopes naming permissions the user
+	// does not hold, a token cannot grant more than its owner has.
+	ScopeNotHeldErr    = errors.New("scope is not a permission of the user")
+	InvalidLifeTimeErr = errors.New("personal access token lifetime is out of range")
+)
+
+const (
+	tokenBytes = 32
+	// prefixLength is how much of the secret is kept in clear to tell
+	// tokens apart.
+	prefixLength = 8
+)
+
+type Settings struct {
+	// MaxLifeTime bounds how long a token may be valid.
+	MaxLifeTime time.Duration
+}
+
+type personalTokenService struct {
+	tokens   interfaces.PersonalTokenRepo
+	settings Settings
+	now      func() time.Time
+}
+
+// New needs the same token store as the auth service, which accepts the
+// tokens.
+func New(tokens interfaces.PersonalTokenRepo, settings Settings) *personalTokenService {
+	return &personalTokenService{
+		tokens:   tokens,
+		settings: settings,
+		now:      time.Now,
+	}
+}
+
+// Create issues a token of the user for the tenant of the user's current
+// login. The scopes are permissions the user holds now.
+func (s *personalTokenService) Create(ctx context.Context, user *models.User, name string, scopes []string, lifeTime time.Duration) (*models.PersonalAccessToken, string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if lifeTime <= 0 || lifeTime > s.settings.MaxLifeTime {
+		return nil, "", fmt.Errorf("%w: at most %v", InvalidLifeTimeErr, s.settings.MaxLifeTime)
+	}
+	for _, scope := range scopes {
+		if !user.HasPermission(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ScopeNotHeldErr, scope)
+		}
+	}
+
+	secret, err := utils.RandomToken(tokenBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate personal access token error: %w", err)
+	}
+	secret = models.PersonalTokenPrefix + secret
+
+	now := s.now()
+	token := &models.PersonalAccessToken{
+		ID:        primitive.NewObjectID().Hex(),
+		UserID:    user.ID.Hex(),
+		Tenant:    user.Tenant,
+		Name:      name,
+		Prefix:    secret[:len(models.PersonalTokenPrefix)+prefixLength],
+		Hash:      utils.HashToken(secret),
+		Scopes:    append([]string{}, scopes...),
+		CreatedAt: now,
+		ExpiresAt: now.Add(lifeTime),
+	}
+	if err := s.tokens.Create(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("create personal access token error: %w", err)
+	}
+
+	return token, secret, nil
+}
+
+func (s *personalTokenService) GetAll(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return s.tokens.GetByUser(ctx, userID)
+}
+
+// Revoke deletes a token of the user, it is rejected from the next request
+// on. Tokens of other users are reported as not found.
+func (s *personalTokenService) Revoke(ctx context.Context, userID, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	err := s.tokens.Delete(ctx, userID, id)
+	if errors.Is(err, repositories.NotFoundPersonalTokenErr) {
+		return NotFoundPersonalTokenErr
+	}
+
+	return err
+}
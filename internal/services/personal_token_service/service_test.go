@@ -0,0 +1,87 @@
+package personal_token_service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/personal_token_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+	"time"
+)
+
+var user = &models.User{
+	ID:          primitive.NewObjectID(),
+	Username:    "test123",
+	Tenant:      "acme",
+	Permissions: []string{models.PermissionUsersRead},
+}
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func newService(tokens *repositories.MemoryPersonalTokenRepo) interface {
+	Create(ctx context.Context, user *models.User, name string, scopes []string, lifeTime time.Duration) (*models.PersonalAccessToken, string, error)
+	GetAll(ctx context.Context, userID string) ([]*models.PersonalAccessToken, error)
+	Revoke(ctx context.Context, userID, id string) error
+} {
+	return personal_token_service.New(tokens, personal_token_service.Settings{MaxLifeTime: 30 * 24 * time.Hour})
+}
+
+func (u *unitTestSuit) TestCreate() {
+	tokens := repositories.NewMemoryPersonalTokenRepo()
+	s := newService(tokens)
+	ctx := context.Background()
+
+	token, secret, err := s.Create(ctx, user, "ci deploy", []string{models.PermissionUsersRead}, 24*time.Hour)
+	u.Require().NoError(err)
+	u.True(strings.HasPrefix(secret, models.PersonalTokenPrefix))
+	u.True(strings.HasPrefix(secret, token.Prefix))
+	u.Len(token.Prefix, len(models.PersonalTokenPrefix)+8)
+	u.Equal(user.ID.Hex(), token.UserID)
+	u.Equal("acme", token.Tenant, "token acts for the organization of the login")
+	u.WithinDuration(time.Now().Add(24*time.Hour), token.ExpiresAt, time.Second)
+
+	stored, err := tokens.GetByHash(ctx, utils.HashToken(secret))
+	u.Require().NoError(err, "token must be found by its hash")
+	u.Equal(token.ID, stored.ID)
+	u.NotContains(stored.Hash, secret)
+
+	_, _, err = s.Create(ctx, user, "admin", []string{models.PermissionUsersDelete}, 24*time.Hour)
+	u.ErrorIs(err, personal_token_service.ScopeNotHeldErr)
+
+	_, _, err = s.Create(ctx, user, "forever", nil, 365*24*time.Hour)
+	u.ErrorIs(err, personal_token_service.InvalidLifeTimeErr)
+}
+
+func (u *unitTestSuit) TestGetAllAndRevoke() {
+	s := newService(repositories.NewMemoryPersonalTokenRepo())
+	ctx := context.Background()
+
+	first, _, err := s.Create(ctx, user, "first", nil, time.Hour)
+	u.Require().NoError(err)
+	second, _, err := s.Create(ctx, user, "second", nil, time.Hour)
+	u.Require().NoError(err)
+
+	tokens, err := s.GetAll(ctx, user.ID.Hex())
+	u.Require().NoError(err)
+	u.Len(tokens, 2)
+
+	u.ErrorIs(s.Revoke(ctx, primitive.NewObjectID().Hex(), first.ID), personal_token_service.NotFoundPersonalTokenErr,
+		"tokens of other users are not found")
+	u.NoError(s.Revoke(ctx, user.ID.Hex(), first.ID))
+	u.ErrorIs(s.Revoke(ctx, user.ID.Hex(), first.ID), personal_token_service.NotFoundPersonalTokenErr)
+
+	tokens, err = s.GetAll(ctx, user.ID.Hex())
+	u.Require().NoError(err)
+	u.Require().Len(tokens, 1)
+	u.Equal(second.ID, tokens[0].ID)
+}
@@ -0,0 +1,184 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"sync"
+	"time"
+)
+
+// policyService keeps the rules in memory, they are read again refresh after
+// the last read and right after a change through the service. Changes made
+// by other replicas apply within refresh.
+type policyService struct {
+	rules   interfaces.PolicyRepo
+	refresh time.Duration
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	cached   []*models.PolicyRule
+	loadedAt time.Time
+	// version counts the changes, rules read before a change are not cached
+	version uint64
+}
+
+var (
+	ActionRequiredErr   = errors.New("action is required")
+	ResourceRequiredErr = errors.New("resource is required")
+	InvalidRuleErr      = errors.New("invalid policy rule")
+)
+
+func New(rules interfaces.PolicyRepo, refresh time.Duration) *policyService {
+	return &policyService{
+		rules:   rules,
+		refresh: refresh,
+		now:     time.Now,
+	}
+}
+
+// Check decides whether subject may perform action on resource. A matching
+// deny rule wins over any allow rule, without a matching rule the request is
+// denied. The decision carries the first deciding rule in id order.
+func (ps *policyService) Check(ctx context.Context, subject *models.User, action, resource string) (*models.PolicyDecision, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if action == "" {
+		return nil, ActionRequiredErr
+	}
+	if resource == "" {
+		return nil, ResourceRequiredErr
+	}
+
+	rules, err := ps.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var allow *models.PolicyRule
+	for _, rule := range rules {
+		if !appliesTo(rule, subject) || !matchAny(rule.Actions, action) || !matchAny(rule.Resources, resource) {
+			continue
+		}
+
+		switch rule.Effect {
+		case models.PolicyEffectDeny:
+			return &models.PolicyDecision{Allowed: false, Rule: rule}, nil
+		case models.PolicyEffectAllow:
+			if allow == nil {
+				allow = rule
+			}
+		}
+	}
+
+	return &models.PolicyDecision{Allowed: allow != nil, Rule: allow}, nil
+}
+
+// GetAll returns the stored rules ordered by id.
+func (ps *policyService) GetAll(ctx context.Context) ([]*models.PolicyRule, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return ps.rules.GetAll(ctx)
+}
+
+// Save creates or replaces the rule, checks use it right away.
+func (ps *policyService) Save(ctx context.Context, rule *models.PolicyRule) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	switch {
+	case rule.ID == "":
+		return fmt.Errorf("%w: id is required", InvalidRuleErr)
+	case rule.Effect != models.PolicyEffectAllow && rule.Effect != models.PolicyEffectDeny:
+		return fmt.Errorf("%w: effect must be %s or %s", InvalidRuleErr, models.PolicyEffectAllow, models.PolicyEffectDeny)
+	case len(rule.Actions) == 0:
+		return fmt.Errorf("%w: actions are required", InvalidRuleErr)
+	case len(rule.Resources) == 0:
+		return fmt.Errorf("%w: resources are required", InvalidRuleErr)
+	}
+
+	if err := ps.rules.Save(ctx, rule); err != nil {
+		return err
+	}
+	ps.invalidate()
+
+	return nil
+}
+
+func (ps *policyService) Delete(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if err := ps.rules.Delete(ctx, id); err != nil {
+		return err
+	}
+	ps.invalidate()
+
+	return nil
+}
+
+func (ps *policyService) load(ctx context.Context) ([]*models.PolicyRule, error) {
+	ps.mu.RLock()
+	rules, loadedAt, version := ps.cached, ps.loadedAt, ps.version
+	ps.mu.RUnlock()
+
+	now := ps.now()
+	if rules != nil && now.Sub(loadedAt) < ps.refresh {
+		return rules, nil
+	}
+
+	rules, err := ps.rules.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.mu.Lock()
+	if ps.version == version {
+		ps.cached, ps.loadedAt = rules, now
+	}
+	ps.mu.Unlock()
+
+	return rules, nil
+}
+
+func (ps *policyService) invalidate() {
+	ps.mu.Lock()
+	ps.cached = nil
+	ps.version++
+	ps.mu.Unlock()
+}
+
+func appliesTo(rule *models.PolicyRule, subject *models.User) bool {
+	if len(rule.Roles) == 0 && len(rule.Permissions) == 0 {
+		return true
+	}
+
+	return intersects(rule.Roles, subject.Roles) || intersects(rule.Permissions, subject.Permissions)
+}
+
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if utils.MatchGlob(pattern, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+package policy_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/policy"
+	"testing"
+	"time"
+)
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+var (
+	editor = &models.User{
+		Username: "test123",
+		Roles:    []string{"editor"},
+	}
+	editDocuments = &models.PolicyRule{
+		ID:        "edit-documents",
+		Effect:    models.PolicyEffectAllow,
+		Roles:     []string{"editor"},
+		Actions:   []string{"documents:*"},
+		Resources: []string{"projects/*/documents/*"},
+	}
+	protectArchive = &models.PolicyRule{
+		ID:        "protect-archive",
+		Effect:    models.PolicyEffectDeny,
+		Actions:   []string{"documents:delete"},
+		Resources: []string{"projects/archive/*"},
+	}
+	readUsers = &models.PolicyRule{
+		ID:          "read-users",
+		Effect:      models.PolicyEffectAllow,
+		Permissions: []string{models.PermissionUsersRead},
+		Actions:     []string{"users:read"},
+		Resources:   []string{"*"},
+	}
+)
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func (u *unitTestSuit) TestAllow() {
+	ps := policy.New(repositories.NewMemoryPolicyRepo(editDocuments, protectArchive, readUsers), time.Minute)
+
+	decision, err := ps.Check(context.Background(), editor, "documents:write", "projects/42/documents/7")
+
+	u.NoError(err)
+	u.True(decision.Allowed)
+	u.Equal(editDocuments.ID, decision.Rule.ID)
+}
+
+func (u *unitTestSuit) TestDenyWins() {
+	ps := policy.New(repositories.NewMemoryPolicyRepo(editDocuments, protectArchive), time.Minute)
+
+	decision, err := ps.Check(context.Background(), editor, "documents:delete", "projects/archive/documents/7")
+
+	u.NoError(err)
+	u.False(decision.Allowed)
+	u.Equal(protectArchive.ID, decision.Rule.ID)
+}
+
+func (u *unitTestSuit) TestDefaultDeny() {
+	ps := policy.New(repositories.NewMemoryPolicyRepo(editDocuments, readUsers), time.Minute)
+
+	decision, err := ps.Check(context.Background(), editor, "users:read", "users/1")
+	u.NoError(err)
+	u.False(decision.Allowed)
+	u.Nil(decision.Rule)
+
+	decision, err = ps.Check(context.Background(), editor, "documents:write", "projects/42/reports/7")
+	u.NoError(err)
+	u.False(decision.Allowed)
+}
+
+func (u *unitTestSuit) TestPermissionSubject() {
+	ps := policy.New(repositories.NewMemoryPolicyRepo(readUsers), time.Minute)
+	reader := &models.User{Permissions: []string{models.PermissionUsersRead}}
+
+	decision, err := ps.Check(context.Background(), reader, "users:read", "users/1")
+
+	u.NoError(err)
+	u.True(decision.Allowed)
+	u.Equal(readUsers.ID, decision.Rule.ID)
+}
+
+func (u *unitTestSuit) TestRequired() {
+	ps := policy.New(repositories.NewMemoryPolicyRepo(), time.Minute)
+
+	_, err := ps.Check(context.Background(), editor, "", "users/1")
+	u.ErrorIs(err, policy.ActionRequiredErr)
+
+	_, err = ps.Check(context.Background(), editor, "users:read", "")
+	u.ErrorIs(err, policy.ResourceRequiredErr)
+}
+
+// countingRepo counts the reads reaching the store.
+type countingRepo struct {
+	*repositories.MemoryPolicyRepo
+	reads int
+}
+
+func (r *countingRepo) GetAll(ctx context.Context) ([]*models.PolicyRule, error) {
+	r.reads++
+	return r.MemoryPolicyRepo.GetAll(ctx)
+}
+
+func (u *unitTestSuit) TestCachedRules() {
+	repo := &countingRepo{MemoryPolicyRepo: repositories.NewMemoryPolicyRepo(editDocuments)}
+	ps := policy.New(repo, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		decision, err := ps.Check(ctx, editor, "documents:delete", "projects/archive/documents/7")
+		u.Require().NoError(err)
+		u.True(decision.Allowed)
+	}
+	u.Equal(1, repo.reads, "checks share the cached rules")
+
+	u.Require().NoError(ps.Save(ctx, protectArchive))
+	decision, err := ps.Check(ctx, editor, "documents:delete", "projects/archive/documents/7")
+	u.Require().NoError(err)
+	u.False(decision.Allowed, "a saved rule applies right away")
+
+	u.Require().NoError(ps.Delete(ctx, protectArchive.ID))
+	decision, err = ps.Check(ctx, editor, "documents:delete", "projects/archive/documents/7")
+	u.Require().NoError(err)
+	u.True(decision.Allowed, "a deleted rule stops applying right away")
+
+	u.ErrorIs(ps.Delete(ctx, protectArchive.ID), repositories.NotFoundPolicyRuleErr)
+}
+
+func (u *unitTestSuit) TestInvalidRule() {
+	ps := policy.New(repositories.NewMemoryPolicyRepo(), time.Minute)
+
+	for name, rule := range map[string]*models.PolicyRule{
+		"no id":        {Effect: models.PolicyEffectAllow, Actions: []string{"*"}, Resources: []string{"*"}},
+		"bad effect":   {ID: "a", Effect: "maybe", Actions: []string{"*"}, Resources: []string{"*"}},
+		"no actions":   {ID: "a", Effect: models.PolicyEffectAllow, Resources: []string{"*"}},
+		"no resources": {ID: "a", Effect: models.PolicyEffectDeny, Actions: []string{"*"}},
+	} {
+		u.ErrorIs(ps.Save(context.Background(), rule), policy.InvalidRuleErr, name)
+	}
+}
@@ -0,0 +1,171 @@
+package rate_limit_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+var InvalidRuleErr = errors.New("invalid rate limit rule")
+
+type rateLimitService struct {
+	counters interfaces.RateLimitRepo
+	rules    []models.RateLimitRule
+	now      func() time.Time
+}
+
+// New checks the rules, requests are counted in the store, which must be
+// shared by all instances for the limits to hold across replicas.
+func New(counters interfaces.RateLimitRepo, rules []models.RateLimitRule) (*rateLimitService, error) {
+	names := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		if err := validate(rule); err != nil {
+			return nil, err
+		}
+		if _, ok := names[rule.Name]; ok {
+			return nil, fmt.Errorf("%w: rule %q is defined twice", InvalidRuleErr, rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+	}
+
+	return &rateLimitService{
+		counters: counters,
+		rules:    rules,
+		now:      time.Now,
+	}, nil
+}
+
+// Limit counts the request against every rule matching it, in order, and
+// rejects it at the first spent limit. Limits slide: the requests of the
+// previous period count as much as the period overlaps the last Period.
+// Rejected requests count too, so clients ignoring Retry-After stay limited.
+// Requests are let through when the store fails, an outage of the counters
+// must not take logins down.
+func (s *rateLimitService) Limit(ctx context.Context, request *models.RateLimitRequest, kinds ...string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	now := s.now()
+	for _, rule := range s.rules {
+		if !contains(kinds, rule.By) || !matches(rule, request) {
+			continue
+		}
+		subject, ok := subject(rule.By, request)
+		if !ok {
+			continue
+		}
+
+		key := rule.Name + ":" + subject
+		window := now.Truncate(rule.Period)
+		current, err := s.counters.Hit(ctx, key, window, window.Add(2*rule.Period))
+		if err != nil {
+			log.Println(fmt.Errorf("count request error: %w", err))
+			continue
+		}
+		previous, err := s.counters.Count(ctx, key, window.Add(-rule.Period))
+		if err != nil {
+			log.Println(fmt.Errorf("count request error: %w", err))
+		}
+
+		elapsed := now.Sub(window)
+		overlap := 1 - float64(elapsed)/float64(rule.Period)
+		if float64(previous)*overlap+float64(current) > float64(rule.Limit) {
+			return &models.RateLimitedError{
+				Rule:       rule.Name,
+				Limit:      rule.Limit,
+				Period:     rule.Period,
+				RetryAfter: retryAfter(rule, previous, current, elapsed),
+			}
+		}
+	}
+
+	return nil
+}
+
+// retryAfter is how long until one more request fits the limit, when no
+// other request comes.
+func retryAfter(rule models.RateLimitRule, previous, current int, elapsed time.Duration) time.Duration {
+	period := float64(rule.Period)
+
+	var wait float64
+	if current < rule.Limit {
+		// the previous period has to slide out far enough
+		overlap := float64(rule.Limit-current-1) / float64(previous)
+		wait = (1-overlap)*period - float64(elapsed)
+	} else {
+		// this period has to end and then slide out far enough
+		overlap := float64(rule.Limit-1) / float64(current)
+		wait = period - float64(elapsed) + (1-overlap)*period
+	}
+
+	return time.Duration(math.Max(wait, float64(time.Second)))
+}
+
+func validate(rule models.RateLimitRule) error {
+	switch {
+	case rule.Name == "":
+		return fmt.Errorf("%w: name is required", InvalidRuleErr)
+	case rule.Limit <= 0 || rule.Period <= 0:
+		return fmt.Errorf("%w: rule %q needs a positive limit and period", InvalidRuleErr, rule.Name)
+	case (rule.Path == "") == (rule.GRPCMethod == ""):
+		return fmt.Errorf("%w: rule %q needs either a path or a gRPC method", InvalidRuleErr, rule.Name)
+	case rule.By == models.RateLimitByUser && rule.Path == "":
+		return fmt.Errorf("%w: rule %q counts by user, which only REST requests know", InvalidRuleErr, rule.Name)
+	case rule.By != models.RateLimitByIP && rule.By != models.RateLimitByUser && rule.By != models.RateLimitByRoute:
+		return fmt.Errorf("%w: rule %q must count by %s, %s or %s", InvalidRuleErr, rule.Name, models.RateLimitByIP, models.RateLimitByUser, models.RateLimitByRoute)
+	}
+
+	return nil
+}
+
+func matches(rule models.RateLimitRule, request *models.RateLimitRequest) bool {
+	if rule.Path != "" {
+		return request.Path != "" &&
+			(rule.Method == "" || strings.EqualFold(rule.Method, request.Method)) &&
+			matchPattern(rule.Path, request.Path)
+	}
+
+	return request.GRPCMethod != "" && matchPattern(rule.GRPCMethod, request.GRPCMethod)
+}
+
+// matchPattern matches the value itself, or with a pattern ending in /*
+// the values below it.
+func matchPattern(pattern, value string) bool {
+	if !strings.HasSuffix(pattern, "/*") {
+		return pattern == value
+	}
+
+	prefix := strings.TrimSuffix(pattern, "*")
+
+	return strings.HasPrefix(value, prefix) || value == strings.TrimSuffix(prefix, "/")
+}
+
+// subject is what the rule counts the request by, anonymous requests and
+// unknown addresses are not counted by user or by ip.
+func subject(by string, request *models.RateLimitRequest) (string, bool) {
+	switch by {
+	case models.RateLimitByIP:
+		return request.IP, request.IP != ""
+	case models.RateLimitByUser:
+		return request.UserID, request.UserID != ""
+	default:
+		return "", true
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,130 @@
+package rate_limit_service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/rate_limit_service"
+	"testing"
+	"time"
+)
+
+// day keeps the counting windows of a test in one period
+const day = 24 * time.Hour
+
+var all = []string{models.RateLimitByIP, models.RateLimitByUser, models.RateLimitByRoute}
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+type failingRepo struct{}
+
+func (failingRepo) Hit(context.Context, string, time.Time, time.Time) (int, error) {
+	return 0, errors.New("store is down")
+}
+
+func (failingRepo) Count(context.Context, string, time.Time) (int, error) {
+	return 0, errors.New("store is down")
+}
+
+func limited(err error) *models.RateLimitedError {
+	var limited *models.RateLimitedError
+	if errors.As(err, &limited) {
+		return limited
+	}
+
+	return nil
+}
+
+func (u *unitTestSuit) TestLimitByIP() {
+	s, err := rate_limit_service.New(repositories.NewMemoryRateLimitRepo(), []models.RateLimitRule{
+		{Name: "login", Method: "POST", Path: "/v1/auth/login/*", By: models.RateLimitByIP, Limit: 3, Period: day},
+	})
+	u.Require().NoError(err)
+	ctx := context.Background()
+
+	login := &models.RateLimitRequest{Method: "POST", Path: "/v1/auth/login", IP: "192.168.0.1"}
+	for i := 0; i < 3; i++ {
+		u.Require().NoError(s.Limit(ctx, login, all...))
+	}
+
+	l := limited(s.Limit(ctx, &models.RateLimitRequest{Method: "POST", Path: "/v1/auth/login/mfa", IP: "192.168.0.1"}, all...))
+	u.Require().NotNil(l, "paths below the pattern share the limit")
+	u.Equal("login", l.Rule)
+	u.Equal(3, l.Limit)
+	u.Greater(l.RetryAfter, time.Duration(0))
+	u.LessOrEqual(l.RetryAfter, 2*day)
+
+	u.NoError(s.Limit(ctx, &models.RateLimitRequest{Method: "POST", Path: "/v1/auth/login", IP: "10.0.0.1"}, all...), "other addresses have their own limit")
+	u.NoError(s.Limit(ctx, &models.RateLimitRequest{Method: "GET", Path: "/v1/auth/login", IP: "192.168.0.1"}, all...), "other methods do not match")
+	u.NoError(s.Limit(ctx, &models.RateLimitRequest{Method: "POST", Path: "/v1/auth/logout", IP: "192.168.0.1"}, all...), "other paths do not match")
+	u.NoError(s.Limit(ctx, login, models.RateLimitByUser), "rules counting by other kinds are skipped")
+}
+
+func (u *unitTestSuit) TestLimitByUserAndRoute() {
+	s, err := rate_limit_service.New(repositories.NewMemoryRateLimitRepo(), []models.RateLimitRule{
+		{Name: "api", Path: "/v1/*", By: models.RateLimitByUser, Limit: 1, Period: day},
+		{Name: "export", Path: "/v1/admin/users", By: models.RateLimitByRoute, Limit: 1, Period: day},
+	})
+	u.Require().NoError(err)
+	ctx := context.Background()
+
+	u.Require().NoError(s.Limit(ctx, &models.RateLimitRequest{Method: "GET", Path: "/v1/user", UserID: "a"}, all...))
+	u.NotNil(limited(s.Limit(ctx, &models.RateLimitRequest{Method: "GET", Path: "/v1/orgs", UserID: "a"}, all...)))
+	u.NoError(s.Limit(ctx, &models.RateLimitRequest{Method: "GET", Path: "/v1/orgs"}, all...), "anonymous requests are not counted by user")
+
+	u.Require().NoError(s.Limit(ctx, &models.RateLimitRequest{Method: "GET", Path: "/v1/admin/users", UserID: "b", IP: "10.0.0.1"}, all...))
+	l := limited(s.Limit(ctx, &models.RateLimitRequest{Method: "GET", Path: "/v1/admin/users", UserID: "c", IP: "10.0.0.2"}, all...))
+	u.Require().NotNil(l, "all callers of the route share its limit")
+	u.Equal("export", l.Rule)
+}
+
+func (u *unitTestSuit) TestLimitGRPC() {
+	s, err := rate_limit_service.New(repositories.NewMemoryRateLimitRepo(), []models.RateLimitRule{
+		{Name: "grpc", GRPCMethod: "/auth.auth_service.v1.AuthService/*", By: models.RateLimitByIP, Limit: 1, Period: day},
+	})
+	u.Require().NoError(err)
+	ctx := context.Background()
+
+	call := &models.RateLimitRequest{GRPCMethod: "/auth.auth_service.v1.AuthService/Validate", IP: "10.0.0.1"}
+	u.Require().NoError(s.Limit(ctx, call, all...))
+	u.NotNil(limited(s.Limit(ctx, call, all...)))
+	u.NoError(s.Limit(ctx, &models.RateLimitRequest{Method: "POST", Path: "/auth.auth_service.v1.AuthService/Validate", IP: "10.0.0.1"}, all...), "gRPC rules do not match REST requests")
+}
+
+func (u *unitTestSuit) TestFailOpen() {
+	s, err := rate_limit_service.New(failingRepo{}, []models.RateLimitRule{
+		{Name: "all", Path: "/*", By: models.RateLimitByRoute, Limit: 1, Period: day},
+	})
+	u.Require().NoError(err)
+
+	for i := 0; i < 3; i++ {
+		u.NoError(s.Limit(context.Background(), &models.RateLimitRequest{Method: "GET", Path: "/v1/user"}, all...))
+	}
+}
+
+func (u *unitTestSuit) TestInvalidRules() {
+	for name, rules := range map[string][]models.RateLimitRule{
+		"no name":      {{Path: "/*", By: models.RateLimitByIP, Limit: 1, Period: time.Second}},
+		"no limit":     {{Name: "a", Path: "/*", By: models.RateLimitByIP, Period: time.Second}},
+		"no period":    {{Name: "a", Path: "/*", By: models.RateLimitByIP, Limit: 1}},
+		"no target":    {{Name: "a", By: models.RateLimitByIP, Limit: 1, Period: time.Second}},
+		"two targets":  {{Name: "a", Path: "/*", GRPCMethod: "/*", By: models.RateLimitByIP, Limit: 1, Period: time.Second}},
+		"unknown kind": {{Name: "a", Path: "/*", By: "tenant", Limit: 1, Period: time.Second}},
+		"grpc user":    {{Name: "a", GRPCMethod: "/*", By: models.RateLimitByUser, Limit: 1, Period: time.Second}},
+		"duplicate": {
+			{Name: "a", Path: "/*", By: models.RateLimitByIP, Limit: 1, Period: time.Second},
+			{Name: "a", Path: "/v1/*", By: models.RateLimitByIP, Limit: 1, Period: time.Second},
+		},
+	} {
+		_, err := rate_limit_service.New(repositories.NewMemoryRateLimitRepo(), rules)
+		u.ErrorIs(err, rate_limit_service.InvalidRuleErr, name)
+	}
+}
@@ -0,0 +1,145 @@
+package redirect_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var RedirectNotAllowedErr = errors.New("redirect uri is not allowed")
+
+// pattern is an allowed redirect target. Host may start with *. to allow any
+// subdomain, an empty path allows every path and * in the path matches any
+// run of characters.
+type pattern struct {
+	scheme string
+	host   string
+	path   string
+}
+
+type redirectService struct {
+	patterns []pattern
+	clients  interfaces.ClientRepo
+	events   interfaces.SecurityEvents
+	now      func() time.Time
+}
+
+// New builds the allowlist from patterns such as https://app.example.com or
+// https://*.example.com/callback/*. Relative paths on this service are always
+// allowed.
+func New(patterns []string, clients interfaces.ClientRepo, events interfaces.SecurityEvents) (*redirectService, error) {
+	parsed := make([]pattern, 0, len(patterns))
+	for _, raw := range patterns {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("redirect pattern %q: %w", raw, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("redirect pattern %q must be an http(s) origin with an optional path", raw)
+		}
+
+		p := pattern{scheme: u.Scheme, host: strings.ToLower(u.Host), path: u.Path}
+		if p.path == "/" {
+			p.path = ""
+		}
+		parsed = append(parsed, p)
+	}
+
+	return &redirectService{
+		patterns: parsed,
+		clients:  clients,
+		events:   events,
+		now:      time.Now,
+	}, nil
+}
+
+// Check accepts relative paths, URIs matching the allowlist and, when the
+// request names an OAuth client, the redirect URIs registered for it.
+// Rejections are reported as security events.
+func (rs *redirectService) Check(ctx context.Context, redirectURI, clientID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	allowed, err := rs.allowed(ctx, redirectURI, clientID)
+	if err != nil {
+		return err
+	}
+	if allowed {
+		return nil
+	}
+
+	client := utils.ClientInfo(ctx)
+	rs.events.Emit(ctx, &models.SecurityEvent{
+		Type: models.SecurityEventRedirectRejected,
+		Time: rs.now(),
+		Metadata: map[string]string{
+			"redirect_uri": redirectURI,
+			"client_id":    clientID,
+			"ip":           client.IP,
+			"user_agent":   client.UserAgent,
+		},
+	})
+
+	return RedirectNotAllowedErr
+}
+
+func (rs *redirectService) allowed(ctx context.Context, redirectURI, clientID string) (bool, error) {
+	// browsers treat backslashes as slashes, /\evil.com is protocol relative
+	if redirectURI == "" || strings.ContainsAny(redirectURI, "\\\r\n\t") {
+		return false, nil
+	}
+
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return false, nil
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(u.Path, "/") && !strings.HasPrefix(redirectURI, "//"), nil
+	}
+	if u.User != nil {
+		return false, nil
+	}
+
+	for _, p := range rs.patterns {
+		if p.matches(u) {
+			return true, nil
+		}
+	}
+
+	if clientID == "" {
+		return false, nil
+	}
+	client, err := rs.clients.Get(ctx, clientID)
+	if errors.Is(err, repositories.NotFoundClientErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return client.HasRedirectURI(redirectURI), nil
+}
+
+func (p pattern) matches(u *url.URL) bool {
+	if u.Scheme != p.scheme {
+		return false
+	}
+
+	host := strings.ToLower(u.Host)
+	if strings.HasPrefix(p.host, "*.") {
+		if !strings.HasSuffix(host, p.host[1:]) {
+			return false
+		}
+	} else if host != p.host {
+		return false
+	}
+
+	return p.path == "" || utils.MatchGlob(p.path, u.Path)
+}
@@ -0,0 +1,97 @@
+package redirect_service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/redirect_service"
+	"testing"
+)
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+type recordedEvents struct {
+	events []*models.SecurityEvent
+}
+
+func (r *recordedEvents) Emit(_ context.Context, event *models.SecurityEvent) {
+	r.events = append(r.events, event)
+}
+
+func (u *unitTestSuit) newService(events *recordedEvents) interface {
+	Check(ctx context.Context, redirectURI, clientID string) error
+} {
+	clients := new(repositories.MockClientRepository)
+	clients.On("Get", "grafana").Return(&models.Client{
+		ID:           "grafana",
+		RedirectURIs: []string{"https://grafana.example.org/login/generic_oauth"},
+	})
+	clients.On("Get", "unknown").Return(nil, repositories.NotFoundClientErr)
+
+	s, err := redirect_service.New([]string{
+		"https://app.example.com",
+		"https://*.example.com/callback/*",
+	}, clients, events)
+	u.Require().NoError(err)
+
+	return s
+}
+
+func (u *unitTestSuit) TestAllowed() {
+	s := u.newService(&recordedEvents{})
+
+	for _, uri := range []string{
+		"/oauth/authorize?client_id=web",
+		"https://app.example.com",
+		"https://app.example.com/any/path?x=1",
+		"https://APP.example.com/",
+		"https://team.example.com/callback/done",
+	} {
+		u.NoError(s.Check(context.Background(), uri, ""), uri)
+	}
+
+	u.NoError(s.Check(context.Background(), "https://grafana.example.org/login/generic_oauth", "grafana"), "registered for the client")
+}
+
+func (u *unitTestSuit) TestRejected() {
+	events := &recordedEvents{}
+	s := u.newService(events)
+
+	rejected := []string{
+		"https://evil.com",
+		"//evil.com",
+		"/\\evil.com",
+		"http://app.example.com",
+		"https://app.example.com.evil.com",
+		"https://app.example.com@evil.com",
+		"https://user@app.example.com",
+		"https://team.example.com/other",
+		"https://example.com/callback/x",
+		"javascript:alert(1)",
+		"relative/path",
+	}
+	for _, uri := range rejected {
+		u.ErrorIs(s.Check(context.Background(), uri, ""), redirect_service.RedirectNotAllowedErr, uri)
+	}
+
+	u.ErrorIs(s.Check(context.Background(), "https://grafana.example.org/login/generic_oauth", ""), redirect_service.RedirectNotAllowedErr, "client uris need the client id")
+	u.ErrorIs(s.Check(context.Background(), "https://grafana.example.org/login/generic_oauth", "unknown"), redirect_service.RedirectNotAllowedErr)
+
+	u.Require().Len(events.events, len(rejected)+2)
+	u.Equal(models.SecurityEventRedirectRejected, events.events[0].Type)
+	u.Equal("https://evil.com", events.events[0].Metadata["redirect_uri"])
+}
+
+func (u *unitTestSuit) TestInvalidPatterns() {
+	for _, pattern := range []string{"app.example.com", "ftp://app.example.com", "https://app.example.com/?x=1", "/relative"} {
+		_, err := redirect_service.New([]string{pattern}, nil, &recordedEvents{})
+		u.Error(err, pattern)
+	}
+}
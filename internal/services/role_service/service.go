@@ -0,0 +1,174 @@
+package role_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"sort"
+	"time"
+)
+
+type roleService struct {
+	roles interfaces.RoleRepo
+	users interfaces.UserRepo
+}
+
+var (
+	UnknownPermissionErr = errors.New("unknown permission")
+	UnknownRoleErr       = errors.New("unknown role")
+	RoleNameRequiredErr  = errors.New("role name is required")
+)
+
+func New(roles interfaces.RoleRepo, users interfaces.UserRepo) *roleService {
+	return &roleService{
+		roles: roles,
+		users: users,
+	}
+}
+
+func (rs *roleService) GetAll(ctx context.Context) ([]*models.Role, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return rs.roles.GetAll(ctx)
+}
+
+// Save creates or replaces the role definition. Users holding the role get
+// the new permissions with their next access token.
+func (rs *roleService) Save(ctx context.Context, role *models.Role) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if role.Name == "" {
+		return RoleNameRequiredErr
+	}
+
+	known := make(map[string]struct{}, len(models.Permissions))
+	for _, permission := range models.Permissions {
+		known[permission] = struct{}{}
+	}
+	for _, permission := range role.Permissions {
+		if _, ok := known[permission]; !ok {
+			return fmt.Errorf("%w: %s", UnknownPermissionErr, permission)
+		}
+	}
+
+	role.Permissions = unique(role.Permissions)
+	role.UpdatedAt = time.Now()
+
+	return rs.roles.Save(ctx, role)
+}
+
+// Delete removes the role definition. Users keep the role name but it no
+// longer grants any permission.
+func (rs *roleService) Delete(ctx context.Context, name string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return rs.roles.Delete(ctx, name)
+}
+
+// Assign replaces the roles of the user, every role must be defined.
+func (rs *roleService) Assign(ctx context.Context, userID string, names []string) (*models.User, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	names = unique(names)
+	roles, err := rs.roles.GetMany(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(names) {
+		found := make(map[string]struct{}, len(roles))
+		for _, role := range roles {
+			found[role.Name] = struct{}{}
+		}
+		for _, name := range names {
+			if _, ok := found[name]; !ok {
+				return nil, fmt.Errorf("%w: %s", UnknownRoleErr, name)
+			}
+		}
+	}
+
+	user, err := rs.users.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Roles = names
+	if err := rs.users.UpdateRoles(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Bootstrap gives the admin role to the global accounts named in usernames,
+// keeping their other roles. Unknown usernames are skipped and returned in
+// missing, they are granted on a start after the account is created.
+func (rs *roleService) Bootstrap(ctx context.Context, usernames []string) (missing []string, err error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	_, err = rs.roles.Get(ctx, models.RoleAdmin)
+	if errors.Is(err, repositories.NotFoundRoleErr) {
+		return nil, fmt.Errorf("%w: %s", UnknownRoleErr, models.RoleAdmin)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, username := range unique(usernames) {
+		user, err := rs.users.GetByName(ctx, "", username)
+		if errors.Is(err, repositories.NotFoundUserErr) {
+			missing = append(missing, username)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if contains(user.Roles, models.RoleAdmin) {
+			continue
+		}
+		user.Roles = unique(append(user.Roles, models.RoleAdmin))
+		if err := rs.users.UpdateRoles(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
+
+func unique(values []string) []string {
+	set := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, ok := set[value]; ok {
+			continue
+		}
+		set[value] = struct{}{}
+		result = append(result, value)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
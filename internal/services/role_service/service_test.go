@@ -0,0 +1,116 @@
+package role_service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/role_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+var (
+	user = models.User{
+		ID:       primitive.ObjectID{},
+		Username: "test123",
+	}
+	admin = &models.Role{
+		Name:        "admin",
+		Permissions: []string{models.PermissionUsersRead},
+	}
+)
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func (u *unitTestSuit) TestSave() {
+	roles := repositories.NewMemoryRoleRepo()
+	rs := role_service.New(roles, new(repositories.MockUserRepository))
+
+	err := rs.Save(context.Background(), &models.Role{
+		Name:        "support",
+		Permissions: []string{models.PermissionUsersWrite, models.PermissionUsersRead, models.PermissionUsersRead},
+	})
+	u.Require().NoError(err)
+
+	role, err := roles.Get(context.Background(), "support")
+	u.Require().NoError(err)
+	u.Equal([]string{models.PermissionUsersRead, models.PermissionUsersWrite}, role.Permissions)
+	u.False(role.UpdatedAt.IsZero())
+}
+
+func (u *unitTestSuit) TestSaveUnknownPermission() {
+	roles := repositories.NewMemoryRoleRepo()
+	rs := role_service.New(roles, new(repositories.MockUserRepository))
+
+	err := rs.Save(context.Background(), &models.Role{Name: "support", Permissions: []string{"users:everything"}})
+	u.ErrorIs(err, role_service.UnknownPermissionErr)
+
+	err = rs.Save(context.Background(), &models.Role{Permissions: []string{models.PermissionUsersRead}})
+	u.ErrorIs(err, role_service.RoleNameRequiredErr)
+
+	all, err := roles.GetAll(context.Background())
+	u.NoError(err)
+	u.Empty(all)
+}
+
+func (u *unitTestSuit) TestAssign() {
+	stored := user
+	r := new(repositories.MockUserRepository)
+	r.On("Get", stored.ID.Hex()).Return(&stored)
+	r.On("UpdateRoles", &stored).Return(nil)
+
+	rs := role_service.New(repositories.NewMemoryRoleRepo(admin), r)
+
+	result, err := rs.Assign(context.Background(), stored.ID.Hex(), []string{"admin", "admin"})
+
+	u.NoError(err)
+	u.Equal([]string{"admin"}, result.Roles)
+	r.AssertExpectations(u.T())
+}
+
+func (u *unitTestSuit) TestAssignUnknownRole() {
+	r := new(repositories.MockUserRepository)
+
+	rs := role_service.New(repositories.NewMemoryRoleRepo(admin), r)
+
+	_, err := rs.Assign(context.Background(), user.ID.Hex(), []string{"admin", "root"})
+
+	u.ErrorIs(err, role_service.UnknownRoleErr)
+	r.AssertNotCalled(u.T(), "UpdateRoles", &user)
+}
+
+func (u *unitTestSuit) TestBootstrap() {
+	stored := user
+	stored.Roles = []string{"support"}
+	r := new(repositories.MockUserRepository)
+	r.On("GetByName", "", "test123").Return(&stored, nil)
+	r.On("GetByName", "", "ghost").Return(nil, repositories.NotFoundUserErr)
+	r.On("UpdateRoles", &stored).Return(nil)
+
+	rs := role_service.New(repositories.NewMemoryRoleRepo(admin), r)
+
+	missing, err := rs.Bootstrap(context.Background(), []string{"test123", "ghost"})
+
+	u.NoError(err)
+	u.Equal([]string{"ghost"}, missing)
+	u.Equal([]string{"admin", "support"}, stored.Roles)
+	r.AssertExpectations(u.T())
+}
+
+func (u *unitTestSuit) TestBootstrapWithoutAdminRole() {
+	r := new(repositories.MockUserRepository)
+
+	rs := role_service.New(repositories.NewMemoryRoleRepo(), r)
+
+	_, err := rs.Bootstrap(context.Background(), []string{"test123"})
+
+	u.ErrorIs(err, role_service.UnknownRoleErr)
+	r.AssertNotCalled(u.T(), "GetByName", "", "test123")
+}
@@ -0,0 +1,291 @@
+package service_account_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/auth_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"time"
+)
+
+var (
+	NotFoundServiceAccountErr = errors.New("service account not found")
+	NotFoundKeyErr            = errors.New("service account key not found")
+	NameRequiredErr           = errors.New("service account name is required")
+	// UnknownOwnerErr is returned for owners that are not an existing user
+	// or organization.
+	UnknownOwnerErr     = errors.New("unknown service account owner")
+	UnknownRoleErr      = errors.New("unknown role")
+	InvalidPublicKeyErr = errors.New("public key is invalid")
+)
+
+const (
+	apiKeyBytes = 32
+	// prefixLength is how much of the secret is kept in clear to tell keys
+	// apart.
+	prefixLength = 8
+)
+
+type serviceAccountService struct {
+	accounts interfaces.ServiceAccountRepo
+	users    interfaces.UserRepo
+	orgs     interfaces.OrganizationRepo
+	roles    interfaces.RoleRepo
+	now      func() time.Time
+}
+
+// New needs the same account store as the auth service, which accepts the
+// keys and assertions of the accounts.
+func New(accounts interfaces.ServiceAccountRepo, users interfaces.UserRepo, orgs interfaces.OrganizationRepo, roles interfaces.RoleRepo) *serviceAccountService {
+	return &serviceAccountService{
+		accounts: accounts,
+		users:    users,
+		orgs:     orgs,
+		roles:    roles,
+		now:      time.Now,
+	}
+}
+
+// Create registers the account for its owner, a user id or an organization
+// id as OwnerType tells. The account starts without keys.
+func (s *serviceAccountService) Create(ctx context.Context, account *models.ServiceAccount) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	if account.Name == "" {
+		return NameRequiredErr
+	}
+	if err := s.checkOwner(ctx, account.OwnerType, account.OwnerID); err != nil {
+		return err
+	}
+
+	roles, err := s.checkRoles(ctx, account.Roles)
+	if err != nil {
+		return err
+	}
+
+	account.ID = primitive.NewObjectID()
+	account.Roles = roles
+	account.Disabled = false
+	account.DisabledAt = time.Time{}
+	account.Keys = []models.ServiceAccountKey{}
+	account.APIKeys = []models.ServiceAccountAPIKey{}
+	account.CreatedAt = s.now()
+
+	if err := s.accounts.Create(ctx, account); err != nil {
+		return fmt.Errorf("create service account error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceAccountService) Get(ctx context.Context, id string) (*models.ServiceAccount, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	account, err := s.accounts.Get(ctx, id)
+	if errors.Is(err, repositories.NotFoundServiceAccountErr) {
+		return nil, NotFoundServiceAccountErr
+	}
+
+	return account, err
+}
+
+// GetAll lists the accounts of the owner, every account when ownerID is empty.
+func (s *serviceAccountService) GetAll(ctx context.Context, ownerID string) ([]*models.ServiceAccount, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return s.accounts.GetAll(ctx, ownerID)
+}
+
+// SetDisabled disables the account or enables it again. Its API keys and
+// access tokens are rejected from the next request on while it is disabled.
+func (s *serviceAccountService) SetDisabled(ctx context.Context, id string, disabled bool) (*models.ServiceAccount, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	err := s.accounts.SetDisabled(ctx, id, disabled, s.now())
+	if errors.Is(err, repositories.NotFoundServiceAccountErr) {
+		return nil, NotFoundServiceAccountErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// AssignRoles replaces the roles of the account. API keys get the new
+// permissions at once, access tokens when the next one is issued.
+func (s *serviceAccountService) AssignRoles(ctx context.Context, id string, names []string) (*models.ServiceAccount, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	roles, err := s.checkRoles(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.accounts.UpdateRoles(ctx, id, roles)
+	if errors.Is(err, repositories.NotFoundServiceAccountErr) {
+		return nil, NotFoundServiceAccountErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// AddKey registers a PEM encoded public key for the algorithm, the account
+// names it by the returned key id in the kid header of its assertions.
+func (s *serviceAccountService) AddKey(ctx context.Context, id, algorithm, publicKey string) (*models.ServiceAccountKey, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	parsed, err := auth_service.ParsePublicKey([]byte(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidPublicKeyErr, err)
+	}
+	if _, err := auth_service.VerificationMethod(algorithm, parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidPublicKeyErr, err)
+	}
+
+	key := &models.ServiceAccountKey{
+		ID:        primitive.NewObjectID().Hex(),
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+		CreatedAt: s.now(),
+	}
+	err = s.accounts.AddKey(ctx, id, key)
+	if errors.Is(err, repositories.NotFoundServiceAccountErr) {
+		return nil, NotFoundServiceAccountErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("add service account key error: %w", err)
+	}
+
+	return key, nil
+}
+
+func (s *serviceAccountService) DeleteKey(ctx context.Context, id, keyID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return notFound(s.accounts.DeleteKey(ctx, id, keyID))
+}
+
+// CreateAPIKey issues a bearer secret of the account, only its hash is kept.
+func (s *serviceAccountService) CreateAPIKey(ctx context.Context, id, name string) (*models.ServiceAccountAPIKey, string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	secret, err := utils.RandomToken(apiKeyBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("generate service account key error: %w", err)
+	}
+	secret = models.ServiceAccountKeyPrefix + secret
+
+	key := &models.ServiceAccountAPIKey{
+		ID:        primitive.NewObjectID().Hex(),
+		Name:      name,
+		Prefix:    secret[:len(models.ServiceAccountKeyPrefix)+prefixLength],
+		Hash:      utils.HashToken(secret),
+		CreatedAt: s.now(),
+	}
+	err = s.accounts.AddAPIKey(ctx, id, key)
+	if errors.Is(err, repositories.NotFoundServiceAccountErr) {
+		return nil, "", NotFoundServiceAccountErr
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("add service account api key error: %w", err)
+	}
+
+	return key, secret, nil
+}
+
+// DeleteAPIKey revokes the key, it is rejected from the next request on.
+func (s *serviceAccountService) DeleteAPIKey(ctx context.Context, id, keyID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return notFound(s.accounts.DeleteAPIKey(ctx, id, keyID))
+}
+
+func (s *serviceAccountService) checkOwner(ctx context.Context, ownerType, ownerID string) error {
+	var err error
+	switch ownerType {
+	case models.OwnerUser:
+		if _, hexErr := primitive.ObjectIDFromHex(ownerID); hexErr != nil {
+			return fmt.Errorf("%w: user %s", UnknownOwnerErr, ownerID)
+		}
+		_, err = s.users.Get(ctx, ownerID)
+		if errors.Is(err, repositories.NotFoundUserErr) {
+			return fmt.Errorf("%w: user %s", UnknownOwnerErr, ownerID)
+		}
+	case models.OwnerOrganization:
+		_, err = s.orgs.Get(ctx, ownerID)
+		if errors.Is(err, repositories.NotFoundOrganizationErr) {
+			return fmt.Errorf("%w: organization %s", UnknownOwnerErr, ownerID)
+		}
+	default:
+		return fmt.Errorf("%w: owner type must be %s or %s", UnknownOwnerErr, models.OwnerUser, models.OwnerOrganization)
+	}
+
+	return err
+}
+
+// checkRoles returns the names without duplicates once all of them are found.
+func (s *serviceAccountService) checkRoles(ctx context.Context, names []string) ([]string, error) {
+	names = unique(names)
+	roles, err := s.roles.GetMany(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) != len(names) {
+		found := make(map[string]struct{}, len(roles))
+		for _, role := range roles {
+			found[role.Name] = struct{}{}
+		}
+		for _, name := range names {
+			if _, ok := found[name]; !ok {
+				return nil, fmt.Errorf("%w: %s", UnknownRoleErr, name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+func unique(values []string) []string {
+	set := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if _, ok := set[value]; ok {
+			continue
+		}
+		set[value] = struct{}{}
+		result = append(result, value)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func notFound(err error) error {
+	switch {
+	case errors.Is(err, repositories.NotFoundServiceAccountErr):
+		return NotFoundServiceAccountErr
+	case errors.Is(err, repositories.NotFoundServiceAccountKeyErr):
+		return NotFoundKeyErr
+	}
+
+	return err
+}
@@ -0,0 +1,185 @@
+package service_account_service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/service_account_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+)
+
+var owner = &models.User{
+	ID:       primitive.NewObjectID(),
+	Username: "test123",
+}
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+func newService(accounts *repositories.MemoryServiceAccountRepo) interface {
+	Create(ctx context.Context, account *models.ServiceAccount) error
+	Get(ctx context.Context, id string) (*models.ServiceAccount, error)
+	GetAll(ctx context.Context, ownerID string) ([]*models.ServiceAccount, error)
+	SetDisabled(ctx context.Context, id string, disabled bool) (*models.ServiceAccount, error)
+	AssignRoles(ctx context.Context, id string, roles []string) (*models.ServiceAccount, error)
+	AddKey(ctx context.Context, id, algorithm, publicKey string) (*models.ServiceAccountKey, error)
+	DeleteKey(ctx context.Context, id, keyID string) error
+	CreateAPIKey(ctx context.Context, id, name string) (*models.ServiceAccountAPIKey, string, error)
+	DeleteAPIKey(ctx context.Context, id, keyID string) error
+} {
+	users := new(repositories.MockUserRepository)
+	users.On("Get", owner.ID.Hex()).Return(owner)
+	users.On("Get", primitive.NilObjectID.Hex()).Return(nil, repositories.NotFoundUserErr)
+
+	orgs := repositories.NewMemoryOrganizationRepo(&models.Organization{ID: "acme"})
+	roles := repositories.NewMemoryRoleRepo(&models.Role{Name: "admin"})
+
+	return service_account_service.New(accounts, users, orgs, roles)
+}
+
+func (u *unitTestSuit) TestCreate() {
+	accounts := repositories.NewMemoryServiceAccountRepo()
+	s := newService(accounts)
+	ctx := context.Background()
+
+	account := &models.ServiceAccount{Name: "billing-sync", OwnerType: models.OwnerOrganization, OwnerID: "acme", Roles: []string{"admin", "admin"}}
+	u.Require().NoError(s.Create(ctx, account))
+	u.False(account.ID.IsZero())
+	u.Equal([]string{"admin"}, account.Roles)
+	u.Equal("acme", account.Tenant(), "accounts of an organization act for it")
+
+	personal := &models.ServiceAccount{Name: "backup", OwnerType: models.OwnerUser, OwnerID: owner.ID.Hex()}
+	u.Require().NoError(s.Create(ctx, personal))
+	u.Empty(personal.Tenant())
+
+	all, err := s.GetAll(ctx, "")
+	u.Require().NoError(err)
+	u.Len(all, 2)
+	mine, err := s.GetAll(ctx, owner.ID.Hex())
+	u.Require().NoError(err)
+	u.Require().Len(mine, 1)
+	u.Equal("backup", mine[0].Name)
+
+	invalid := map[error]*models.ServiceAccount{
+		service_account_service.NameRequiredErr: {OwnerType: models.OwnerOrganization, OwnerID: "acme"},
+		service_account_service.UnknownOwnerErr: {Name: "x", OwnerType: models.OwnerOrganization, OwnerID: "globex"},
+		service_account_service.UnknownRoleErr:  {Name: "x", OwnerType: models.OwnerOrganization, OwnerID: "acme", Roles: []string{"root"}},
+	}
+	for want, account := range invalid {
+		u.ErrorIs(s.Create(ctx, account), want)
+	}
+	for _, account := range []*models.ServiceAccount{
+		{Name: "x", OwnerType: models.OwnerUser, OwnerID: primitive.NilObjectID.Hex()},
+		{Name: "x", OwnerType: models.OwnerUser, OwnerID: "not-an-id"},
+		{Name: "x", OwnerType: "team", OwnerID: "acme"},
+	} {
+		u.ErrorIs(s.Create(ctx, account), service_account_service.UnknownOwnerErr, account.OwnerID)
+	}
+}
+
+func (u *unitTestSuit) TestDisableAndRoles() {
+	s := newService(repositories.NewMemoryServiceAccountRepo())
+	ctx := context.Background()
+
+	account := &models.ServiceAccount{Name: "billing-sync", OwnerType: models.OwnerOrganization, OwnerID: "acme"}
+	u.Require().NoError(s.Create(ctx, account))
+	id := account.ID.Hex()
+
+	disabled, err := s.SetDisabled(ctx, id, true)
+	u.Require().NoError(err)
+	u.True(disabled.Disabled)
+	u.False(disabled.DisabledAt.IsZero())
+
+	enabled, err := s.SetDisabled(ctx, id, false)
+	u.Require().NoError(err)
+	u.False(enabled.Disabled)
+	u.True(enabled.DisabledAt.IsZero())
+
+	updated, err := s.AssignRoles(ctx, id, []string{"admin"})
+	u.Require().NoError(err)
+	u.Equal([]string{"admin"}, updated.Roles)
+
+	_, err = s.AssignRoles(ctx, id, []string{"root"})
+	u.ErrorIs(err, service_account_service.UnknownRoleErr)
+
+	_, err = s.SetDisabled(ctx, primitive.NewObjectID().Hex(), true)
+	u.ErrorIs(err, service_account_service.NotFoundServiceAccountErr)
+	_, err = s.Get(ctx, "not-an-id")
+	u.ErrorIs(err, service_account_service.NotFoundServiceAccountErr)
+}
+
+func (u *unitTestSuit) TestAPIKeys() {
+	accounts := repositories.NewMemoryServiceAccountRepo()
+	s := newService(accounts)
+	ctx := context.Background()
+
+	account := &models.ServiceAccount{Name: "billing-sync", OwnerType: models.OwnerOrganization, OwnerID: "acme"}
+	u.Require().NoError(s.Create(ctx, account))
+	id := account.ID.Hex()
+
+	key, secret, err := s.CreateAPIKey(ctx, id, "production")
+	u.Require().NoError(err)
+	u.True(strings.HasPrefix(secret, models.ServiceAccountKeyPrefix))
+	u.True(strings.HasPrefix(secret, key.Prefix))
+	u.Equal(utils.HashToken(secret), key.Hash, "only the hash is stored")
+
+	found, err := accounts.GetByAPIKey(ctx, utils.HashToken(secret))
+	u.Require().NoError(err)
+	u.Equal(account.ID, found.ID)
+
+	u.Require().NoError(s.DeleteAPIKey(ctx, id, key.ID))
+	_, err = accounts.GetByAPIKey(ctx, utils.HashToken(secret))
+	u.ErrorIs(err, repositories.NotFoundServiceAccountErr)
+	u.ErrorIs(s.DeleteAPIKey(ctx, id, key.ID), service_account_service.NotFoundKeyErr)
+
+	_, _, err = s.CreateAPIKey(ctx, primitive.NewObjectID().Hex(), "production")
+	u.ErrorIs(err, service_account_service.NotFoundServiceAccountErr)
+}
+
+func (u *unitTestSuit) TestKeys() {
+	s := newService(repositories.NewMemoryServiceAccountRepo())
+	ctx := context.Background()
+
+	account := &models.ServiceAccount{Name: "billing-sync", OwnerType: models.OwnerOrganization, OwnerID: "acme"}
+	u.Require().NoError(s.Create(ctx, account))
+	id := account.ID.Hex()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	u.Require().NoError(err)
+	der, err := x509.MarshalPKIXPublicKey(private.Public())
+	u.Require().NoError(err)
+	public := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	key, err := s.AddKey(ctx, id, "ES256", public)
+	u.Require().NoError(err)
+	u.NotEmpty(key.ID)
+
+	stored, err := s.Get(ctx, id)
+	u.Require().NoError(err)
+	_, ok := stored.Key(key.ID)
+	u.True(ok)
+
+	for _, algorithm := range []string{"HS256", "ES384", "RS256", "none"} {
+		_, err = s.AddKey(ctx, id, algorithm, public)
+		u.ErrorIs(err, service_account_service.InvalidPublicKeyErr, algorithm)
+	}
+	_, err = s.AddKey(ctx, id, "ES256", "not a key")
+	u.ErrorIs(err, service_account_service.InvalidPublicKeyErr)
+
+	u.Require().NoError(s.DeleteKey(ctx, id, key.ID))
+	u.ErrorIs(s.DeleteKey(ctx, id, key.ID), service_account_service.NotFoundKeyErr)
+}
@@ -0,0 +1,94 @@
+package session_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+)
+
+type sessionService struct {
+	sessions    interfaces.SessionRepo
+	families    interfaces.TokenFamilyRepo
+	revocations interfaces.RevocationRepo
+}
+
+var NotFoundSessionErr = errors.New("session not found")
+
+// New needs the same family and revocation stores as the auth service, so
+// revoked sessions are rejected by token validation.
+func New(sessions interfaces.SessionRepo, families interfaces.TokenFamilyRepo, revocations interfaces.RevocationRepo) *sessionService {
+	return &sessionService{
+		sessions:    sessions,
+		families:    families,
+		revocations: revocations,
+	}
+}
+
+func (ss *sessionService) GetAll(ctx context.Context, userID string) ([]*models.Session, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return ss.sessions.GetByUser(ctx, userID)
+}
+
+// Revoke ends one session of the user. Sessions of other users are reported
+// as not found.
+func (ss *sessionService) Revoke(ctx context.Context, userID, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	session, err := ss.sessions.Get(ctx, id)
+	if errors.Is(err, repositories.NotFoundSessionErr) || err == nil && session.UserID != userID {
+		return NotFoundSessionErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return ss.revoke(ctx, session)
+}
+
+// RevokeAll ends every session of the user except the one with exceptID,
+// which may be empty to log out everywhere.
+func (ss *sessionService) RevokeAll(ctx context.Context, userID, exceptID string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	sessions, err := ss.sessions.GetByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if session.ID == exceptID {
+			continue
+		}
+		if err := ss.revoke(ctx, session); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revoke blocks both the session id, which every token of the session
+// carries, and its refresh token family.
+func (ss *sessionService) revoke(ctx context.Context, session *models.Session) error {
+	if err := ss.revocations.Revoke(ctx, session.ID, session.ExpiresAt); err != nil {
+		return fmt.Errorf("revoke session tokens error: %w", err)
+	}
+
+	if err := ss.families.Revoke(ctx, session.FamilyID); err != nil {
+		return fmt.Errorf("revoke token family error: %w", err)
+	}
+
+	if err := ss.sessions.Revoke(ctx, session.ID); err != nil {
+		return fmt.Errorf("revoke session error: %w", err)
+	}
+
+	return nil
+}
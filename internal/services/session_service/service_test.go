@@ -0,0 +1,140 @@
+package session_service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/session_service"
+	"testing"
+	"time"
+)
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+var (
+	userID  = "62b1b6c3f0e1a2b3c4d5e6f7"
+	otherID = "62b1b6c3f0e1a2b3c4d5e6f8"
+)
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+type stores struct {
+	sessions    *repositories.MemorySessionRepo
+	families    *repositories.MemoryTokenFamilyRepo
+	revocations *repositories.MemoryRevocationRepo
+}
+
+func (u *unitTestSuit) newStores(sessions ...*models.Session) *stores {
+	s := &stores{
+		sessions:    repositories.NewMemorySessionRepo(),
+		families:    repositories.NewMemoryTokenFamilyRepo(),
+		revocations: repositories.NewMemoryRevocationRepo(),
+	}
+
+	for _, session := range sessions {
+		u.Require().NoError(s.sessions.Create(context.Background(), session))
+		u.Require().NoError(s.families.Create(context.Background(), &models.TokenFamily{
+			ID:        session.FamilyID,
+			UserID:    session.UserID,
+			ExpiresAt: session.ExpiresAt,
+		}))
+	}
+
+	return s
+}
+
+func newSession(id, userID string, lastSeen time.Time) *models.Session {
+	return &models.Session{
+		ID:         id,
+		UserID:     userID,
+		FamilyID:   "family-" + id,
+		LastSeenAt: lastSeen,
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+}
+
+func (u *unitTestSuit) TestGetAll() {
+	now := time.Now()
+	s := u.newStores(
+		newSession("a", userID, now.Add(-time.Hour)),
+		newSession("b", userID, now),
+		newSession("c", otherID, now),
+	)
+	ss := session_service.New(s.sessions, s.families, s.revocations)
+
+	sessions, err := ss.GetAll(context.Background(), userID)
+
+	u.NoError(err)
+	u.Require().Len(sessions, 2)
+	u.Equal("b", sessions[0].ID, "most recently used session must come first")
+	u.Equal("a", sessions[1].ID)
+}
+
+func (u *unitTestSuit) TestRevoke() {
+	s := u.newStores(newSession("a", userID, time.Now()), newSession("b", userID, time.Now()))
+	ss := session_service.New(s.sessions, s.families, s.revocations)
+
+	err := ss.Revoke(context.Background(), userID, "a")
+	u.Require().NoError(err)
+
+	revoked, err := s.revocations.IsRevoked(context.Background(), "a")
+	u.NoError(err)
+	u.True(revoked, "tokens of the session must be revoked")
+
+	family, err := s.families.Get(context.Background(), "family-a")
+	u.NoError(err)
+	u.True(family.Revoked(), "refresh token family must be revoked")
+
+	sessions, err := ss.GetAll(context.Background(), userID)
+	u.NoError(err)
+	u.Require().Len(sessions, 1)
+	u.Equal("b", sessions[0].ID)
+}
+
+func (u *unitTestSuit) TestRevokeOtherUsersSession() {
+	s := u.newStores(newSession("a", otherID, time.Now()))
+	ss := session_service.New(s.sessions, s.families, s.revocations)
+
+	err := ss.Revoke(context.Background(), userID, "a")
+	u.ErrorIs(err, session_service.NotFoundSessionErr)
+
+	err = ss.Revoke(context.Background(), userID, "missing")
+	u.ErrorIs(err, session_service.NotFoundSessionErr)
+
+	revoked, err := s.revocations.IsRevoked(context.Background(), "a")
+	u.NoError(err)
+	u.False(revoked)
+}
+
+func (u *unitTestSuit) TestRevokeAll() {
+	s := u.newStores(
+		newSession("a", userID, time.Now()),
+		newSession("b", userID, time.Now()),
+		newSession("c", otherID, time.Now()),
+	)
+	ss := session_service.New(s.sessions, s.families, s.revocations)
+
+	err := ss.RevokeAll(context.Background(), userID, "b")
+	u.Require().NoError(err)
+
+	sessions, err := ss.GetAll(context.Background(), userID)
+	u.NoError(err)
+	u.Require().Len(sessions, 1)
+	u.Equal("b", sessions[0].ID, "the excepted session must stay")
+
+	err = ss.RevokeAll(context.Background(), userID, "")
+	u.Require().NoError(err)
+
+	sessions, err = ss.GetAll(context.Background(), userID)
+	u.NoError(err)
+	u.Empty(sessions)
+
+	sessions, err = ss.GetAll(context.Background(), otherID)
+	u.NoError(err)
+	u.Len(sessions, 1, "other users must not be logged out")
+}
@@ -2,6 +2,7 @@ package user_service
 
 import (
 	"context"
+	"errors"
 	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
 	"gitlab.com/g6834/team17/auth-service/internal/utils"
@@ -11,6 +12,8 @@ type userService struct {
 	repo interfaces.UserRepo
 }
 
+var WrongPasswordErr = errors.New("current password is wrong")
+
 func New(repo interfaces.UserRepo) *userService {
 	return &userService{
 		repo: repo,
@@ -28,6 +31,27 @@ func (us *userService) GetAll(ctx context.Context) ([]*models.User, error) {
 	return users, nil
 }
 
+func (us *userService) Get(ctx context.Context, id string) (*models.User, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return us.repo.Get(ctx, id)
+}
+
+func (us *userService) Find(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return us.repo.Find(ctx, query)
+}
+
+func (us *userService) Delete(ctx context.Context, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return us.repo.Delete(ctx, id)
+}
+
 func (us *userService) Create(ctx context.Context, user *models.User) (err error) {
 	ctx, span := utils.StartSpan(ctx)
 	defer span.End()
@@ -49,6 +73,25 @@ func (us *userService) UpdatePassword(ctx context.Context, user *models.User) (e
 	ctx, span := utils.StartSpan(ctx)
 	defer span.End()
 
+	user.Password = utils.GetHash([]byte(user.Password))
 	err = us.repo.UpdatePassword(ctx, user)
 	return err
 }
+
+// ChangePassword sets a new password once the current one is confirmed.
+func (us *userService) ChangePassword(ctx context.Context, id, current, password string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	user, err := us.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.CheckPassword([]byte(current), []byte(user.Password)); err != nil {
+		return WrongPasswordErr
+	}
+
+	user.Password = password
+	return us.UpdatePassword(ctx, user)
+}
@@ -8,6 +8,7 @@ import (
 	"gitlab.com/g6834/team17/auth-service/internal/models"
 	"gitlab.com/g6834/team17/auth-service/internal/repositories"
 	"gitlab.com/g6834/team17/auth-service/internal/services/user_service"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"testing"
 )
@@ -17,11 +18,12 @@ type unitTestSuit struct {
 }
 
 var (
-	userName = "test123"
-	user     = models.User{
+	userName     = "test123"
+	passwordHash = "$2a$04$3Fwej2KBe58nKVdo0n9mqugGQrEdwzvJqF1JBUgDI3TLLzntYOW96"
+	user         = models.User{
 		ID:           primitive.ObjectID{},
 		Username:     userName,
-		Password:     "$2a$04$3Fwej2KBe58nKVdo0n9mqugGQrEdwzvJqF1JBUgDI3TLLzntYOW96",
+		Password:     passwordHash,
 		Email:        "user123@ya.ru",
 		FirstName:    "test",
 		LastName:     "123",
@@ -169,3 +171,87 @@ func (u *unitTestSuit) TestUpdatePasswordError() {
 
 	r.AssertExpectations(u.T())
 }
+
+func (u *unitTestSuit) TestUpdatePasswordHashes() {
+	r := new(repositories.MockUserRepository)
+
+	changed := user
+	changed.Password = "new-password"
+	r.On("UpdatePassword", &changed).Return(nil)
+
+	us := user_service.New(r)
+
+	err := us.UpdatePassword(context.Background(), &changed)
+
+	u.Nil(err, "error must be nil")
+	u.NotEqual("new-password", changed.Password, "password must not be stored in plain text")
+	u.NoError(utils.CheckPassword([]byte("new-password"), []byte(changed.Password)))
+
+	r.AssertExpectations(u.T())
+}
+
+func (u *unitTestSuit) TestChangePasswordSuccess() {
+	r := new(repositories.MockUserRepository)
+
+	// UpdatePassword hashes the shared user in place, start from a known hash
+	stored := user
+	stored.Password = passwordHash
+	r.On("Get", stored.ID.Hex()).Return(&stored)
+	r.On("UpdatePassword", &stored).Return(nil)
+
+	us := user_service.New(r)
+
+	err := us.ChangePassword(context.Background(), stored.ID.Hex(), "qwerty", "new-password")
+
+	u.Nil(err, "error must be nil")
+	u.NoError(utils.CheckPassword([]byte("new-password"), []byte(stored.Password)))
+
+	r.AssertExpectations(u.T())
+}
+
+func (u *unitTestSuit) TestChangePasswordWrongCurrent() {
+	r := new(repositories.MockUserRepository)
+
+	// UpdatePassword hashes the shared user in place, start from a known hash
+	stored := user
+	stored.Password = passwordHash
+	r.On("Get", stored.ID.Hex()).Return(&stored)
+
+	us := user_service.New(r)
+
+	err := us.ChangePassword(context.Background(), stored.ID.Hex(), "wrong", "new-password")
+
+	u.ErrorIs(err, user_service.WrongPasswordErr)
+	r.AssertNotCalled(u.T(), "UpdatePassword", &stored)
+}
+
+func (u *unitTestSuit) TestFind() {
+	r := new(repositories.MockUserRepository)
+
+	query := models.UserQuery{UsernamePrefix: "test", Limit: 10}
+	page := &models.UserPage{Users: []*models.User{&user}, NextCursor: "next"}
+	r.On("Find", query).Return(page)
+
+	us := user_service.New(r)
+
+	result, err := us.Find(context.Background(), query)
+
+	u.Nil(err, "error must be nil")
+	u.Equal(page, result)
+
+	r.AssertExpectations(u.T())
+}
+
+func (u *unitTestSuit) TestDeleteError() {
+	r := new(repositories.MockUserRepository)
+
+	r.On("Delete", user.ID.Hex()).Return(repositories.NotFoundUserErr)
+
+	us := user_service.New(r)
+
+	err := us.Delete(context.Background(), user.ID.Hex())
+
+	u.ErrorIs(err, repositories.NotFoundUserErr)
+
+	r.AssertExpectations(u.T())
+}
@@ -0,0 +1,506 @@
+package webauthn_service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+	"gitlab.com/g6834/team17/auth-service/internal/interfaces"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"time"
+)
+
+var (
+	// InvalidResponseErr is returned for authenticator responses that do not
+	// answer a pending ceremony or fail verification.
+	InvalidResponseErr          = errors.New("webauthn response is invalid")
+	NotFoundCredentialErr       = errors.New("webauthn credential not found")
+	DuplicateCredentialErr      = errors.New("webauthn credential is already registered")
+	NoCredentialsErr            = errors.New("user has no webauthn credentials")
+	CloneWarningErr             = fmt.Errorf("%w: signature counter went back, the authenticator may be cloned", InvalidResponseErr)
+	errCeremonyMismatch         = errors.New("response does not answer this ceremony")
+	errCredentialOwnerMismatch  = errors.New("credential belongs to another user")
+	errUserVerificationRequired = errors.New("passwordless login requires user verification")
+)
+
+// Settings describes the relying party. RPID is the domain credentials are
+// scoped to, RPOrigin the origin of the pages running the ceremonies.
+type Settings struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigin      string
+	// Timeout is how long a ceremony waits for the authenticator.
+	Timeout time.Duration
+}
+
+type webauthnService struct {
+	webauthn    *webauthn.WebAuthn
+	credentials interfaces.WebAuthnCredentialRepo
+	sessions    interfaces.WebAuthnSessionRepo
+	users       interfaces.UserRepo
+	events      interfaces.SecurityEvents
+	settings    Settings
+	now         func() time.Time
+}
+
+func New(credentials interfaces.WebAuthnCredentialRepo, sessions interfaces.WebAuthnSessionRepo, users interfaces.UserRepo, events interfaces.SecurityEvents, settings Settings) (*webauthnService, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          settings.RPID,
+		RPDisplayName: settings.RPDisplayName,
+		RPOrigin:      settings.RPOrigin,
+		Timeout:       int(settings.Timeout / time.Millisecond),
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationPreferred,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &webauthnService{
+		webauthn:    w,
+		credentials: credentials,
+		sessions:    sessions,
+		users:       users,
+		events:      events,
+		settings:    settings,
+		now:         time.Now,
+	}, nil
+}
+
+// BeginRegistration returns the options for navigator.credentials.create.
+// Credentials the user has already registered are excluded.
+func (s *webauthnService) BeginRegistration(ctx context.Context, user *models.User) (*protocol.CredentialCreation, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	owner, err := s.owner(ctx, user.ID.Hex(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(owner.credentials))
+	for _, credential := range owner.credentials {
+		exclusions = append(exclusions, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: credential.ID,
+		})
+	}
+
+	options, session, err := s.webauthn.BeginRegistration(owner, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, fmt.Errorf("begin registration error: %w", err)
+	}
+
+	err = s.startSession(ctx, session, &models.WebAuthnSession{
+		Ceremony: models.WebAuthnRegistration,
+		UserID:   user.ID.Hex(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// FinishRegistration checks the response of navigator.credentials.create
+// and stores the new credential under the given name.
+func (s *webauthnService) FinishRegistration(ctx context.Context, user *models.User, name string, response []byte) (*models.WebAuthnCredential, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidResponseErr, err)
+	}
+
+	session, err := s.consumeSession(ctx, parsed.Response.CollectedClientData.Challenge, models.WebAuthnRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != user.ID.Hex() {
+		return nil, fmt.Errorf("%w: %v", InvalidResponseErr, errCeremonyMismatch)
+	}
+
+	owner, err := s.owner(ctx, session.UserID, user)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.webauthn.CreateCredential(owner, s.sessionData(session, parsed.Response.CollectedClientData.Challenge, owner), parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidResponseErr, err)
+	}
+
+	credential := &models.WebAuthnCredential{
+		ID:              base64.RawURLEncoding.EncodeToString(created.ID),
+		UserID:          session.UserID,
+		Name:            name,
+		PublicKey:       created.PublicKey,
+		AttestationType: created.AttestationType,
+		AAGUID:          created.Authenticator.AAGUID,
+		SignCount:       created.Authenticator.SignCount,
+		CreatedAt:       s.now(),
+	}
+	err = s.credentials.Create(ctx, credential)
+	if errors.Is(err, repositories.DuplicateWebAuthnCredentialErr) {
+		return nil, DuplicateCredentialErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create credential error: %w", err)
+	}
+
+	return credential, nil
+}
+
+// BeginLogin returns the options for navigator.credentials.get to confirm
+// a login of the user with a registered credential.
+func (s *webauthnService) BeginLogin(ctx context.Context, userID string) (*protocol.CredentialAssertion, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	owner, err := s.owner(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(owner.credentials) == 0 {
+		return nil, NoCredentialsErr
+	}
+
+	options, session, err := s.webauthn.BeginLogin(owner)
+	if err != nil {
+		return nil, fmt.Errorf("begin login error: %w", err)
+	}
+
+	err = s.startSession(ctx, session, &models.WebAuthnSession{
+		Ceremony:             models.WebAuthnLogin,
+		UserID:               userID,
+		AllowedCredentialIDs: session.AllowedCredentialIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// BeginPasswordless returns the options for navigator.credentials.get to
+// log in with a credential alone. With a username the credentials of the
+// account are offered, without one the authenticator offers its passkeys.
+// Unknown usernames get the same options as no username, so they cannot be
+// told apart from accounts without credentials.
+func (s *webauthnService) BeginPasswordless(ctx context.Context, tenant, username string) (*protocol.CredentialAssertion, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	challenge, err := protocol.CreateChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("create challenge error: %w", err)
+	}
+
+	options := protocol.PublicKeyCredentialRequestOptions{
+		Challenge:        challenge,
+		Timeout:          s.webauthn.Config.Timeout,
+		RelyingPartyID:   s.webauthn.Config.RPID,
+		UserVerification: protocol.VerificationRequired,
+	}
+
+	if username != "" {
+		allowed, err := s.allowedCredentials(ctx, tenant, username)
+		if err != nil {
+			return nil, err
+		}
+		options.AllowedCredentials = allowed
+	}
+
+	err = s.startSession(ctx, &webauthn.SessionData{
+		Challenge:            base64.RawURLEncoding.EncodeToString(challenge),
+		AllowedCredentialIDs: options.GetAllowedCredentialIDs(),
+		UserVerification:     options.UserVerification,
+	}, &models.WebAuthnSession{
+		Ceremony:             models.WebAuthnLogin,
+		Tenant:               tenant,
+		AllowedCredentialIDs: options.GetAllowedCredentialIDs(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &protocol.CredentialAssertion{Response: options}, nil
+}
+
+// Login checks the response to BeginPasswordless and returns the owner of
+// the credential with the tenant to log in to.
+func (s *webauthnService) Login(ctx context.Context, response []byte) (string, string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(response))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", InvalidResponseErr, err)
+	}
+
+	session, err := s.consumeSession(ctx, parsed.Response.CollectedClientData.Challenge, models.WebAuthnLogin)
+	if err != nil {
+		return "", "", err
+	}
+	if session.UserID != "" {
+		return "", "", fmt.Errorf("%w: %v", InvalidResponseErr, errCeremonyMismatch)
+	}
+	if session.UserVerification != string(protocol.VerificationRequired) {
+		return "", "", fmt.Errorf("%w: %v", InvalidResponseErr, errUserVerificationRequired)
+	}
+
+	credential, err := s.credentials.Get(ctx, base64.RawURLEncoding.EncodeToString(parsed.RawID))
+	if errors.Is(err, repositories.NotFoundWebAuthnCredentialErr) {
+		return "", "", fmt.Errorf("%w: %v", InvalidResponseErr, err)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("get credential error: %w", err)
+	}
+
+	if err := s.assert(ctx, credential.UserID, session, parsed); err != nil {
+		return "", "", err
+	}
+
+	return credential.UserID, session.Tenant, nil
+}
+
+// Methods reports webauthn as second factor of users with a credential.
+func (s *webauthnService) Methods(ctx context.Context, userID string) ([]string, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	credentials, err := s.credentials.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) == 0 {
+		return nil, nil
+	}
+
+	return []string{models.MFAMethodWebAuthn}, nil
+}
+
+// Verify accepts the response to BeginLogin of the user as second factor,
+// the code is the JSON encoded PublicKeyCredential.
+func (s *webauthnService) Verify(ctx context.Context, userID, code string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader([]byte(code)))
+	if err != nil {
+		return models.InvalidMFACodeErr
+	}
+
+	session, err := s.consumeSession(ctx, parsed.Response.CollectedClientData.Challenge, models.WebAuthnLogin)
+	if errors.Is(err, InvalidResponseErr) {
+		return models.InvalidMFACodeErr
+	}
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return models.InvalidMFACodeErr
+	}
+
+	err = s.assert(ctx, userID, session, parsed)
+	if errors.Is(err, InvalidResponseErr) {
+		return fmt.Errorf("%w: %v", models.InvalidMFACodeErr, err)
+	}
+
+	return err
+}
+
+func (s *webauthnService) Credentials(ctx context.Context, userID string) ([]*models.WebAuthnCredential, error) {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	return s.credentials.GetByUser(ctx, userID)
+}
+
+func (s *webauthnService) DeleteCredential(ctx context.Context, userID, id string) error {
+	ctx, span := utils.StartSpan(ctx)
+	defer span.End()
+
+	err := s.credentials.Delete(ctx, userID, id)
+	if errors.Is(err, repositories.NotFoundWebAuthnCredentialErr) {
+		return NotFoundCredentialErr
+	}
+
+	return err
+}
+
+// assert verifies the assertion against the credentials of the user and
+// records the signature counter. A counter that went back is reported and
+// the assertion rejected, two copies of the private key may be in use.
+func (s *webauthnService) assert(ctx context.Context, userID string, session *models.WebAuthnSession, parsed *protocol.ParsedCredentialAssertionData) error {
+	owner, err := s.owner(ctx, userID, nil)
+	if err != nil {
+		return err
+	}
+
+	used, err := s.webauthn.ValidateLogin(owner, s.sessionData(session, parsed.Response.CollectedClientData.Challenge, owner), parsed)
+	if err != nil {
+		return fmt.Errorf("%w: %v", InvalidResponseErr, err)
+	}
+
+	id := base64.RawURLEncoding.EncodeToString(used.ID)
+	if used.Authenticator.CloneWarning {
+		s.events.Emit(ctx, &models.SecurityEvent{
+			Type:   models.SecurityEventWebAuthnCloneWarning,
+			UserID: userID,
+			Time:   s.now(),
+			Metadata: map[string]string{
+				"credential_id": id,
+				"ip":            utils.ClientInfo(ctx).IP,
+				"user_agent":    utils.ClientInfo(ctx).UserAgent,
+			},
+		})
+		return CloneWarningErr
+	}
+
+	if err := s.credentials.UpdateSignCount(ctx, id, used.Authenticator.SignCount, s.now()); err != nil {
+		return fmt.Errorf("update sign count error: %w", err)
+	}
+
+	return nil
+}
+
+// allowedCredentials returns the credentials of the account, looked up like
+// a password login, or none for unknown accounts.
+func (s *webauthnService) allowedCredentials(ctx context.Context, tenant, username string) ([]protocol.CredentialDescriptor, error) {
+	user, err := s.users.GetByName(ctx, tenant, username)
+	if err != nil && tenant != "" {
+		user, err = s.users.GetByName(ctx, "", username)
+	}
+	if errors.Is(err, repositories.NotFoundUserErr) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user error: %w", err)
+	}
+
+	owner, err := s.owner(ctx, user.ID.Hex(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make([]protocol.CredentialDescriptor, 0, len(owner.credentials))
+	for _, credential := range owner.credentials {
+		allowed = append(allowed, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: credential.ID,
+		})
+	}
+
+	return allowed, nil
+}
+
+func (s *webauthnService) startSession(ctx context.Context, data *webauthn.SessionData, session *models.WebAuthnSession) error {
+	session.ID = utils.HashToken(data.Challenge)
+	session.UserVerification = string(data.UserVerification)
+	session.ExpiresAt = s.now().Add(s.settings.Timeout)
+
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return fmt.Errorf("create webauthn session error: %w", err)
+	}
+
+	return nil
+}
+
+// consumeSession finds the ceremony by the challenge the authenticator
+// signed, so no other state has to travel with the response.
+func (s *webauthnService) consumeSession(ctx context.Context, challenge string, ceremony models.WebAuthnCeremony) (*models.WebAuthnSession, error) {
+	session, err := s.sessions.Consume(ctx, utils.HashToken(challenge))
+	if errors.Is(err, repositories.NotFoundWebAuthnSessionErr) {
+		return nil, fmt.Errorf("%w: %v", InvalidResponseErr, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webauthn session error: %w", err)
+	}
+	if session.Ceremony != ceremony || !s.now().Before(session.ExpiresAt) {
+		return nil, fmt.Errorf("%w: %v", InvalidResponseErr, errCeremonyMismatch)
+	}
+
+	return session, nil
+}
+
+func (s *webauthnService) sessionData(session *models.WebAuthnSession, challenge string, owner *credentialOwner) webauthn.SessionData {
+	return webauthn.SessionData{
+		Challenge:            challenge,
+		UserID:               owner.WebAuthnID(),
+		AllowedCredentialIDs: session.AllowedCredentialIDs,
+		UserVerification:     protocol.UserVerificationRequirement(session.UserVerification),
+	}
+}
+
+// owner loads the credentials of the user, user may be nil when only the
+// id is known.
+func (s *webauthnService) owner(ctx context.Context, userID string, user *models.User) (*credentialOwner, error) {
+	stored, err := s.credentials.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get credentials error: %w", err)
+	}
+
+	owner := &credentialOwner{id: userID, user: user}
+	for _, credential := range stored {
+		id, err := base64.RawURLEncoding.DecodeString(credential.ID)
+		if err != nil {
+			return nil, fmt.Errorf("credential %s: %w", credential.ID, err)
+		}
+		owner.credentials = append(owner.credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    credential.AAGUID,
+				SignCount: credential.SignCount,
+			},
+		})
+	}
+
+	return owner, nil
+}
+
+// credentialOwner presents a user to the webauthn library, the user handle
+// is the hex user id.
+type credentialOwner struct {
+	id          string
+	user        *models.User
+	credentials []webauthn.Credential
+}
+
+func (o *credentialOwner) WebAuthnID() []byte {
+	return []byte(o.id)
+}
+
+func (o *credentialOwner) WebAuthnName() string {
+	if o.user == nil {
+		return o.id
+	}
+
+	return o.user.Username
+}
+
+func (o *credentialOwner) WebAuthnDisplayName() string {
+	if o.user == nil || o.user.FirstName == "" && o.user.LastName == "" {
+		return o.WebAuthnName()
+	}
+
+	return o.user.FirstName + " " + o.user.LastName
+}
+
+func (o *credentialOwner) WebAuthnIcon() string {
+	return ""
+}
+
+func (o *credentialOwner) WebAuthnCredentials() []webauthn.Credential {
+	return o.credentials
+}
@@ -0,0 +1,354 @@
+package webauthn_service_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
+	"gitlab.com/g6834/team17/auth-service/internal/services/webauthn_service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+const (
+	rpID     = "localhost"
+	rpOrigin = "http://localhost:3000"
+)
+
+var user = &models.User{
+	ID:       primitive.NewObjectID(),
+	Username: "test123",
+}
+
+type unitTestSuit struct {
+	suite.Suite
+}
+
+func TestUnitTestSuite(t *testing.T) {
+	suite.Run(t, &unitTestSuit{})
+}
+
+type recordedEvents struct {
+	events []*models.SecurityEvent
+}
+
+func (r *recordedEvents) Emit(_ context.Context, event *models.SecurityEvent) {
+	r.events = append(r.events, event)
+}
+
+func newService(u *unitTestSuit, users *repositories.MockUserRepository, events *recordedEvents) interface {
+	BeginRegistration(ctx context.Context, user *models.User) (*protocol.CredentialCreation, error)
+	FinishRegistration(ctx context.Context, user *models.User, name string, response []byte) (*models.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context, userID string) (*protocol.CredentialAssertion, error)
+	BeginPasswordless(ctx context.Context, tenant, username string) (*protocol.CredentialAssertion, error)
+	Login(ctx context.Context, response []byte) (string, string, error)
+	Methods(ctx context.Context, userID string) ([]string, error)
+	Verify(ctx context.Context, userID, code string) error
+	Credentials(ctx context.Context, userID string) ([]*models.WebAuthnCredential, error)
+	DeleteCredential(ctx context.Context, userID, id string) error
+} {
+	s, err := webauthn_service.New(repositories.NewMemoryWebAuthnCredentialRepo(), repositories.NewMemoryWebAuthnSessionRepo(), users, events, webauthn_service.Settings{
+		RPID:          rpID,
+		RPDisplayName: "auth-service",
+		RPOrigin:      rpOrigin,
+		Timeout:       time.Minute,
+	})
+	u.Require().NoError(err)
+
+	return s
+}
+
+// authenticator is a software security key with an ES256 key and no
+// attestation.
+type authenticator struct {
+	u       *unitTestSuit
+	key     *ecdsa.PrivateKey
+	id      []byte
+	counter uint32
+}
+
+func newAuthenticator(u *unitTestSuit) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	u.Require().NoError(err)
+
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	u.Require().NoError(err)
+
+	return &authenticator{u: u, key: key, id: id}
+}
+
+func (a *authenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.counter)
+
+	return append(data, attested...)
+}
+
+func (a *authenticator) clientData(ceremony protocol.CeremonyType, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      string(ceremony),
+		"challenge": challenge,
+		"origin":    rpOrigin,
+	})
+	a.u.Require().NoError(err)
+
+	return data
+}
+
+// create answers navigator.credentials.create.
+func (a *authenticator) create(options *protocol.CredentialCreation) []byte {
+	publicKey, err := cbor.Marshal(map[int]interface{}{
+		1:  2,  // EC2
+		3:  -7, // ES256
+		-1: 1,  // P-256
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	a.u.Require().NoError(err)
+
+	attested := make([]byte, 18, 18+len(a.id)+len(publicKey))
+	binary.BigEndian.PutUint16(attested[16:], uint16(len(a.id)))
+	attested = append(append(attested, a.id...), publicKey...)
+
+	attestation, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(0x45, attested),
+	})
+	a.u.Require().NoError(err)
+
+	return a.credential(map[string]string{
+		"attestationObject": encode(attestation),
+		"clientDataJSON":    encode(a.clientData(protocol.CreateCeremony, options.Response.Challenge.String())),
+	})
+}
+
+// get answers navigator.credentials.get, signing with the next counter.
+func (a *authenticator) get(options *protocol.CredentialAssertion) []byte {
+	a.counter++
+	authData := a.authData(0x05, nil)
+	clientData := a.clientData(protocol.AssertCeremony, options.Response.Challenge.String())
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	a.u.Require().NoError(err)
+
+	return a.credential(map[string]string{
+		"authenticatorData": encode(authData),
+		"clientDataJSON":    encode(clientData),
+		"signature":         encode(signature),
+	})
+}
+
+func (a *authenticator) credential(response map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       encode(a.id),
+		"rawId":    encode(a.id),
+		"type":     "public-key",
+		"response": response,
+	})
+	a.u.Require().NoError(err)
+
+	return data
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func register(u *unitTestSuit, s interface {
+	BeginRegistration(ctx context.Context, user *models.User) (*protocol.CredentialCreation, error)
+	FinishRegistration(ctx context.Context, user *models.User, name string, response []byte) (*models.WebAuthnCredential, error)
+}, key *authenticator) *models.WebAuthnCredential {
+	ctx := context.Background()
+
+	options, err := s.BeginRegistration(ctx, user)
+	u.Require().NoError(err)
+
+	credential, err := s.FinishRegistration(ctx, user, "YubiKey", key.create(options))
+	u.Require().NoError(err)
+
+	return credential
+}
+
+func (u *unitTestSuit) TestRegistration() {
+	s := newService(u, new(repositories.MockUserRepository), &recordedEvents{})
+	ctx := context.Background()
+	key := newAuthenticator(u)
+
+	methods, err := s.Methods(ctx, user.ID.Hex())
+	u.NoError(err)
+	u.Empty(methods)
+
+	credential := register(u, s, key)
+	u.Equal(encode(key.id), credential.ID)
+	u.Equal(user.ID.Hex(), credential.UserID)
+	u.Equal("YubiKey", credential.Name)
+	u.Equal("none", credential.AttestationType)
+
+	methods, err = s.Methods(ctx, user.ID.Hex())
+	u.NoError(err)
+	u.Equal([]string{models.MFAMethodWebAuthn}, methods)
+
+	options, err := s.BeginRegistration(ctx, user)
+	u.Require().NoError(err)
+	u.Require().Len(options.Response.CredentialExcludeList, 1, "registered credentials must be excluded")
+	u.Equal(key.id, []byte(options.Response.CredentialExcludeList[0].CredentialID))
+
+	_, err = s.FinishRegistration(ctx, user, "again", key.create(options))
+	u.ErrorIs(err, webauthn_service.DuplicateCredentialErr)
+}
+
+func (u *unitTestSuit) TestRegistrationChallenge() {
+	s := newService(u, new(repositories.MockUserRepository), &recordedEvents{})
+	ctx := context.Background()
+	key := newAuthenticator(u)
+
+	options, err := s.BeginRegistration(ctx, user)
+	u.Require().NoError(err)
+	response := key.create(options)
+
+	other := &models.User{ID: primitive.NewObjectID(), Username: "other"}
+	_, err = s.FinishRegistration(ctx, other, "YubiKey", response)
+	u.ErrorIs(err, webauthn_service.InvalidResponseErr, "ceremony of another user")
+
+	_, err = s.FinishRegistration(ctx, user, "YubiKey", response)
+	u.ErrorIs(err, webauthn_service.InvalidResponseErr, "challenge is single use")
+
+	forged := key.create(&protocol.CredentialCreation{Response: protocol.PublicKeyCredentialCreationOptions{Challenge: []byte("not issued")}})
+	_, err = s.FinishRegistration(ctx, user, "YubiKey", forged)
+	u.ErrorIs(err, webauthn_service.InvalidResponseErr, "unknown challenge")
+
+	_, err = s.FinishRegistration(ctx, user, "YubiKey", []byte("{}"))
+	u.ErrorIs(err, webauthn_service.InvalidResponseErr)
+}
+
+func (u *unitTestSuit) TestSecondFactor() {
+	s := newService(u, new(repositories.MockUserRepository), &recordedEvents{})
+	ctx := context.Background()
+	key := newAuthenticator(u)
+	register(u, s, key)
+
+	u.ErrorIs(s.Verify(ctx, user.ID.Hex(), "123456"), models.InvalidMFACodeErr, "totp codes are not for this factor")
+
+	options, err := s.BeginLogin(ctx, user.ID.Hex())
+	u.Require().NoError(err)
+	u.Require().Len(options.Response.AllowedCredentials, 1)
+
+	response := string(key.get(options))
+	u.ErrorIs(s.Verify(ctx, primitive.NewObjectID().Hex(), response), models.InvalidMFACodeErr, "ceremony of another user")
+
+	options, err = s.BeginLogin(ctx, user.ID.Hex())
+	u.Require().NoError(err)
+	response = string(key.get(options))
+	u.NoError(s.Verify(ctx, user.ID.Hex(), response))
+	u.ErrorIs(s.Verify(ctx, user.ID.Hex(), response), models.InvalidMFACodeErr, "assertion is single use")
+
+	credentials, err := s.Credentials(ctx, user.ID.Hex())
+	u.Require().NoError(err)
+	u.Require().Len(credentials, 1)
+	u.Equal(key.counter, credentials[0].SignCount)
+	u.False(credentials[0].LastUsedAt.IsZero())
+
+	_, err = s.BeginLogin(ctx, primitive.NewObjectID().Hex())
+	u.ErrorIs(err, webauthn_service.NoCredentialsErr)
+}
+
+func (u *unitTestSuit) TestPasswordless() {
+	users := new(repositories.MockUserRepository)
+	users.On("GetByName", "", user.Username).Return(user, nil)
+	users.On("GetByName", "", "unknown").Return(nil, repositories.NotFoundUserErr)
+
+	s := newService(u, users, &recordedEvents{})
+	ctx := context.Background()
+	key := newAuthenticator(u)
+	register(u, s, key)
+
+	options, err := s.BeginPasswordless(ctx, "", "")
+	u.Require().NoError(err)
+	u.Empty(options.Response.AllowedCredentials, "discoverable login offers no credentials")
+	u.Equal(protocol.VerificationRequired, options.Response.UserVerification)
+
+	userID, tenant, err := s.Login(ctx, key.get(options))
+	u.Require().NoError(err)
+	u.Equal(user.ID.Hex(), userID)
+	u.Empty(tenant)
+
+	options, err = s.BeginPasswordless(ctx, "", user.Username)
+	u.Require().NoError(err)
+	u.Require().Len(options.Response.AllowedCredentials, 1)
+
+	userID, _, err = s.Login(ctx, key.get(options))
+	u.Require().NoError(err)
+	u.Equal(user.ID.Hex(), userID)
+
+	unknown, err := s.BeginPasswordless(ctx, "", "unknown")
+	u.Require().NoError(err)
+	u.Empty(unknown.Response.AllowedCredentials, "unknown users look like users without credentials")
+
+	userID, _, err = s.Login(ctx, key.get(unknown))
+	u.Require().NoError(err)
+	u.Equal(user.ID.Hex(), userID, "the credential names the user, not the username")
+
+	options, err = s.BeginPasswordless(ctx, "", "")
+	u.Require().NoError(err)
+	_, _, err = s.Login(ctx, newAuthenticator(u).get(options))
+	u.ErrorIs(err, webauthn_service.InvalidResponseErr, "unregistered credential")
+
+	second, err := s.BeginLogin(ctx, user.ID.Hex())
+	u.Require().NoError(err)
+	_, _, err = s.Login(ctx, key.get(second))
+	u.ErrorIs(err, webauthn_service.InvalidResponseErr, "second factor ceremony is no passwordless login")
+}
+
+func (u *unitTestSuit) TestCloneWarning() {
+	events := &recordedEvents{}
+	s := newService(u, new(repositories.MockUserRepository), events)
+	ctx := context.Background()
+	key := newAuthenticator(u)
+	register(u, s, key)
+
+	options, err := s.BeginPasswordless(ctx, "", "")
+	u.Require().NoError(err)
+	_, _, err = s.Login(ctx, key.get(options))
+	u.Require().NoError(err)
+
+	// a copy of the key still at the old counter
+	key.counter = 0
+	options, err = s.BeginPasswordless(ctx, "", "")
+	u.Require().NoError(err)
+	_, _, err = s.Login(ctx, key.get(options))
+	u.ErrorIs(err, webauthn_service.CloneWarningErr)
+
+	u.Require().Len(events.events, 1)
+	u.Equal(models.SecurityEventWebAuthnCloneWarning, events.events[0].Type)
+	u.Equal(user.ID.Hex(), events.events[0].UserID)
+	u.Equal(encode(key.id), events.events[0].Metadata["credential_id"])
+}
+
+func (u *unitTestSuit) TestDeleteCredential() {
+	s := newService(u, new(repositories.MockUserRepository), &recordedEvents{})
+	ctx := context.Background()
+	credential := register(u, s, newAuthenticator(u))
+
+	u.ErrorIs(s.DeleteCredential(ctx, primitive.NewObjectID().Hex(), credential.ID), webauthn_service.NotFoundCredentialErr, "credential of another user")
+	u.NoError(s.DeleteCredential(ctx, user.ID.Hex(), credential.ID))
+	u.ErrorIs(s.DeleteCredential(ctx, user.ID.Hex(), credential.ID), webauthn_service.NotFoundCredentialErr)
+
+	methods, err := s.Methods(ctx, user.ID.Hex())
+	u.NoError(err)
+	u.Empty(methods)
+}
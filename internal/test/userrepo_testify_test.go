@@ -2,7 +2,9 @@ package test
 
 import (
 	"context"
+	"fmt"
 	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/repositories"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 )
@@ -50,6 +52,29 @@ func (tc *TestContainersSuite) TestUserRepoCreateDuplicateName() {
 	tc.NotNil(err)
 }
 
+func (tc *TestContainersSuite) TestUserRepoTenantScopedNames() {
+	tenantUser := &models.User{
+		Username: user.Username,
+		Password: user.Password,
+		Email:    user.Email,
+		Tenant:   "acme",
+	}
+
+	err := tc.userRepo.Insert(context.Background(), tenantUser)
+	tc.Require().NoError(err, "names are unique per tenant")
+
+	err = tc.userRepo.Insert(context.Background(), tenantUser)
+	tc.ErrorIs(err, repositories.DuplicateUserErr)
+
+	dbUser, err := tc.userRepo.GetByName(context.Background(), "acme", user.Username)
+	tc.Require().NoError(err)
+	tc.Equal("acme", dbUser.Tenant)
+
+	dbUser, err = tc.userRepo.GetByName(context.Background(), "", user.Username)
+	tc.Require().NoError(err)
+	tc.Equal(user.ID, dbUser.ID)
+}
+
 func (tc *TestContainersSuite) TestUserRepoGetAllSuccess() {
 	users, err := tc.userRepo.GetAll(context.Background())
 
@@ -61,7 +86,7 @@ func (tc *TestContainersSuite) TestUserRepoGetAllSuccess() {
 }
 
 func (tc *TestContainersSuite) TestUserRepoGetByNameSuccess() {
-	dbUser, err := tc.userRepo.GetByName(context.Background(), userName)
+	dbUser, err := tc.userRepo.GetByName(context.Background(), "", userName)
 
 	tc.NoError(err)
 	tc.NotNil(user, "user must be not nil")
@@ -108,7 +133,7 @@ func (tc *TestContainersSuite) TestUserRepoUpdateSuccess() {
 	err := tc.userRepo.Update(context.Background(), newUser)
 	tc.Nil(err)
 
-	dbUser, err := tc.userRepo.GetByName(context.Background(), user.Username)
+	dbUser, err := tc.userRepo.GetByName(context.Background(), "", user.Username)
 
 	tc.Equal(dbUser.FirstName, newUser.FirstName)
 	tc.Equal(dbUser.LastName, newUser.LastName)
@@ -118,3 +143,83 @@ func (tc *TestContainersSuite) TestUserRepoUpdateSuccess() {
 	tc.NotEqualValues(dbUser.LastName, user.LastName)
 	tc.NotEqualValues(dbUser.Email, user.Email)
 }
+
+func (tc *TestContainersSuite) TestUserRepoUpdateTenantAccount() {
+	for _, tenant := range []string{"", "globex"} {
+		err := tc.userRepo.Insert(context.Background(), &models.User{
+			Username: "shared",
+			Password: user.Password,
+			Email:    "shared@ya.ru",
+			Tenant:   tenant,
+		})
+		tc.Require().NoError(err)
+	}
+
+	tenantUser, err := tc.userRepo.GetByName(context.Background(), "globex", "shared")
+	tc.Require().NoError(err)
+	tenantUser.FirstName = "globex"
+
+	err = tc.userRepo.Update(context.Background(), tenantUser)
+	tc.Require().NoError(err)
+
+	dbUser, err := tc.userRepo.GetByName(context.Background(), "globex", "shared")
+	tc.Require().NoError(err)
+	tc.Equal("globex", dbUser.FirstName)
+
+	dbUser, err = tc.userRepo.GetByName(context.Background(), "", "shared")
+	tc.Require().NoError(err)
+	tc.Empty(dbUser.FirstName, "the account of another tenant with the same name must not change")
+}
+
+func (tc *TestContainersSuite) TestUserRepoFindPages() {
+	for i := 0; i < 3; i++ {
+		err := tc.userRepo.Insert(context.Background(), &models.User{
+			Username: fmt.Sprintf("page%d", i),
+			Password: user.Password,
+			Email:    fmt.Sprintf("page%d@ya.ru", i),
+		})
+		tc.Require().NoError(err)
+	}
+
+	query := models.UserQuery{UsernamePrefix: "page", SortBy: models.UserSortUsername, Desc: true, Limit: 2}
+	page, err := tc.userRepo.Find(context.Background(), query)
+	tc.Require().NoError(err)
+	tc.Require().Len(page.Users, 2)
+	tc.Equal("page2", page.Users[0].Username)
+	tc.Equal("page1", page.Users[1].Username)
+	tc.NotEmpty(page.NextCursor)
+
+	query.Cursor = page.NextCursor
+	page, err = tc.userRepo.Find(context.Background(), query)
+	tc.Require().NoError(err)
+	tc.Require().Len(page.Users, 1)
+	tc.Equal("page0", page.Users[0].Username)
+	tc.Empty(page.NextCursor, "last page must not have a cursor")
+}
+
+func (tc *TestContainersSuite) TestUserRepoFindInvalidCursor() {
+	_, err := tc.userRepo.Find(context.Background(), models.UserQuery{Cursor: "garbage"})
+
+	tc.ErrorIs(err, repositories.InvalidCursorErr)
+}
+
+func (tc *TestContainersSuite) TestUserRepoDelete() {
+	err := tc.userRepo.Insert(context.Background(), &models.User{
+		Username: "deleted",
+		Password: user.Password,
+		Email:    "deleted@ya.ru",
+	})
+	tc.Require().NoError(err)
+
+	dbUser, err := tc.userRepo.GetByName(context.Background(), "", "deleted")
+	tc.Require().NoError(err)
+
+	err = tc.userRepo.Delete(context.Background(), dbUser.ID.Hex())
+	tc.NoError(err)
+
+	_, err = tc.userRepo.Get(context.Background(), dbUser.ID.Hex())
+	tc.ErrorIs(err, repositories.NotFoundUserErr)
+
+	err = tc.userRepo.Delete(context.Background(), dbUser.ID.Hex())
+	tc.ErrorIs(err, repositories.NotFoundUserErr)
+}
@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+)
+
+// ClientInfo returns the device the request comes from, empty outside of
+// HTTP requests.
+func ClientInfo(ctx context.Context) models.ClientInfo {
+	info, _ := ctx.Value(constants.CTX_CLIENT_INFO).(models.ClientInfo)
+	return info
+}
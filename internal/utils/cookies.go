@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"net/http"
+	"time"
+)
+
+// InsecureCookies drops the Secure attribute of the token cookies so browsers
+// send them over plain HTTP, for local development only.
+var InsecureCookies bool
+
+// SetTokenCookies stores the pair in cookies expiring together with the tokens.
+func SetTokenCookies(w http.ResponseWriter, td *models.TokenDetails) {
+	http.SetCookie(w, tokenCookie(constants.ACCESS_TOKEN, td.AccessToken, td.AtExpires))
+	http.SetCookie(w, tokenCookie(constants.REFRESH_TOKEN, td.RefreshToken, td.RtExpires))
+}
+
+// ClearTokenCookies removes both token cookies.
+func ClearTokenCookies(w http.ResponseWriter) {
+	http.SetCookie(w, tokenCookie(constants.ACCESS_TOKEN, "", time.Unix(0, 0)))
+	http.SetCookie(w, tokenCookie(constants.REFRESH_TOKEN, "", time.Unix(0, 0)))
+}
+
+// tokenCookie keeps the attributes of setting and clearing a cookie equal,
+// browsers may not replace a cookie otherwise. Scripts never read the tokens,
+// the login responses carry them in the body.
+func tokenCookie(name, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   !InsecureCookies,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,51 @@
+package utils_test
+
+import (
+	"gitlab.com/g6834/team17/auth-service/internal/models"
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenCookiesAttributes(t *testing.T) {
+	set := httptest.NewRecorder()
+	utils.SetTokenCookies(set, &models.TokenDetails{
+		AccessToken:  "at",
+		RefreshToken: "rt",
+		AtExpires:    time.Now().Add(time.Minute),
+		RtExpires:    time.Now().Add(time.Hour),
+	})
+	cleared := httptest.NewRecorder()
+	utils.ClearTokenCookies(cleared)
+
+	setCookies, clearedCookies := set.Result().Cookies(), cleared.Result().Cookies()
+	if len(setCookies) != 2 || len(clearedCookies) != 2 {
+		t.Fatalf("want both token cookies, got %d set and %d cleared", len(setCookies), len(clearedCookies))
+	}
+	for i, cookie := range setCookies {
+		if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+			t.Errorf("cookie %s must be Secure, HttpOnly and SameSite=Lax", cookie.Name)
+		}
+		clear := clearedCookies[i]
+		if clear.Name != cookie.Name || clear.Path != cookie.Path || clear.Secure != cookie.Secure ||
+			clear.HttpOnly != cookie.HttpOnly || clear.SameSite != cookie.SameSite {
+			t.Errorf("cookie %s is cleared with other attributes than it is set with", cookie.Name)
+		}
+	}
+}
+
+func TestInsecureCookies(t *testing.T) {
+	utils.InsecureCookies = true
+	defer func() { utils.InsecureCookies = false }()
+
+	rec := httptest.NewRecorder()
+	utils.ClearTokenCookies(rec)
+
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Secure {
+			t.Errorf("cookie %s must not be Secure for local development", cookie.Name)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package utils
+
+// MatchGlob reports whether value matches pattern, * matches any run of characters.
+func MatchGlob(pattern, value string) bool {
+	// position of the last * and the value offset it was tried at, to backtrack to
+	star, retry := -1, 0
+	p, v := 0, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, retry = p, v
+			p++
+		case p < len(pattern) && pattern[p] == value[v]:
+			p++
+			v++
+		case star >= 0:
+			retry++
+			p, v = star+1, retry
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// TrustedProxies are the networks of the reverse proxies in front of the
+// service. Only their X-Forwarded-For and X-Real-IP headers are believed,
+// any other caller could send a different address with every request.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies reads addresses and CIDR ranges such as 10.0.0.1 or
+// 10.0.0.0/8.
+func ParseTrustedProxies(values []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q is not an address or CIDR range", value)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an address or CIDR range", value)
+		}
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+func (t TrustedProxies) trusts(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the address of the client behind remoteAddr, the peer of
+// the connection. While the peer is a trusted proxy the forwarded addresses
+// are followed from the right, the first one not trusted is the client.
+// X-Real-IP is only used without X-Forwarded-For.
+func (t TrustedProxies) ClientIP(remoteAddr string, forwardedFor []string, realIP string) string {
+	ip := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		ip = host
+	}
+	if !t.trusts(ip) {
+		return ip
+	}
+
+	var hops []string
+	for _, header := range forwardedFor {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	if len(hops) == 0 {
+		if realIP = strings.TrimSpace(realIP); net.ParseIP(realIP) != nil {
+			return realIP
+		}
+		return ip
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			// a malformed hop was not written by a trusted proxy
+			return ip
+		}
+		ip = hops[i]
+		if !t.trusts(ip) {
+			return ip
+		}
+	}
+
+	return ip
+}
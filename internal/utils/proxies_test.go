@@ -0,0 +1,48 @@
+package utils_test
+
+import (
+	"gitlab.com/g6834/team17/auth-service/internal/utils"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := utils.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.0.1", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		want         string
+	}{
+		"direct":                 {remoteAddr: "203.0.113.7:5000", want: "203.0.113.7"},
+		"untrusted peer":         {remoteAddr: "203.0.113.7:5000", forwardedFor: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.7"},
+		"trusted proxy":          {remoteAddr: "10.1.2.3:5000", forwardedFor: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		"spoofed leftmost hop":   {remoteAddr: "10.1.2.3:5000", forwardedFor: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		"proxy chain":            {remoteAddr: "10.1.2.3:5000", forwardedFor: []string{"198.51.100.1, 192.168.0.1", "10.9.9.9"}, want: "198.51.100.1"},
+		"real ip":                {remoteAddr: "192.168.0.1:5000", realIP: "198.51.100.2", want: "198.51.100.2"},
+		"malformed hop":          {remoteAddr: "10.1.2.3:5000", forwardedFor: []string{"198.51.100.1, garbage"}, want: "10.1.2.3"},
+		"only proxies":           {remoteAddr: "10.1.2.3:5000", forwardedFor: []string{"10.0.0.2"}, want: "10.0.0.2"},
+		"ipv6 proxy":             {remoteAddr: "[fd00::1]:5000", forwardedFor: []string{"2001:db8::1"}, want: "2001:db8::1"},
+		"address without a port": {remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+	} {
+		if got := proxies.ClientIP(tc.remoteAddr, tc.forwardedFor, tc.realIP); got != tc.want {
+			t.Errorf("%s: got %s, want %s", name, got, tc.want)
+		}
+	}
+
+	var none utils.TrustedProxies
+	if got := none.ClientIP("10.1.2.3:5000", []string{"198.51.100.1"}, "198.51.100.2"); got != "10.1.2.3" {
+		t.Errorf("without trusted proxies forwarded addresses must be ignored, got %s", got)
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, value := range []string{"proxy", "10.0.0.0/33", ""} {
+		if _, err := utils.ParseTrustedProxies([]string{value}); err == nil {
+			t.Errorf("%q must be rejected", value)
+		}
+	}
+}
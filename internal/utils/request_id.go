@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"context"
+	"gitlab.com/g6834/team17/auth-service/internal/constants"
+)
+
+// RequestID returns the id of the REST request, empty outside of HTTP
+// requests.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(constants.CTX_REQUEST_ID).(string)
+	return id
+}